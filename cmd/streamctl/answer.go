@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/faraz/streamctl/internal/store"
+)
+
+func runAnswer(st *store.Store) {
+	if len(os.Args) < 4 {
+		fmt.Fprintln(os.Stderr, `Usage: streamctl answer PROJECT/NAME "ANSWER" [--as NAME]`)
+		os.Exit(1)
+	}
+
+	by := os.Getenv("USER")
+	for i, a := range os.Args[4:] {
+		if a == "--as" && i+1 < len(os.Args[4:]) {
+			by = os.Args[i+5]
+		}
+	}
+
+	question, err := answerHelp(st, os.Args[2], os.Args[3], by)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if question != "" {
+		fmt.Printf("Answered %s: %s\n", os.Args[2], question)
+	} else {
+		fmt.Printf("Answered %s\n", os.Args[2])
+	}
+}
+
+// answerHelp answers the help request of the workstream named by target
+// ("PROJECT/NAME"), returning the question that was answered.
+func answerHelp(s *store.Store, target, answer, by string) (string, error) {
+	project, name, ok := strings.Cut(target, "/")
+	if !ok || project == "" || name == "" {
+		return "", fmt.Errorf("expected PROJECT/NAME, got %q", target)
+	}
+
+	req, err := s.AnswerHelp(project, name, answer, by)
+	if err != nil {
+		return "", err
+	}
+	return req.Question, nil
+}
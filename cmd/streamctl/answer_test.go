@@ -0,0 +1,46 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/faraz/streamctl/internal/store"
+	"github.com/faraz/streamctl/pkg/workstream"
+)
+
+func TestAnswerHelp(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	s, err := store.New(dbPath)
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	s.Create(&workstream.Workstream{Project: "myproject", Name: "auth", State: workstream.StateInProgress})
+	if _, err := s.RequestHelp("myproject", "auth", "Which OAuth provider?", "agent-1"); err != nil {
+		t.Fatalf("RequestHelp: %v", err)
+	}
+
+	question, err := answerHelp(s, "myproject/auth", "Use Google", "faraz")
+	if err != nil {
+		t.Fatalf("answerHelp: %v", err)
+	}
+	if question != "Which OAuth provider?" {
+		t.Errorf("question = %q", question)
+	}
+
+	ws, _ := s.Get("myproject", "auth")
+	if ws.NeedsHelp {
+		t.Errorf("needs_help should be cleared")
+	}
+	if ws.Log[0].Content != "Answer: Use Google" || ws.Log[0].Author != "faraz" {
+		t.Errorf("answer should be logged, got %+v", ws.Log[0])
+	}
+
+	if _, err := answerHelp(s, "myproject", "x", "faraz"); err == nil {
+		t.Errorf("expected error for target without name")
+	}
+	if _, err := answerHelp(s, "myproject/auth", "again", "faraz"); err == nil {
+		t.Errorf("expected error when no help is needed")
+	}
+}
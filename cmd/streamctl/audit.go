@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/faraz/streamctl/internal/store"
+)
+
+func runAudit(st *store.Store) {
+	project := ""
+	limit := 50
+	args := os.Args[2:]
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--limit" && i+1 < len(args):
+			limit, _ = strconv.Atoi(args[i+1])
+			i++
+		case args[i] == "--project" && i+1 < len(args):
+			project = args[i+1]
+			i++
+		default:
+			fmt.Fprintln(os.Stderr, "Usage: streamctl audit [--project P] [--limit N]")
+			os.Exit(1)
+		}
+	}
+
+	if err := printAudit(st, project, limit, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// printAudit lists the web UI's recorded writes, newest first
+func printAudit(st *store.Store, project string, limit int, w io.Writer) error {
+	entries, err := st.AuditLog(project, limit)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Fprintln(w, "No audited writes")
+		return nil
+	}
+	for _, e := range entries {
+		fmt.Fprintf(w, "%s  %-12s  %s/%s  %s: %s\n",
+			e.CreatedAt.Local().Format("2006-01-02 15:04:05"), e.Principal,
+			e.Project, e.Workstream, e.Action, e.Detail)
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/faraz/streamctl/internal/store"
+)
+
+func TestPrintAudit(t *testing.T) {
+	s, err := store.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	var buf bytes.Buffer
+	printAudit(s, "", 10, &buf)
+	if !strings.Contains(buf.String(), "No audited writes") {
+		t.Errorf("empty audit log output = %q", buf.String())
+	}
+
+	s.RecordAudit(&store.AuditEntry{Principal: "alice", Project: "proj", Workstream: "auth", Action: "state", Detail: "state set to done"})
+	s.RecordAudit(&store.AuditEntry{Principal: "ci", Project: "other", Workstream: "docs", Action: "log", Detail: "logged"})
+
+	buf.Reset()
+	printAudit(s, "proj", 10, &buf)
+	out := buf.String()
+	if !strings.Contains(out, "alice") || !strings.Contains(out, "proj/auth  state: state set to done") {
+		t.Errorf("audit output = %q", out)
+	}
+	if strings.Contains(out, "ci") {
+		t.Errorf("--project should filter out other projects, got %q", out)
+	}
+}
@@ -3,14 +3,13 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"path/filepath"
 
-	"github.com/faraz/streamctl/internal/mcp"
 	"github.com/faraz/streamctl/internal/store"
 	"github.com/faraz/streamctl/internal/web"
-	"github.com/mark3labs/mcp-go/server"
 )
 
 const version = "1.0.0"
@@ -53,6 +52,30 @@ func main() {
 		st := mustOpenStore(dbPath)
 		defer st.Close()
 		runExport(st)
+	case "answer":
+		st := mustOpenStore(dbPath)
+		defer st.Close()
+		runAnswer(st)
+	case "audit":
+		st := mustOpenStore(dbPath)
+		defer st.Close()
+		runAudit(st)
+	case "stats":
+		st := mustOpenStore(dbPath)
+		defer st.Close()
+		runStats(st)
+	case "stale":
+		st := mustOpenStore(dbPath)
+		defer st.Close()
+		runStale(st)
+	case "webhook":
+		st := mustOpenStore(dbPath)
+		defer st.Close()
+		runWebhook(st)
+	case "watch":
+		st := mustOpenStore(dbPath)
+		defer st.Close()
+		runWatch(st)
 	case "version", "--version", "-v":
 		fmt.Println("streamctl", version)
 	case "help", "--help", "-h":
@@ -70,17 +93,38 @@ func printUsage() {
 Usage:
   streamctl init                        Initialize the database
   streamctl serve                       Start MCP server (stdio)
+                                        [--project P] limit tools to P (repeatable; "." detects it)
+                                        [--allow-cross-project] let scoped writes link other projects
   streamctl web [--port PORT]           Start web UI (default: 8080)
+                                        [--edit] [--token T] enable editing, signed in by token
+                                        [--all] serve every project, under /p/PROJECT/
+                                        [--bind ADDR] listen address (default: localhost)
+                                        [--auth FILE] require sign-in; principals and roles (JSON)
+  streamctl audit [--project P]         Show who made each write through the web UI [--limit N]
   streamctl list [--project X]          List workstreams (JSON)
   streamctl export PROJECT/NAME         Export single workstream to stdout
   streamctl export PROJECT [--dir DIR]  Export all workstreams to directory
+                                        [--format html] as a static site (default dir: site)
+  streamctl answer PROJECT/NAME "..."   Answer a needs_help request [--as NAME]
+  streamctl stats PROJECT [--days N]    Throughput, cycle time, time blocked, help and burndown
+  streamctl stale [--project P]         List workstreams without recent updates [--after STATE=DUR,...]
+                                        [--release] release their claims [--reset] and move to pending
+  streamctl webhook add URL [flags]     Send events to URL [--secret S] [--project P] [--events a,b]
+  streamctl webhook list|remove ID|log  Manage webhooks and view the delivery log
+  streamctl watch PROJECT [flags]       Live activity and events [--only needs_help] [--workstream NAME]
+                                        [--notify] [--exec CMD]
   streamctl version                     Show version
   streamctl help                        Show this help
 
 Database location (in priority order):
   1. STREAMCTL_DB env variable
   2. .streamctl/workstreams.db (project-local)
-  3. ~/.streamctl/workstreams.db (user global)`)
+  3. ~/.streamctl/workstreams.db (user global)
+
+serve and web deliver webhook events in the background. Set
+STREAMCTL_CLAIM_TTL (e.g. 24h) to release claims idle for that long,
+STREAMCTL_STALE (default in_progress=7d,blocked=14d) to set when workstreams
+count as stale, and STREAMCTL_AUTH to an auth file for web and web_serve.`)
 }
 
 func mustOpenStore(dbPath string) *store.Store {
@@ -112,14 +156,6 @@ func runInit(dbPath string) {
 	fmt.Printf("Initialized database at %s\n", dbPath)
 }
 
-func runServer(st *store.Store) {
-	s := mcp.NewServer(st)
-	if err := server.ServeStdio(s); err != nil {
-		fmt.Fprintf(os.Stderr, "Server error: %v\n", err)
-		os.Exit(1)
-	}
-}
-
 func runList(st *store.Store) {
 	filter := store.Filter{}
 
@@ -144,7 +180,7 @@ func runList(st *store.Store) {
 
 func runExport(st *store.Store) {
 	if len(os.Args) < 3 {
-		fmt.Fprintln(os.Stderr, "Usage: streamctl export PROJECT/NAME or streamctl export PROJECT [--dir DIR]")
+		fmt.Fprintln(os.Stderr, "Usage: streamctl export PROJECT/NAME or streamctl export PROJECT [--dir DIR] [--format md|html]")
 		os.Exit(1)
 	}
 
@@ -161,22 +197,44 @@ func runExport(st *store.Store) {
 		return
 	}
 
-	// Otherwise it's PROJECT [--dir DIR]
+	// Otherwise it's PROJECT [--dir DIR] [--format md|html]
 	project := arg
-	dir := "./workstreams"
-
-	for i, a := range os.Args[3:] {
-		if a == "--dir" && i+1 < len(os.Args[3:]) {
-			dir = os.Args[i+4]
+	dir := ""
+	format := "md"
+
+	args := os.Args[3:]
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--dir" && i+1 < len(args):
+			dir = args[i+1]
+			i++
+		case args[i] == "--format" && i+1 < len(args):
+			format = args[i+1]
+			i++
 		}
 	}
 
-	if err := exportAllWorkstreams(st, project, dir); err != nil {
+	var err error
+	switch format {
+	case "md", "markdown":
+		if dir == "" {
+			dir = "./workstreams"
+		}
+		err = exportAllWorkstreams(st, project, dir)
+	case "html":
+		if dir == "" {
+			dir = "./site"
+		}
+		err = web.NewServer(st, project).ExportSite(dir)
+	default:
+		err = fmt.Errorf("unknown format %q: want md or html", format)
+	}
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 
-	fmt.Printf("Exported workstreams to %s/\n", dir)
+	fmt.Printf("Exported workstreams to %s/\n", filepath.Clean(dir))
 }
 
 func indexOf(s string, c byte) int {
@@ -190,33 +248,94 @@ func indexOf(s string, c byte) int {
 
 func runWeb(st *store.Store) {
 	port := "8080"
+	edit := false
+	all := false
+	token := ""
+	bind := "localhost"
+	authFile := os.Getenv("STREAMCTL_AUTH")
 
-	// Parse --port flag
+	// Parse --port, --edit, --token, --all, --bind and --auth flags
 	for i, arg := range os.Args[2:] {
-		if arg == "--port" && i+1 < len(os.Args[2:]) {
+		switch {
+		case arg == "--port" && i+1 < len(os.Args[2:]):
 			port = os.Args[i+3]
+		case arg == "--edit":
+			edit = true
+		case arg == "--all":
+			all = true
+		case arg == "--bind" && i+1 < len(os.Args[2:]):
+			bind = os.Args[i+3]
+		case arg == "--auth" && i+1 < len(os.Args[2:]):
+			authFile = os.Args[i+3]
+		case arg == "--token" && i+1 < len(os.Args[2:]):
+			edit = true
+			token = os.Args[i+3]
 		}
 	}
 
-	// Detect project from current directory
-	cwd, err := os.Getwd()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error getting current directory: %v\n", err)
-		os.Exit(1)
+	var srv *web.Server
+	what := "all projects'"
+	if all {
+		srv = web.NewMultiServer(st)
+	} else {
+		// Detect project from current directory
+		cwd, err := os.Getwd()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error getting current directory: %v\n", err)
+			os.Exit(1)
+		}
+
+		project, err := web.DetectProject(cwd)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error detecting project: %v\n", err)
+			os.Exit(1)
+		}
+		srv = web.NewServer(st, project)
+		what = project
 	}
+	startDispatcher(st)
 
-	project, err := web.DetectProject(cwd)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error detecting project: %v\n", err)
-		os.Exit(1)
+	if authFile != "" {
+		cfg, err := web.LoadAuthConfig(authFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading auth config: %v\n", err)
+			os.Exit(1)
+		}
+		srv.EnableAuth(cfg)
+	} else if !isLoopback(bind) {
+		fmt.Fprintf(os.Stderr, "Warning: serving on %s without --auth; anyone who can reach it can read every log\n", bind)
 	}
 
-	srv := web.NewServer(st, project)
+	if edit {
+		if token == "" {
+			token = web.NewEditToken()
+		}
+		srv.EnableEditing(token)
+		fmt.Printf("Editing enabled: open http://%s/?token=%s to sign in\n", net.JoinHostPort(browserHost(bind), port), token)
+	}
 
-	fmt.Printf("Serving %s workstreams at http://localhost:%s\n", project, port)
-	if err := http.ListenAndServe(":"+port, srv); err != nil {
+	fmt.Printf("Serving %s workstreams at http://%s\n", what, net.JoinHostPort(bind, port))
+	if err := http.ListenAndServe(net.JoinHostPort(bind, port), srv); err != nil {
 		fmt.Fprintf(os.Stderr, "Server error: %v\n", err)
 		os.Exit(1)
 	}
 }
 
+// browserHost returns the host a browser on this machine can open to reach
+// a server bound to bind; wildcard binds are reached through localhost
+func browserHost(bind string) string {
+	switch bind {
+	case "", "0.0.0.0", "::":
+		return "localhost"
+	}
+	return bind
+}
+
+// isLoopback reports whether bind only accepts local connections
+func isLoopback(bind string) bool {
+	if bind == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(bind)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/faraz/streamctl/internal/mcp"
+	"github.com/faraz/streamctl/internal/store"
+	"github.com/faraz/streamctl/internal/web"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func runServer(st *store.Store) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error getting current directory: %v\n", err)
+		os.Exit(1)
+	}
+	scope, err := parseServeScope(os.Args[2:], cwd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		fmt.Fprintln(os.Stderr, "Usage: streamctl serve [--project P]... [--allow-cross-project]")
+		os.Exit(1)
+	}
+
+	startDispatcher(st)
+	s, h := mcp.NewServer(st)
+	h.SetScope(scope)
+	err = server.ServeStdio(s)
+	h.StopWebServers()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Server error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// parseServeScope reads serve's flags into the projects its tools are limited
+// to, or nil for every project. "--project ." is the project cwd belongs to,
+// which MCP clients set to the workspace they launch serve from.
+func parseServeScope(args []string, cwd string) (*mcp.Scope, error) {
+	scope := &mcp.Scope{}
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--project" && i+1 < len(args):
+			project := args[i+1]
+			i++
+			if project == "." {
+				detected, err := web.DetectProject(cwd)
+				if err != nil {
+					return nil, fmt.Errorf("detecting project: %w", err)
+				}
+				project = detected
+			}
+			scope.Projects = append(scope.Projects, project)
+		case args[i] == "--allow-cross-project":
+			scope.AllowCrossProject = true
+		default:
+			return nil, fmt.Errorf("unknown flag %s", args[i])
+		}
+	}
+	if len(scope.Projects) == 0 {
+		if scope.AllowCrossProject {
+			return nil, fmt.Errorf("--allow-cross-project needs --project")
+		}
+		return nil, nil
+	}
+	return scope, nil
+}
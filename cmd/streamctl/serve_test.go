@@ -0,0 +1,32 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestParseServeScope(t *testing.T) {
+	cwd := filepath.Join(t.TempDir(), "myapp")
+	os.Mkdir(cwd, 0755)
+
+	scope, err := parseServeScope(nil, cwd)
+	if err != nil || scope != nil {
+		t.Errorf("no flags = %+v, %v; want no scope", scope, err)
+	}
+
+	scope, err = parseServeScope([]string{"--project", "api", "--project", ".", "--allow-cross-project"}, cwd)
+	if err != nil {
+		t.Fatalf("parseServeScope() error = %v", err)
+	}
+	if !slices.Equal(scope.Projects, []string{"api", "myapp"}) || !scope.AllowCrossProject {
+		t.Errorf("scope = %+v, want api and the detected myapp, allowing cross-project links", scope)
+	}
+
+	for _, bad := range [][]string{{"--allow-cross-project"}, {"--project"}, {"--bogus"}} {
+		if _, err := parseServeScope(bad, cwd); err == nil {
+			t.Errorf("parseServeScope(%v) should fail", bad)
+		}
+	}
+}
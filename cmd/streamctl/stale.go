@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/faraz/streamctl/internal/store"
+	"github.com/faraz/streamctl/pkg/workstream"
+)
+
+func runStale(st *store.Store) {
+	project := ""
+	release, reset := false, false
+	rules, err := workstream.StaleRulesFromEnv()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	args := os.Args[2:]
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--project" && i+1 < len(args):
+			project = args[i+1]
+			i++
+		case args[i] == "--after" && i+1 < len(args):
+			rules, err = workstream.ParseStaleRules(rules, args[i+1])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			i++
+		case args[i] == "--release":
+			release = true
+		case args[i] == "--reset":
+			reset = true
+		default:
+			staleUsage()
+		}
+	}
+
+	if err := printStale(st, project, rules, release, reset, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func staleUsage() {
+	fmt.Fprintln(os.Stderr, "Usage: streamctl stale [--project P] [--after STATE=DURATION,...] [--release] [--reset]")
+	os.Exit(1)
+}
+
+// staleClient attributes the log entries printStale writes, so they read as
+// coming from streamctl rather than an agent
+const staleClient = "streamctl stale"
+
+// printStale lists workstreams stale under rules. With release it clears
+// their claims, and with reset it also moves them back to pending, logging
+// a system entry on each.
+func printStale(st *store.Store, project string, rules workstream.StaleRules, release, reset bool, w io.Writer) error {
+	list, err := st.List(store.Filter{Project: project, Stale: rules})
+	if err != nil {
+		return err
+	}
+	if len(list) == 0 {
+		fmt.Fprintf(w, "No stale workstreams (%s)\n", rules)
+		return nil
+	}
+
+	now := time.Now()
+	for _, ws := range list {
+		idle := workstream.FormatDuration(now.Sub(ws.LastUpdate))
+		owner := "-"
+		if ws.Owner != "" {
+			owner = "@" + ws.Owner
+		}
+		fmt.Fprintf(w, "%-32s %-12s %-16s idle %s", ws.Project+"/"+ws.Name, ws.State, owner, idle)
+
+		var update store.WorkstreamUpdate
+		var actions []string
+		if (release || reset) && ws.Owner != "" {
+			none := ""
+			update.Owner = &none
+			actions = append(actions, "released claim by "+ws.Owner)
+		}
+		if reset && ws.State != workstream.StatePending {
+			pending := workstream.StatePending
+			update.State = &pending
+			actions = append(actions, "moved back to pending")
+		}
+		if len(actions) == 0 {
+			fmt.Fprintln(w)
+			continue
+		}
+		entry := fmt.Sprintf("Marked stale after %s without updates: %s", idle, strings.Join(actions, " and "))
+		update.LogEntry = &entry
+		update.LogClient = staleClient
+		if err := st.Update(ws.Project, ws.Name, update); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "  → %s\n", strings.Join(actions, ", "))
+	}
+	noun := "workstreams"
+	if len(list) == 1 {
+		noun = "workstream"
+	}
+	fmt.Fprintf(w, "%d stale %s (%s)\n", len(list), noun, rules)
+	return nil
+}
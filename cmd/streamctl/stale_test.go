@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/faraz/streamctl/internal/store"
+	"github.com/faraz/streamctl/pkg/workstream"
+)
+
+func TestPrintStale(t *testing.T) {
+	s, err := store.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	old := time.Now().UTC().Add(-10 * 24 * time.Hour)
+	s.Create(&workstream.Workstream{Project: "proj", Name: "auth", State: workstream.StateInProgress, Owner: "agent-1", LastUpdate: old})
+	s.Create(&workstream.Workstream{Project: "proj", Name: "api", State: workstream.StateInProgress, Owner: "agent-2", LastUpdate: time.Now().UTC()})
+	s.Create(&workstream.Workstream{Project: "proj", Name: "docs", State: workstream.StateBlocked, LastUpdate: old})
+
+	// Listing changes nothing
+	var buf bytes.Buffer
+	if err := printStale(s, "proj", workstream.DefaultStaleRules, false, false, &buf); err != nil {
+		t.Fatalf("printStale() error = %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "proj/auth") || !strings.Contains(out, "@agent-1") || strings.Contains(out, "proj/api") || strings.Contains(out, "proj/docs") {
+		t.Errorf("only auth should be stale:\n%s", out)
+	}
+	if !strings.Contains(out, "1 stale workstream (blocked=14d0h,in_progress=7d0h)") {
+		t.Errorf("output should summarize the rules:\n%s", out)
+	}
+	if ws, _ := s.Get("proj", "auth"); ws.Owner != "agent-1" {
+		t.Errorf("listing should not release claims")
+	}
+
+	// --release clears the claim and logs a system entry
+	buf.Reset()
+	if err := printStale(s, "proj", workstream.DefaultStaleRules, true, false, &buf); err != nil {
+		t.Fatalf("printStale(release) error = %v", err)
+	}
+	ws, _ := s.Get("proj", "auth")
+	if ws.Owner != "" || ws.State != workstream.StateInProgress {
+		t.Errorf("after release: owner = %q, state = %s", ws.Owner, ws.State)
+	}
+	last := ws.Log[0]
+	if last.Author != "" || !strings.Contains(last.Content, "Marked stale after 10d") || !strings.Contains(last.Content, "released claim by agent-1") {
+		t.Errorf("log entry = %+v", last)
+	}
+	if last.Attribution() != "streamctl stale" {
+		t.Errorf("log entry attributed to %q, want streamctl stale", last.Attribution())
+	}
+
+	// --reset moves stale items back to pending
+	rules, _ := workstream.ParseStaleRules(workstream.DefaultStaleRules, "blocked=1d")
+	buf.Reset()
+	if err := printStale(s, "proj", rules, false, true, &buf); err != nil {
+		t.Fatalf("printStale(reset) error = %v", err)
+	}
+	if ws, _ := s.Get("proj", "docs"); ws.State != workstream.StatePending || !strings.Contains(ws.Log[0].Content, "moved back to pending") {
+		t.Errorf("docs should be back in pending, got %s", ws.State)
+	}
+
+	buf.Reset()
+	printStale(s, "proj", rules, false, false, &buf)
+	if !strings.HasPrefix(buf.String(), "No stale workstreams") {
+		t.Errorf("acting should refresh last_update:\n%s", buf.String())
+	}
+}
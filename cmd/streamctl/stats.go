@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/faraz/streamctl/internal/metrics"
+	"github.com/faraz/streamctl/internal/store"
+)
+
+func runStats(st *store.Store) {
+	args := os.Args[2:]
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		statsUsage()
+	}
+	project := args[0]
+	days := metrics.DefaultDays
+	for i := 1; i < len(args); i++ {
+		switch {
+		case args[i] == "--days" && i+1 < len(args):
+			days, _ = strconv.Atoi(args[i+1])
+			i++
+		default:
+			statsUsage()
+		}
+	}
+	if days <= 0 {
+		statsUsage()
+	}
+
+	if err := printStats(st, project, days, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func statsUsage() {
+	fmt.Fprintln(os.Stderr, "Usage: streamctl stats PROJECT [--days N]")
+	os.Exit(1)
+}
+
+// printStats reports project's throughput, cycle time, time blocked,
+// needs_help frequency and milestone burndown
+func printStats(st *store.Store, project string, days int, w io.Writer) error {
+	stats, err := metrics.Load(st, project, days, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	stats.WriteText(w)
+	return nil
+}
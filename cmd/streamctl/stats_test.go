@@ -0,0 +1,34 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/faraz/streamctl/internal/store"
+	"github.com/faraz/streamctl/pkg/workstream"
+)
+
+func TestPrintStats(t *testing.T) {
+	s, err := store.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	s.Create(&workstream.Workstream{Project: "proj", Name: "auth", State: workstream.StatePending, Plan: []workstream.PlanItem{{Text: "schema"}, {Text: "handlers"}}})
+	s.SetTaskStatus("proj", "auth", 0, workstream.TaskDone)
+	s.SetTaskStatus("proj", "auth", 1, workstream.TaskDone)
+
+	var buf bytes.Buffer
+	if err := printStats(s, "proj", 7, &buf); err != nil {
+		t.Fatalf("printStats() error = %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"Stats for proj (last 7 days)", "Throughput: 2 tasks done", "Time blocked: none", "Burndown: no milestones"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("stats output should contain %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,331 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/faraz/streamctl/internal/store"
+	"github.com/faraz/streamctl/internal/webhook"
+	"github.com/faraz/streamctl/pkg/workstream"
+)
+
+// logEntryKind is the watch item type for plain log entries, alongside the
+// event types
+const logEntryKind = "log_entry"
+
+// watchBatch is how many new log entries are read per query
+const watchBatch = 200
+
+const watchUsage = `Usage: streamctl watch PROJECT [--only TYPES] [--workstream NAME] [--notify] [--exec CMD] [--interval 2s]
+
+  --only        Comma-separated: needs_help, log_entry, or event types
+                (state_changed, needs_help_raised, needs_help_cleared,
+                task_done, milestone_completed, claim_expired)
+  --workstream  Only this workstream
+  --notify      Also show a desktop notification (notify-send)
+  --exec CMD    Run CMD with sh -c per item, with its JSON on stdin`
+
+// watchOptions configures `streamctl watch`
+type watchOptions struct {
+	Project    string
+	Workstream string
+	Only       map[string]bool // Item types to show; empty shows all
+	Notify     bool
+	Exec       string
+	Interval   time.Duration
+}
+
+func runWatch(st *store.Store) {
+	opts, err := parseWatchArgs(os.Args[2:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n\n%s\n", err, watchUsage)
+		os.Exit(1)
+	}
+
+	w, err := newWatcher(st, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	color := useColor(os.Stdout)
+	fmt.Printf("Watching %s (Ctrl-C to stop)\n", opts.Project)
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		items, err := w.Poll()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			continue
+		}
+		for _, item := range items {
+			fmt.Println(formatWatchItem(item, color))
+			if opts.Notify {
+				notify(item)
+			}
+			if opts.Exec != "" {
+				if err := runHook(ctx, opts.Exec, item, os.Stdout, os.Stderr); err != nil {
+					fmt.Fprintf(os.Stderr, "--exec: %v\n", err)
+				}
+			}
+		}
+	}
+}
+
+// parseWatchArgs parses "PROJECT [flags]"
+func parseWatchArgs(args []string) (watchOptions, error) {
+	opts := watchOptions{Only: map[string]bool{}, Interval: 2 * time.Second}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch arg {
+		case "--notify":
+			opts.Notify = true
+			continue
+		case "--only", "--workstream", "--exec", "--interval":
+		default:
+			if strings.HasPrefix(arg, "--") || opts.Project != "" {
+				return opts, fmt.Errorf("unexpected argument: %s", arg)
+			}
+			opts.Project = arg
+			continue
+		}
+
+		if i+1 >= len(args) {
+			return opts, fmt.Errorf("%s needs a value", arg)
+		}
+		i++
+		val := args[i]
+		switch arg {
+		case "--workstream":
+			opts.Workstream = val
+		case "--exec":
+			opts.Exec = val
+		case "--interval":
+			d, err := time.ParseDuration(val)
+			if err != nil || d <= 0 {
+				return opts, fmt.Errorf("invalid interval: %s", val)
+			}
+			opts.Interval = d
+		case "--only":
+			for _, kind := range strings.Split(val, ",") {
+				kind = strings.TrimSpace(kind)
+				switch kind {
+				case "needs_help":
+					opts.Only[string(workstream.EventNeedsHelpRaised)] = true
+					opts.Only[string(workstream.EventNeedsHelpCleared)] = true
+				case logEntryKind, "log":
+					opts.Only[logEntryKind] = true
+				default:
+					t, err := workstream.ParseEventType(kind)
+					if err != nil {
+						return opts, err
+					}
+					opts.Only[string(t)] = true
+				}
+			}
+		}
+	}
+	if opts.Project == "" {
+		return opts, fmt.Errorf("PROJECT is required")
+	}
+	return opts, nil
+}
+
+// watcher polls the store for events and log entries recorded since it
+// last looked
+type watcher struct {
+	st        *store.Store
+	opts      watchOptions
+	lastEvent int64
+	lastEntry int64
+}
+
+// newWatcher creates a watcher that reports only what happens from now on
+func newWatcher(st *store.Store, opts watchOptions) (*watcher, error) {
+	eventID, entryID, err := st.LatestIDs()
+	if err != nil {
+		return nil, err
+	}
+	return &watcher{st: st, opts: opts, lastEvent: eventID, lastEntry: entryID}, nil
+}
+
+func (w *watcher) wants(kind, ws string) bool {
+	if w.opts.Workstream != "" && ws != w.opts.Workstream {
+		return false
+	}
+	return len(w.opts.Only) == 0 || w.opts.Only[kind]
+}
+
+// Poll returns new items, oldest first, as webhook payloads. Log entries
+// have type log_entry and carry content, log_type and author in Data.
+func (w *watcher) Poll() ([]webhook.Payload, error) {
+	var items []webhook.Payload
+
+	events, err := w.st.Events(w.opts.Project, w.lastEvent, 0)
+	if err != nil {
+		return nil, err
+	}
+	for _, ev := range events {
+		w.lastEvent = ev.ID
+		if w.wants(string(ev.Type), ev.Workstream) {
+			items = append(items, webhook.NewPayload(ev))
+		}
+	}
+
+	// Read every new entry in ID order, a batch at a time, so bursts aren't skipped
+	for {
+		entries, err := w.st.ActivityAfter(store.ActivityFilter{Project: w.opts.Project}, w.lastEntry, watchBatch)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			w.lastEntry = e.ID
+			if !w.wants(logEntryKind, e.WorkstreamName) {
+				continue
+			}
+			items = append(items, webhook.Payload{
+				Type:       logEntryKind,
+				Project:    e.WorkstreamProject,
+				Workstream: e.WorkstreamName,
+				Data: map[string]string{
+					"content":  e.Content,
+					"log_type": string(e.Type),
+					"author":   e.Attribution(),
+				},
+				CreatedAt: e.Timestamp,
+			})
+		}
+		if len(entries) < watchBatch {
+			break
+		}
+	}
+
+	sort.SliceStable(items, func(i, j int) bool { return items[i].CreatedAt.Before(items[j].CreatedAt) })
+	return items, nil
+}
+
+// ANSI colours for watch output
+const (
+	ansiReset   = "\033[0m"
+	ansiBold    = "\033[1m"
+	ansiDim     = "\033[2m"
+	ansiRed     = "\033[31m"
+	ansiGreen   = "\033[32m"
+	ansiYellow  = "\033[33m"
+	ansiBlue    = "\033[34m"
+	ansiMagenta = "\033[35m"
+	ansiCyan    = "\033[36m"
+)
+
+var watchColors = map[string]string{
+	string(workstream.EventStateChanged):       ansiYellow,
+	string(workstream.EventNeedsHelpRaised):    ansiBold + ansiRed,
+	string(workstream.EventNeedsHelpCleared):   ansiGreen,
+	string(workstream.EventTaskDone):           ansiGreen,
+	string(workstream.EventMilestoneCompleted): ansiBold + ansiMagenta,
+	string(workstream.EventClaimExpired):       ansiRed,
+	logEntryKind:                               ansiCyan,
+}
+
+// useColor reports whether f is a terminal and NO_COLOR is unset
+func useColor(f *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// describeWatchItem returns a one-line description of an item
+func describeWatchItem(p webhook.Payload) string {
+	d := p.Data
+	switch workstream.EventType(p.Type) {
+	case workstream.EventStateChanged:
+		return fmt.Sprintf("state %s → %s", d["from"], d["to"])
+	case workstream.EventNeedsHelpRaised:
+		if d["question"] != "" {
+			return "needs help: " + d["question"]
+		}
+		return "needs help"
+	case workstream.EventNeedsHelpCleared:
+		if d["answer"] != "" {
+			return fmt.Sprintf("answered by %s: %s", d["answered_by"], d["answer"])
+		}
+		return "no longer needs help"
+	case workstream.EventTaskDone:
+		return fmt.Sprintf("task done: %s (%s)", d["task"], d["task_id"])
+	case workstream.EventMilestoneCompleted:
+		return "milestone completed"
+	case workstream.EventClaimExpired:
+		return fmt.Sprintf("claim by %s expired after %s", d["owner"], d["idle"])
+	}
+
+	text := strings.SplitN(d["content"], "\n", 2)[0]
+	if d["log_type"] != "" && d["log_type"] != string(workstream.EntryNote) {
+		text = "[" + d["log_type"] + "] " + text
+	}
+	if d["author"] != "" {
+		text = "@" + d["author"] + " " + text
+	}
+	return text
+}
+
+// formatWatchItem formats an item as a line of watch output
+func formatWatchItem(p webhook.Payload, color bool) string {
+	subject := p.Project + "/" + p.Workstream
+	if p.Milestone != "" {
+		subject = p.Project + " milestone " + p.Milestone
+	}
+	stamp := p.CreatedAt.Local().Format("15:04:05")
+	desc := describeWatchItem(p)
+	if !color {
+		return fmt.Sprintf("%s  %s  %s", stamp, subject, desc)
+	}
+	return fmt.Sprintf("%s%s%s  %s%s%s  %s%s%s",
+		ansiDim, stamp, ansiReset, ansiBold, subject, ansiReset, watchColors[p.Type], desc, ansiReset)
+}
+
+// notify shows a desktop notification for an item, if notify-send is available
+func notify(p webhook.Payload) {
+	title := "streamctl: " + p.Project + "/" + p.Workstream
+	if p.Milestone != "" {
+		title = "streamctl: " + p.Project + " milestone " + p.Milestone
+	}
+	urgency := "normal"
+	if p.Type == string(workstream.EventNeedsHelpRaised) {
+		urgency = "critical"
+	}
+	exec.Command("notify-send", "--urgency", urgency, title, describeWatchItem(p)).Run()
+}
+
+// runHook runs cmd through the shell with the item's JSON on stdin and its
+// type in STREAMCTL_EVENT
+func runHook(ctx context.Context, cmd string, p webhook.Payload, stdout, stderr io.Writer) error {
+	body, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	c := exec.CommandContext(ctx, "sh", "-c", cmd)
+	c.Stdin = bytes.NewReader(body)
+	c.Stdout, c.Stderr = stdout, stderr
+	c.Env = append(os.Environ(), "STREAMCTL_EVENT="+p.Type)
+	return c.Run()
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/faraz/streamctl/internal/store"
+	"github.com/faraz/streamctl/internal/webhook"
+	"github.com/faraz/streamctl/pkg/workstream"
+)
+
+func TestParseWatchArgs(t *testing.T) {
+	opts, err := parseWatchArgs([]string{"myproject", "--only", "needs_help,task_done", "--workstream", "auth", "--notify", "--exec", "cat", "--interval", "500ms"})
+	if err != nil {
+		t.Fatalf("parseWatchArgs: %v", err)
+	}
+	if opts.Project != "myproject" || opts.Workstream != "auth" || !opts.Notify || opts.Exec != "cat" || opts.Interval != 500*time.Millisecond {
+		t.Errorf("opts = %+v", opts)
+	}
+	for _, kind := range []string{"needs_help_raised", "needs_help_cleared", "task_done"} {
+		if !opts.Only[kind] {
+			t.Errorf("--only should include %s", kind)
+		}
+	}
+
+	for _, args := range [][]string{
+		{},
+		{"--notify"},
+		{"myproject", "--only", "bogus"},
+		{"myproject", "--interval", "soon"},
+		{"myproject", "other"},
+	} {
+		if _, err := parseWatchArgs(args); err == nil {
+			t.Errorf("parseWatchArgs(%q) should fail", args)
+		}
+	}
+}
+
+func TestWatcherPoll(t *testing.T) {
+	s, err := store.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	s.Create(&workstream.Workstream{Project: "myproject", Name: "auth", State: workstream.StatePending})
+	s.Create(&workstream.Workstream{Project: "myproject", Name: "billing", State: workstream.StatePending})
+	before := "Old entry"
+	s.Update("myproject", "auth", store.WorkstreamUpdate{LogEntry: &before})
+
+	all, _ := newWatcher(s, watchOptions{Project: "myproject"})
+	helpOnly, _ := newWatcher(s, watchOptions{Project: "myproject", Workstream: "auth", Only: map[string]bool{
+		"needs_help_raised": true, "needs_help_cleared": true,
+	}})
+
+	// Nothing that happened before the watcher started is reported
+	if items, _ := all.Poll(); len(items) != 0 {
+		t.Fatalf("first Poll() = %d items, want 0", len(items))
+	}
+
+	inProgress := workstream.StateInProgress
+	entry := "Started"
+	s.Update("myproject", "auth", store.WorkstreamUpdate{State: &inProgress, LogEntry: &entry, LogAuthor: "agent-1"})
+	s.RequestHelp("myproject", "billing", "Which currency?", "agent-2")
+	s.RequestHelp("myproject", "auth", "Which provider?", "agent-1")
+
+	items, err := all.Poll()
+	if err != nil {
+		t.Fatalf("Poll: %v", err)
+	}
+	kinds := map[string]int{}
+	for _, item := range items {
+		kinds[item.Type]++
+	}
+	if kinds["state_changed"] != 1 || kinds["needs_help_raised"] != 2 || kinds[logEntryKind] != 3 {
+		t.Errorf("Poll() kinds = %v", kinds)
+	}
+
+	items, _ = helpOnly.Poll()
+	if len(items) != 1 || items[0].Workstream != "auth" || items[0].Data["question"] != "Which provider?" {
+		t.Errorf("filtered Poll() = %+v", items)
+	}
+
+	if items, _ := all.Poll(); len(items) != 0 {
+		t.Errorf("Poll() repeated %d items", len(items))
+	}
+}
+
+func TestWatcherPollBurst(t *testing.T) {
+	s, err := store.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	w, _ := newWatcher(s, watchOptions{Project: "myproject"})
+
+	// More entries than one batch, all sharing a timestamp
+	at := time.Now().UTC()
+	ws := &workstream.Workstream{Project: "myproject", Name: "busy", State: workstream.StateInProgress}
+	for i := 0; i < watchBatch*2+5; i++ {
+		ws.Log = append(ws.Log, workstream.LogEntry{Timestamp: at, Content: fmt.Sprintf("entry %d", i)})
+	}
+	s.Create(ws)
+
+	items, err := w.Poll()
+	if err != nil {
+		t.Fatalf("Poll: %v", err)
+	}
+	if len(items) != len(ws.Log) {
+		t.Fatalf("Poll() = %d items, want %d", len(items), len(ws.Log))
+	}
+	for i, item := range items {
+		if want := fmt.Sprintf("entry %d", i); item.Data["content"] != want {
+			t.Fatalf("item %d = %q, want %q (oldest first)", i, item.Data["content"], want)
+		}
+	}
+}
+
+func TestFormatWatchItem(t *testing.T) {
+	item := webhook.Payload{
+		Type: "state_changed", Project: "myproject", Workstream: "auth",
+		Data: map[string]string{"from": "pending", "to": "done"}, CreatedAt: time.Now(),
+	}
+	line := formatWatchItem(item, false)
+	if !strings.Contains(line, "myproject/auth  state pending → done") {
+		t.Errorf("line = %q", line)
+	}
+	if colored := formatWatchItem(item, true); !strings.Contains(colored, ansiYellow) {
+		t.Errorf("colored line = %q", colored)
+	}
+
+	entry := webhook.Payload{
+		Type: logEntryKind, Project: "myproject", Workstream: "auth",
+		Data: map[string]string{"content": "Chose JWT\nbecause...", "log_type": "decision", "author": "agent-1"},
+	}
+	if desc := describeWatchItem(entry); desc != "@agent-1 [decision] Chose JWT" {
+		t.Errorf("describeWatchItem() = %q", desc)
+	}
+}
+
+func TestRunHook(t *testing.T) {
+	item := webhook.Payload{Type: "task_done", Project: "myproject", Workstream: "auth", Data: map[string]string{"task": "Tests"}}
+
+	var out bytes.Buffer
+	if err := runHook(context.Background(), `echo "$STREAMCTL_EVENT"; cat`, item, &out, &out); err != nil {
+		t.Fatalf("runHook: %v", err)
+	}
+	event, body, _ := strings.Cut(out.String(), "\n")
+	if event != "task_done" {
+		t.Errorf("STREAMCTL_EVENT = %q", event)
+	}
+	var got webhook.Payload
+	if err := json.Unmarshal([]byte(body), &got); err != nil || got.Data["task"] != "Tests" {
+		t.Errorf("stdin JSON = %q (%v)", body, err)
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/faraz/streamctl/internal/store"
+	"github.com/faraz/streamctl/internal/webhook"
+	"github.com/faraz/streamctl/pkg/workstream"
+)
+
+const webhookUsage = `Usage:
+  streamctl webhook add URL [--secret S] [--project P] [--events a,b]
+  streamctl webhook list
+  streamctl webhook remove ID
+  streamctl webhook log [--limit N]`
+
+func runWebhook(st *store.Store) {
+	if len(os.Args) < 3 {
+		fmt.Fprintln(os.Stderr, webhookUsage)
+		os.Exit(1)
+	}
+
+	var err error
+	switch os.Args[2] {
+	case "add":
+		var hook *store.Webhook
+		hook, err = parseWebhook(os.Args[3:])
+		if err == nil {
+			err = st.AddWebhook(hook)
+		}
+		if err == nil {
+			fmt.Printf("Added webhook %d: %s\n", hook.ID, hook.URL)
+		}
+	case "list":
+		err = listWebhooks(st, os.Stdout)
+	case "remove":
+		var id int64
+		if len(os.Args) < 4 {
+			err = fmt.Errorf("webhook ID is required")
+		} else if id, err = strconv.ParseInt(os.Args[3], 10, 64); err == nil {
+			err = st.RemoveWebhook(id)
+		}
+		if err == nil {
+			fmt.Printf("Removed webhook %d\n", id)
+		}
+	case "log":
+		limit := 20
+		for i, a := range os.Args[3:] {
+			if a == "--limit" && i+1 < len(os.Args[3:]) {
+				limit, _ = strconv.Atoi(os.Args[i+4])
+			}
+		}
+		err = printDeliveries(st, limit, os.Stdout)
+	default:
+		fmt.Fprintln(os.Stderr, webhookUsage)
+		os.Exit(1)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// parseWebhook builds a webhook from "URL [--secret S] [--project P] [--events a,b]"
+func parseWebhook(args []string) (*store.Webhook, error) {
+	hook := &store.Webhook{}
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--secret", "--project", "--events":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("%s needs a value", args[i])
+			}
+			val := args[i+1]
+			i++
+			switch args[i-1] {
+			case "--secret":
+				hook.Secret = val
+			case "--project":
+				hook.Project = val
+			case "--events":
+				for _, name := range strings.Split(val, ",") {
+					t, err := workstream.ParseEventType(strings.TrimSpace(name))
+					if err != nil {
+						return nil, err
+					}
+					hook.Events = append(hook.Events, t)
+				}
+			}
+		default:
+			if hook.URL != "" {
+				return nil, fmt.Errorf("unexpected argument: %s", args[i])
+			}
+			hook.URL = args[i]
+		}
+	}
+	if hook.URL == "" {
+		return nil, fmt.Errorf("webhook URL is required")
+	}
+	return hook, nil
+}
+
+func listWebhooks(st *store.Store, w io.Writer) error {
+	hooks, err := st.ListWebhooks()
+	if err != nil {
+		return err
+	}
+	if len(hooks) == 0 {
+		fmt.Fprintln(w, "No webhooks")
+		return nil
+	}
+	for _, h := range hooks {
+		project, events := h.Project, "all events"
+		if project == "" {
+			project = "all projects"
+		}
+		if len(h.Events) > 0 {
+			parts := make([]string, len(h.Events))
+			for i, e := range h.Events {
+				parts[i] = string(e)
+			}
+			events = strings.Join(parts, ",")
+		}
+		signed := ""
+		if h.Secret != "" {
+			signed = " (signed)"
+		}
+		fmt.Fprintf(w, "%d  %s%s  [%s; %s]\n", h.ID, h.URL, signed, project, events)
+	}
+	return nil
+}
+
+func printDeliveries(st *store.Store, limit int, w io.Writer) error {
+	deliveries, err := st.Deliveries(limit)
+	if err != nil {
+		return err
+	}
+	if len(deliveries) == 0 {
+		fmt.Fprintln(w, "No deliveries")
+		return nil
+	}
+	for _, d := range deliveries {
+		subject := d.Event.Workstream
+		if subject == "" {
+			subject = d.Event.Milestone
+		}
+		fmt.Fprintf(w, "%s  %-9s  %s %s/%s -> %s (attempts: %d",
+			d.UpdatedAt.Local().Format("2006-01-02 15:04:05"), d.Status, d.Event.Type,
+			d.Event.Project, subject, d.URL, d.Attempts)
+		if d.ResponseCode != 0 {
+			fmt.Fprintf(w, ", status: %d", d.ResponseCode)
+		}
+		if d.LastError != "" {
+			fmt.Fprintf(w, ", error: %s", d.LastError)
+		}
+		fmt.Fprintln(w, ")")
+	}
+	return nil
+}
+
+// startDispatcher delivers webhook events in the background for the life of
+// the process. STREAMCTL_CLAIM_TTL (e.g. "24h") enables claim expiry.
+func startDispatcher(st *store.Store) {
+	d := webhook.NewDispatcher(st)
+	if ttl := os.Getenv("STREAMCTL_CLAIM_TTL"); ttl != "" {
+		parsed, err := time.ParseDuration(ttl)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Ignoring invalid STREAMCTL_CLAIM_TTL: %v\n", err)
+		} else {
+			d.ClaimTTL = parsed
+		}
+	}
+	go d.Run(context.Background())
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/faraz/streamctl/internal/store"
+	"github.com/faraz/streamctl/pkg/workstream"
+)
+
+func TestParseWebhook(t *testing.T) {
+	hook, err := parseWebhook([]string{"https://hooks.example.com/x", "--secret", "s3cret", "--project", "myproject", "--events", "needs_help_raised, claim_expired"})
+	if err != nil {
+		t.Fatalf("parseWebhook: %v", err)
+	}
+	if hook.URL != "https://hooks.example.com/x" || hook.Secret != "s3cret" || hook.Project != "myproject" {
+		t.Errorf("hook = %+v", hook)
+	}
+	if len(hook.Events) != 2 || hook.Events[1] != workstream.EventClaimExpired {
+		t.Errorf("events = %v", hook.Events)
+	}
+
+	for _, args := range [][]string{
+		{},
+		{"https://a", "--events", "bogus"},
+		{"https://a", "--secret"},
+		{"https://a", "https://b"},
+	} {
+		if _, err := parseWebhook(args); err == nil {
+			t.Errorf("parseWebhook(%q) should fail", args)
+		}
+	}
+}
+
+func TestListWebhooks(t *testing.T) {
+	s, err := store.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	var buf bytes.Buffer
+	listWebhooks(s, &buf)
+	if !strings.Contains(buf.String(), "No webhooks") {
+		t.Errorf("empty list = %q", buf.String())
+	}
+
+	s.AddWebhook(&store.Webhook{URL: "https://hooks.example.com/x", Secret: "s", Events: []workstream.EventType{workstream.EventTaskDone}})
+	buf.Reset()
+	listWebhooks(s, &buf)
+	if out := buf.String(); !strings.Contains(out, "https://hooks.example.com/x (signed)") || !strings.Contains(out, "all projects; task_done") {
+		t.Errorf("list = %q", out)
+	}
+}
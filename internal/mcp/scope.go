@@ -0,0 +1,118 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Scope limits which projects a serve process's tools may touch. Agents may
+// read and write the scope's projects; elsewhere they may only read
+// workstreams that one in scope declares as a blocker or blocks.
+type Scope struct {
+	Projects          []string
+	AllowCrossProject bool // Let writes link to other projects' workstreams (blockers, milestone requirements)
+}
+
+// allows reports whether project is in the scope. A nil scope allows every project.
+func (sc *Scope) allows(project string) bool {
+	return sc == nil || slices.Contains(sc.Projects, project)
+}
+
+// SetScope limits every tool to scope; nil lifts the limit
+func (h *Handlers) SetScope(scope *Scope) {
+	h.scope = scope
+}
+
+// scopeReads are tools that may read a workstream outside the scope when it
+// is a declared dependency of one inside it
+var scopeReads = map[string]bool{
+	"workstream_get":         true,
+	"workstream_help_status": true,
+}
+
+// scopeLists are tools where an empty project lists everything; their
+// handlers drop results outside the scope
+var scopeLists = map[string]bool{
+	"workstream_list": true,
+	"milestone_list":  true,
+}
+
+// scopeLinks are parameters naming another workstream as "project/name"
+var scopeLinks = []string{"add_blocker", "remove_blocker", "add_requirement", "remove_requirement"}
+
+// enforceScope is tool middleware rejecting calls that reach outside h's scope
+func (h *Handlers) enforceScope(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if h.scope == nil {
+			return next(ctx, req)
+		}
+		if err := h.checkScope(req); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return next(ctx, req)
+	}
+}
+
+// checkScope returns why req reaches outside h's scope, or nil if it doesn't
+func (h *Handlers) checkScope(req mcp.CallToolRequest) error {
+	tool := req.Params.Name
+	project := mcp.ParseString(req, "project", "")
+
+	switch {
+	case tool == "web_stop":
+		// Only servers started within the scope can be running
+		return nil
+	case tool == "web_serve" && project == "":
+		return fmt.Errorf("web_serve needs a project: this server is limited to %s", h.scopeNames())
+	case project == "":
+		// Lists filter their results; other tools reject the call themselves
+		return nil
+	case h.scope.allows(project):
+	case scopeReads[tool] && h.isScopeDependency(project, mcp.ParseString(req, "name", "")):
+		return nil
+	default:
+		return h.outOfScope(project)
+	}
+
+	for _, param := range scopeLinks {
+		ref := mcp.ParseString(req, param, "")
+		if ref == "" || h.scope.AllowCrossProject {
+			continue
+		}
+		if parts := splitProjectName(ref); len(parts) == 2 && !h.scope.allows(parts[0]) {
+			return fmt.Errorf("%s: %s; start serve with --allow-cross-project to link workstreams across projects", param, h.outOfScope(parts[0]))
+		}
+	}
+	return nil
+}
+
+// isScopeDependency reports whether project/name blocks or is blocked by a
+// workstream in h's scope
+func (h *Handlers) isScopeDependency(project, name string) bool {
+	for _, p := range h.scope.Projects {
+		deps, err := h.store.Dependencies(p)
+		if err != nil {
+			return false
+		}
+		for _, d := range deps {
+			if (d.BlockerProject == project && d.BlockerName == name && h.scope.allows(d.BlockedProject)) ||
+				(d.BlockedProject == project && d.BlockedName == name && h.scope.allows(d.BlockerProject)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (h *Handlers) outOfScope(project string) error {
+	return fmt.Errorf("project '%s' is outside this server's scope (%s)", project, h.scopeNames())
+}
+
+func (h *Handlers) scopeNames() string {
+	return strings.Join(h.scope.Projects, ", ")
+}
@@ -0,0 +1,126 @@
+package mcp
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/faraz/streamctl/pkg/workstream"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// setupScoped returns handlers limited to testproject, with "other" holding
+// a workstream that blocks Feature One and one unrelated to testproject
+func setupScoped(t *testing.T, scope *Scope) *Handlers {
+	t.Helper()
+	st := setupTestStore(t)
+	st.Create(&workstream.Workstream{Project: "other", Name: "lib", State: workstream.StateInProgress})
+	st.Create(&workstream.Workstream{Project: "other", Name: "private", State: workstream.StatePending})
+	st.AddDependency("other", "lib", "testproject", "Feature One")
+	st.CreateMilestone(&workstream.Milestone{Project: "other", Name: "v1"})
+	h := NewHandlers(st)
+	h.SetScope(scope)
+	return h
+}
+
+// callScoped calls tool's handler through the scope middleware, as NewServer wires it
+func callScoped(t *testing.T, h *Handlers, tool string, handler server.ToolHandlerFunc, args map[string]any) (string, bool) {
+	t.Helper()
+	req := mcp.CallToolRequest{Params: mcp.CallToolParams{Name: tool, Arguments: args}}
+	result, err := h.enforceScope(handler)(context.Background(), req)
+	if err != nil {
+		t.Fatalf("%s error = %v", tool, err)
+	}
+	return result.Content[0].(mcp.TextContent).Text, result.IsError
+}
+
+func TestScope_ListsOnlyScopedProjects(t *testing.T) {
+	h := setupScoped(t, &Scope{Projects: []string{"testproject"}})
+
+	text, isErr := callScoped(t, h, "workstream_list", h.HandleList, map[string]any{})
+	if isErr || !strings.Contains(text, "Feature One") || strings.Contains(text, `"other"`) {
+		t.Errorf("workstream_list = %s, want only testproject's workstreams", text)
+	}
+	if _, isErr := callScoped(t, h, "workstream_list", h.HandleList, map[string]any{"project": "other"}); !isErr {
+		t.Errorf("workstream_list of another project should fail")
+	}
+	if text, _ := callScoped(t, h, "milestone_list", h.HandleMilestoneList, map[string]any{}); strings.Contains(text, "v1") {
+		t.Errorf("milestone_list = %s, want no milestones outside the scope", text)
+	}
+}
+
+func TestScope_RejectsWritesOutsideScope(t *testing.T) {
+	h := setupScoped(t, &Scope{Projects: []string{"testproject"}})
+
+	text, isErr := callScoped(t, h, "workstream_update", h.HandleUpdate, map[string]any{"project": "other", "name": "lib", "state": "done"})
+	if !isErr || !strings.Contains(text, "outside this server's scope") {
+		t.Errorf("update outside the scope = %q, want a scope error", text)
+	}
+	if ws, _ := h.store.Get("other", "lib"); ws.State != workstream.StateInProgress {
+		t.Errorf("state = %s, want it unchanged", ws.State)
+	}
+	if _, isErr := callScoped(t, h, "workstream_create", h.HandleCreate, map[string]any{"project": "other", "name": "new", "objective": "x"}); !isErr {
+		t.Errorf("create outside the scope should fail")
+	}
+	if _, isErr := callScoped(t, h, "web_serve", h.HandleWebServe, map[string]any{}); !isErr {
+		t.Errorf("web_serve for all projects should fail when scoped")
+	}
+
+	if _, isErr := callScoped(t, h, "workstream_update", h.HandleUpdate, map[string]any{"project": "testproject", "name": "Feature One", "state": "in_progress"}); isErr {
+		t.Errorf("update inside the scope should succeed")
+	}
+}
+
+func TestScope_DependenciesAreReadOnly(t *testing.T) {
+	h := setupScoped(t, &Scope{Projects: []string{"testproject"}})
+
+	if text, isErr := callScoped(t, h, "workstream_get", h.HandleGet, map[string]any{"project": "other", "name": "lib"}); isErr {
+		t.Errorf("get of a declared blocker = %q, want it allowed", text)
+	}
+	if _, isErr := callScoped(t, h, "workstream_help_status", h.HandleHelpStatus, map[string]any{"project": "other", "name": "lib"}); isErr {
+		t.Errorf("help_status of a declared blocker should be allowed")
+	}
+	if _, isErr := callScoped(t, h, "workstream_get", h.HandleGet, map[string]any{"project": "other", "name": "private"}); !isErr {
+		t.Errorf("get of an unrelated workstream outside the scope should fail")
+	}
+	if _, isErr := callScoped(t, h, "workstream_claim", h.HandleClaim, map[string]any{"project": "other", "name": "lib", "owner": "me"}); !isErr {
+		t.Errorf("claiming a declared blocker should fail")
+	}
+}
+
+func TestScope_CrossProjectLinks(t *testing.T) {
+	args := map[string]any{"project": "testproject", "name": "Feature Two", "add_blocker": "other/private"}
+
+	h := setupScoped(t, &Scope{Projects: []string{"testproject"}})
+	text, isErr := callScoped(t, h, "workstream_update", h.HandleUpdate, args)
+	if !isErr || !strings.Contains(text, "--allow-cross-project") {
+		t.Errorf("cross-project blocker = %q, want it rejected", text)
+	}
+	if _, isErr := callScoped(t, h, "milestone_create", h.HandleMilestoneCreate, map[string]any{"project": "testproject", "name": "m"}); isErr {
+		t.Fatalf("milestone_create inside the scope should succeed")
+	}
+	if _, isErr := callScoped(t, h, "milestone_update", h.HandleMilestoneUpdate, map[string]any{"project": "testproject", "name": "m", "add_requirement": "other/lib"}); !isErr {
+		t.Errorf("cross-project requirement should be rejected")
+	}
+
+	h = setupScoped(t, &Scope{Projects: []string{"testproject"}, AllowCrossProject: true})
+	if text, isErr := callScoped(t, h, "workstream_update", h.HandleUpdate, args); isErr {
+		t.Errorf("cross-project blocker with AllowCrossProject = %q", text)
+	}
+	// The link makes other/private a dependency, so it is now readable
+	if _, isErr := callScoped(t, h, "workstream_get", h.HandleGet, map[string]any{"project": "other", "name": "private"}); isErr {
+		t.Errorf("get of a newly linked blocker should be allowed")
+	}
+}
+
+func TestScope_Unscoped(t *testing.T) {
+	h := setupScoped(t, nil)
+
+	if text, _ := callScoped(t, h, "workstream_list", h.HandleList, map[string]any{}); !strings.Contains(text, `"other"`) {
+		t.Errorf("an unscoped server should list every project")
+	}
+	if _, isErr := callScoped(t, h, "workstream_update", h.HandleUpdate, map[string]any{"project": "other", "name": "lib", "state": "done"}); isErr {
+		t.Errorf("an unscoped server should allow writes to any project")
+	}
+}
@@ -2,14 +2,17 @@ package mcp
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"net"
-	"net/http"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/faraz/streamctl/internal/metrics"
 	"github.com/faraz/streamctl/internal/store"
-	"github.com/faraz/streamctl/internal/web"
 	"github.com/faraz/streamctl/pkg/workstream"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
@@ -17,12 +20,60 @@ import (
 
 // Handlers provides MCP tool handlers
 type Handlers struct {
-	store *store.Store
+	store   *store.Store
+	serveID string      // Identifies this serve process in log attribution
+	web     *webServers // Web UIs started by web_serve
+	scope   *Scope      // Projects tools may touch; nil for all
+
+	mu        sync.Mutex
+	sessionID string // Session begun by session_start, or serveID outside one
 }
 
 // NewHandlers creates a new Handlers instance
 func NewHandlers(st *store.Store) *Handlers {
-	return &Handlers{store: st}
+	id := newSessionID()
+	return &Handlers{
+		store:     st,
+		serveID:   id,
+		sessionID: id,
+		web:       &webServers{running: map[string]*webServer{}},
+	}
+}
+
+// SessionID returns the ID recorded against log entries written now: the
+// session begun by session_start, or this process's ID outside a session
+func (h *Handlers) SessionID() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.sessionID
+}
+
+// setSession makes id the session stamped on new log entries
+func (h *Handlers) setSession(id string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.sessionID = id
+}
+
+// newSessionID returns a short random hex identifier
+func newSessionID() string {
+	b := make([]byte, 4)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// clientIdentity returns "name/version" of the connected MCP client as
+// reported in its initialize handshake, or "" if unknown
+func clientIdentity(ctx context.Context) string {
+	session, ok := server.ClientSessionFromContext(ctx).(server.SessionWithClientInfo)
+	if !ok {
+		return ""
+	}
+	info := session.GetClientInfo()
+	if info.Version == "" {
+		return info.Name
+	}
+	return info.Name + "/" + info.Version
 }
 
 // RegisterTools registers all workstream tools with the MCP server
@@ -33,6 +84,7 @@ func (h *Handlers) RegisterTools(s *server.MCPServer) {
 			mcp.WithString("project", mcp.Description("Filter by project name")),
 			mcp.WithString("state", mcp.Description("Filter by state: pending, in_progress, blocked, done")),
 			mcp.WithString("owner", mcp.Description("Filter by owner")),
+			mcp.WithBoolean("stale", mcp.Description("Only workstreams without updates for longer than their state's threshold (default: in_progress 7d, blocked 14d)")),
 		),
 		h.HandleList,
 	)
@@ -42,6 +94,14 @@ func (h *Handlers) RegisterTools(s *server.MCPServer) {
 			mcp.WithDescription("Get full workstream content"),
 			mcp.WithString("project", mcp.Description("Project name"), mcp.Required()),
 			mcp.WithString("name", mcp.Description("Workstream name (without .md)"), mcp.Required()),
+			mcp.WithString("log_type", mcp.Description("Only include log entries of this type: decision, progress, question, blocker, note")),
+			mcp.WithNumber("max_log_entries", mcp.Description("Show at most this many (newest) log entries")),
+			mcp.WithString("since", mcp.Description("Only show log entries since this time: RFC3339, YYYY-MM-DD, or a duration like \"48h\"")),
+			mcp.WithString("before", mcp.Description("Paging cursor from an \"older entries omitted\" marker; returns only the next page of the log")),
+			mcp.WithBoolean("include_notes", mcp.Description("Include task notes (default true)")),
+			mcp.WithBoolean("include_done_tasks", mcp.Description("Include done and skipped tasks (default true)")),
+			mcp.WithNumber("max_tokens", mcp.Description("Approximate response budget; older log entries, then notes and done tasks are dropped to fit")),
+			mcp.WithBoolean("include_superseded", mcp.Description("Also show log entries already covered by the summary (default false)")),
 		),
 		h.HandleGet,
 	)
@@ -64,14 +124,22 @@ func (h *Handlers) RegisterTools(s *server.MCPServer) {
 			mcp.WithString("new_name", mcp.Description("Rename workstream to this name")),
 			mcp.WithString("state", mcp.Description("New state: pending, in_progress, blocked, done")),
 			mcp.WithString("log_entry", mcp.Description("New log entry to append")),
-			mcp.WithNumber("plan_index", mcp.Description("Toggle completion of plan item at this index")),
-			mcp.WithString("task_add", mcp.Description("Add a new task with this text")),
-			mcp.WithNumber("task_remove", mcp.Description("Remove task at this position (0-indexed)")),
-			mcp.WithObject("task_status", mcp.Description("Set task status: {\"position\": 0, \"status\": \"done\"}")),
-			mcp.WithObject("task_notes", mcp.Description("Set task notes (markdown): {\"position\": 0, \"notes\": \"## Details\\n- item\"}")),
+			mcp.WithString("log_type", mcp.Description("Type of log_entry: decision, progress, question, blocker, note (default)")),
+			mcp.WithArray("alternatives", mcp.WithStringItems(), mcp.Description("For decisions: alternatives considered and rejected")),
+			mcp.WithString("rationale", mcp.Description("For decisions: why this option was chosen")),
+			mcp.WithString("author", mcp.Description("Who is writing log_entry (defaults to the MCP client name)")),
+			mcp.WithAny("plan_index", mcp.Description("Toggle completion of plan item by task ID (\"t12\") or 0-indexed position")),
+			mcp.WithString("task_add", mcp.Description("Add a new task with this text (returns its stable ID)")),
+			mcp.WithObject("subtask_add", mcp.Description("Add a subtask under a task: {\"parent\": \"t12\", \"text\": \"...\"} (returns its stable ID; any depth)")),
+			mcp.WithAny("task_remove", mcp.Description("Remove task (and its subtasks) by ID (\"t12\") or 0-indexed position")),
+			mcp.WithObject("task_status", mcp.Description("Set task or subtask status: {\"id\": \"t12\", \"status\": \"done\"} (\"position\" also accepted). Parent status rolls up from subtasks")),
+			mcp.WithObject("task_notes", mcp.Description("Set task notes (markdown): {\"id\": \"t12\", \"notes\": \"## Details\\n- item\"}")),
+			mcp.WithObject("task_edit", mcp.Description("Replace task text: {\"id\": \"t12\", \"text\": \"New wording\"}")),
+			mcp.WithObject("task_move", mcp.Description("Reorder task among its siblings: {\"id\": \"t12\", \"to\": 0} moves it to 0-indexed position")),
 			mcp.WithString("add_blocker", mcp.Description("Add dependency: 'project/workstream' blocks this one")),
 			mcp.WithString("remove_blocker", mcp.Description("Remove dependency from this workstream")),
 			mcp.WithBoolean("needs_help", mcp.Description("Flag workstream as needing help/at-risk")),
+			mcp.WithString("help_question", mcp.Description("Ask a human for help: sets needs_help and records the question (poll workstream_help_status for the answer)")),
 		),
 		h.HandleUpdate,
 	)
@@ -95,14 +163,74 @@ func (h *Handlers) RegisterTools(s *server.MCPServer) {
 		h.HandleRelease,
 	)
 
+	s.AddTool(
+		mcp.NewTool("workstream_help_status",
+			mcp.WithDescription("Check whether a human has answered this workstream's help request"),
+			mcp.WithString("project", mcp.Description("Project name"), mcp.Required()),
+			mcp.WithString("name", mcp.Description("Workstream name"), mcp.Required()),
+		),
+		h.HandleHelpStatus,
+	)
+
+	s.AddTool(
+		mcp.NewTool("session_start",
+			mcp.WithDescription("Start a working session. Returns a briefing: your claimed work, in-progress tasks, unresolved needs_help flags, and activity by others since your last session"),
+			mcp.WithString("project", mcp.Description("Project name"), mcp.Required()),
+			mcp.WithString("agent", mcp.Description("Your agent identifier (use the same one as workstream owner/author)"), mcp.Required()),
+		),
+		h.HandleSessionStart,
+	)
+
+	s.AddTool(
+		mcp.NewTool("session_end",
+			mcp.WithDescription("End your working session with a handoff note for the next session"),
+			mcp.WithString("project", mcp.Description("Project name"), mcp.Required()),
+			mcp.WithString("agent", mcp.Description("Your agent identifier"), mcp.Required()),
+			mcp.WithString("next_steps", mcp.Description("What should happen next (shown in your next briefing)"), mcp.Required()),
+			mcp.WithBoolean("release_claims", mcp.Description("Release all workstreams you own in this project (default false keeps them)")),
+		),
+		h.HandleSessionEnd,
+	)
+
+	s.AddTool(
+		mcp.NewTool("workstream_compact",
+			mcp.WithDescription("Replace older log entries with a condensed summary (\"state of play\"). workstream_get then shows the summary followed only by newer entries; originals stay available via the before cursor and search. The summary replaces any previous one, so carry forward what it covered."),
+			mcp.WithString("project", mcp.Description("Project name"), mcp.Required()),
+			mcp.WithString("name", mcp.Description("Workstream name"), mcp.Required()),
+			mcp.WithString("summary", mcp.Description("Condensed summary of the log (markdown)"), mcp.Required()),
+			mcp.WithString("through", mcp.Description("Summarise entries up to this time (RFC3339, or a before cursor); default all entries")),
+		),
+		h.HandleCompact,
+	)
+
+	s.AddTool(
+		mcp.NewTool("workstream_stats",
+			mcp.WithDescription("Project metrics: tasks completed per day, cycle time (created → done), time spent blocked, needs_help frequency and burndown per milestone"),
+			mcp.WithString("project", mcp.Description("Project name"), mcp.Required()),
+			mcp.WithNumber("days", mcp.Description("Days of throughput to report (default 14)")),
+		),
+		h.HandleStats,
+	)
+
 	s.AddTool(
 		mcp.NewTool("web_serve",
-			mcp.WithDescription("Start a web UI server for viewing workstreams. Returns the URL."),
-			mcp.WithString("project", mcp.Description("Project name to display"), mcp.Required()),
+			mcp.WithDescription("Start a web UI server for viewing workstreams and return its URL. If one is already running for the project, returns that URL instead of starting another."),
+			mcp.WithString("project", mcp.Description("Project name to display; omit to serve every project with a switcher")),
+			mcp.WithBoolean("edit", mcp.Description("Let the human edit workstreams from the UI; the returned URL signs them in (default false)")),
+			mcp.WithNumber("port", mcp.Description("Port to listen on (default: any free port)")),
+			mcp.WithString("bind", mcp.Description("Address to listen on (default localhost; others need STREAMCTL_AUTH)")),
 		),
 		h.HandleWebServe,
 	)
 
+	s.AddTool(
+		mcp.NewTool("web_stop",
+			mcp.WithDescription("Stop the web UI web_serve started for a project"),
+			mcp.WithString("project", mcp.Description("Project name; omit for the all-projects UI")),
+		),
+		h.HandleWebStop,
+	)
+
 	// Milestone tools
 	s.AddTool(
 		mcp.NewTool("milestone_create",
@@ -164,11 +292,19 @@ func (h *Handlers) HandleList(ctx context.Context, req mcp.CallToolRequest) (*mc
 		State:   workstream.State(state),
 		Owner:   owner,
 	}
+	if mcp.ParseBoolean(req, "stale", false) {
+		rules, err := workstream.StaleRulesFromEnv()
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		filter.Stale = rules
+	}
 
 	workstreams, err := h.store.List(filter)
 	if err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
+	workstreams = slices.DeleteFunc(workstreams, func(ws workstream.Workstream) bool { return !h.scope.allows(ws.Project) })
 
 	// Convert to summary format
 	type wsSummary struct {
@@ -205,13 +341,61 @@ func (h *Handlers) HandleGet(ctx context.Context, req mcp.CallToolRequest) (*mcp
 		return mcp.NewToolResultError("project and name are required"), nil
 	}
 
+	// An empty log_type keeps every entry rather than defaulting to notes
+	var logType workstream.EntryType
+	var err error
+	if s := mcp.ParseString(req, "log_type", ""); s != "" {
+		if logType, err = workstream.ParseEntryType(s); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+	}
+
+	opts := workstream.RenderOptions{
+		MaxLogEntries: mcp.ParseInt(req, "max_log_entries", 0),
+		OmitNotes:     !mcp.ParseBoolean(req, "include_notes", true),
+		OmitDoneTasks: !mcp.ParseBoolean(req, "include_done_tasks", true),
+		MaxTokens:     mcp.ParseInt(req, "max_tokens", 0),
+
+		IncludeSuperseded: mcp.ParseBoolean(req, "include_superseded", false),
+	}
+	if since := mcp.ParseString(req, "since", ""); since != "" {
+		if opts.Since, err = parseSince(since, time.Now()); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+	}
+	if before := mcp.ParseString(req, "before", ""); before != "" {
+		if opts.Before, err = workstream.ParseLogCursor(before); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+	}
+
 	ws, err := h.store.Get(project, name)
 	if err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
+	// Narrow the log to a single entry type if requested
+	if logType != "" {
+		ws.Log = workstream.FilterLog(ws.Log, logType)
+	}
+
 	// Return as markdown
-	return mcp.NewToolResultText(workstream.Serialize(ws)), nil
+	return mcp.NewToolResultText(workstream.RenderWith(ws, opts)), nil
+}
+
+// parseSince parses an absolute time (RFC3339 or YYYY-MM-DD) or a duration
+// before now (e.g. "48h")
+func parseSince(s string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid since %q (want RFC3339, YYYY-MM-DD or a duration like 48h)", s)
 }
 
 // HandleCreate creates a new workstream
@@ -264,36 +448,78 @@ func (h *Handlers) HandleUpdate(ctx context.Context, req mcp.CallToolRequest) (*
 	}
 
 	if logEntry := mcp.ParseString(req, "log_entry", ""); logEntry != "" {
+		logType, err := workstream.ParseEntryType(mcp.ParseString(req, "log_type", ""))
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
 		updates.LogEntry = &logEntry
+		updates.LogType = logType
+		updates.LogAlternatives = req.GetStringSlice("alternatives", nil)
+		updates.LogRationale = mcp.ParseString(req, "rationale", "")
+		updates.LogAuthor = mcp.ParseString(req, "author", "")
+		updates.LogClient = clientIdentity(ctx)
+		updates.LogSession = h.SessionID()
 	}
 
 	args, _ := req.Params.Arguments.(map[string]any)
 
-	// Check if plan_index was provided
+	// Check if plan_index was provided (position or stable task ID)
 	if args != nil {
-		if _, ok := args["plan_index"]; ok {
-			idx := mcp.ParseInt(req, "plan_index", -1)
-			if idx >= 0 {
-				updates.PlanIndex = &idx
+		if v, ok := args["plan_index"]; ok {
+			if taskID, isID := v.(string); isID {
+				updates.PlanTask = &taskID
+			} else {
+				idx := mcp.ParseInt(req, "plan_index", -1)
+				if idx >= 0 {
+					updates.PlanIndex = &idx
+				}
 			}
 		}
 	}
 
+	var changes []string
+
 	// Handle task_add
 	if taskAdd := mcp.ParseString(req, "task_add", ""); taskAdd != "" {
-		if err := h.store.AddTask(project, name, taskAdd); err != nil {
+		taskID, err := h.store.AddTask(project, name, taskAdd)
+		if err != nil {
 			return mcp.NewToolResultError(err.Error()), nil
 		}
+		changes = append(changes, "added task "+taskID)
+	}
+
+	// Handle subtask_add
+	if args != nil {
+		if subObj, ok := args["subtask_add"].(map[string]any); ok {
+			parent, ok := subObj["parent"]
+			if !ok {
+				return mcp.NewToolResultError("subtask_add: parent is required"), nil
+			}
+			parentID, err := h.resolveTask(project, name, parent)
+			if err != nil {
+				return mcp.NewToolResultError("subtask_add: " + err.Error()), nil
+			}
+			text, _ := subObj["text"].(string)
+			if text == "" {
+				return mcp.NewToolResultError("subtask_add: text is required"), nil
+			}
+			taskID, err := h.store.AddSubtask(project, name, parentID, text)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			changes = append(changes, "added subtask "+taskID+" under "+parentID)
+		}
 	}
 
 	// Handle task_remove
 	if args != nil {
-		if _, ok := args["task_remove"]; ok {
-			idx := mcp.ParseInt(req, "task_remove", -1)
-			if idx >= 0 {
-				if err := h.store.RemoveTask(project, name, idx); err != nil {
-					return mcp.NewToolResultError(err.Error()), nil
-				}
+		if v, ok := args["task_remove"]; ok {
+			taskID, err := h.resolveTask(project, name, v)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if err := h.store.RemoveTaskByID(project, name, taskID); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
 			}
 		}
 	}
@@ -301,9 +527,18 @@ func (h *Handlers) HandleUpdate(ctx context.Context, req mcp.CallToolRequest) (*
 	// Handle task_status
 	if args != nil {
 		if statusObj, ok := args["task_status"].(map[string]any); ok {
-			position := int(statusObj["position"].(float64))
-			status := workstream.TaskStatus(statusObj["status"].(string))
-			if err := h.store.SetTaskStatus(project, name, position, status); err != nil {
+			taskID, err := h.resolveTaskObject(project, name, statusObj)
+			if err != nil {
+				return mcp.NewToolResultError("task_status: " + err.Error()), nil
+			}
+			s, _ := statusObj["status"].(string)
+			status := workstream.TaskStatus(s)
+			switch status {
+			case workstream.TaskPending, workstream.TaskInProgress, workstream.TaskDone, workstream.TaskSkipped:
+			default:
+				return mcp.NewToolResultError(fmt.Sprintf("task_status: invalid status %q (valid: pending, in_progress, done, skipped)", s)), nil
+			}
+			if err := h.store.SetTaskStatusByID(project, name, taskID, status); err != nil {
 				return mcp.NewToolResultError(err.Error()), nil
 			}
 		}
@@ -312,9 +547,46 @@ func (h *Handlers) HandleUpdate(ctx context.Context, req mcp.CallToolRequest) (*
 	// Handle task_notes
 	if args != nil {
 		if notesObj, ok := args["task_notes"].(map[string]any); ok {
-			position := int(notesObj["position"].(float64))
-			notes := notesObj["notes"].(string)
-			if err := h.store.SetTaskNotes(project, name, position, notes); err != nil {
+			taskID, err := h.resolveTaskObject(project, name, notesObj)
+			if err != nil {
+				return mcp.NewToolResultError("task_notes: " + err.Error()), nil
+			}
+			notes, _ := notesObj["notes"].(string)
+			if err := h.store.SetTaskNotesByID(project, name, taskID, notes); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+		}
+	}
+
+	// Handle task_edit
+	if args != nil {
+		if editObj, ok := args["task_edit"].(map[string]any); ok {
+			taskID, err := h.resolveTaskObject(project, name, editObj)
+			if err != nil {
+				return mcp.NewToolResultError("task_edit: " + err.Error()), nil
+			}
+			text, _ := editObj["text"].(string)
+			if text == "" {
+				return mcp.NewToolResultError("task_edit: text is required"), nil
+			}
+			if err := h.store.SetTaskText(project, name, taskID, text); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+		}
+	}
+
+	// Handle task_move
+	if args != nil {
+		if moveObj, ok := args["task_move"].(map[string]any); ok {
+			taskID, err := h.resolveTaskObject(project, name, moveObj)
+			if err != nil {
+				return mcp.NewToolResultError("task_move: " + err.Error()), nil
+			}
+			to, ok := moveObj["to"].(float64)
+			if !ok {
+				return mcp.NewToolResultError("task_move: to is required"), nil
+			}
+			if err := h.store.MoveTask(project, name, taskID, int(to)); err != nil {
 				return mcp.NewToolResultError(err.Error()), nil
 			}
 		}
@@ -356,7 +628,47 @@ func (h *Handlers) HandleUpdate(ctx context.Context, req mcp.CallToolRequest) (*
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	return mcp.NewToolResultText("Updated workstream: " + project + "/" + name), nil
+	// Ask for help after other updates, so a needs_help=false in the same call doesn't withdraw it
+	if question := mcp.ParseString(req, "help_question", ""); question != "" {
+		askedBy := mcp.ParseString(req, "author", "")
+		if askedBy == "" {
+			askedBy = clientIdentity(ctx)
+		}
+		if _, err := h.store.RequestHelp(project, name, question, askedBy); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		changes = append(changes, "asked for help")
+	}
+
+	text := "Updated workstream: " + project + "/" + name
+	if len(changes) > 0 {
+		text += " (" + strings.Join(changes, ", ") + ")"
+	}
+	return mcp.NewToolResultText(text), nil
+}
+
+// resolveTask converts a task reference into a stable task ID. A string is taken
+// as the ID itself; a number is a 0-indexed position, kept for backward compatibility.
+func (h *Handlers) resolveTask(project, name string, ref any) (string, error) {
+	switch v := ref.(type) {
+	case string:
+		return v, nil
+	case float64:
+		return h.store.TaskID(project, name, int(v))
+	default:
+		return "", fmt.Errorf("task must be an ID like \"t12\" or a 0-indexed position")
+	}
+}
+
+// resolveTaskObject resolves the "id" or "position" key of a task object parameter
+func (h *Handlers) resolveTaskObject(project, name string, obj map[string]any) (string, error) {
+	if id, ok := obj["id"]; ok {
+		return h.resolveTask(project, name, id)
+	}
+	if pos, ok := obj["position"]; ok {
+		return h.resolveTask(project, name, pos)
+	}
+	return "", fmt.Errorf("id or position is required")
 }
 
 // splitProjectName splits "project/name" into parts
@@ -411,31 +723,160 @@ func (h *Handlers) HandleRelease(ctx context.Context, req mcp.CallToolRequest) (
 	return mcp.NewToolResultText("Released workstream: " + project + "/" + name), nil
 }
 
-// HandleWebServe starts a web UI server and returns the URL
-func (h *Handlers) HandleWebServe(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+// HandleHelpStatus reports the state of a workstream's latest help request
+func (h *Handlers) HandleHelpStatus(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	project := mcp.ParseString(req, "project", "")
+	name := mcp.ParseString(req, "name", "")
 
-	if project == "" {
-		return mcp.NewToolResultError("project is required"), nil
+	if project == "" || name == "" {
+		return mcp.NewToolResultError("project and name are required"), nil
 	}
 
-	// Find an available port
-	listener, err := net.Listen("tcp", "localhost:0")
+	help, err := h.store.HelpStatus(project, name)
 	if err != nil {
-		return mcp.NewToolResultError("failed to find available port: " + err.Error()), nil
+		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	port := listener.Addr().(*net.TCPAddr).Port
-	url := fmt.Sprintf("http://%s.localhost:%d", project, port)
+	var text string
+	switch {
+	case help == nil:
+		text = "No help requested"
+	case !help.Answered():
+		text = fmt.Sprintf("Waiting for an answer (asked %s ago): %s", workstream.FormatDuration(time.Since(help.AskedAt)), help.Question)
+	case help.Answer == "":
+		text = "Help request withdrawn (needs_help cleared without an answer)"
+	default:
+		text = "Answered"
+		if help.AnsweredBy != "" {
+			text += " by " + help.AnsweredBy
+		}
+		text += " at " + help.AnsweredAt.Format(workstream.TimeFormat) + ":\n" + help.Answer
+	}
+	return mcp.NewToolResultText(text), nil
+}
 
-	// Create and start the web server
-	srv := web.NewServer(h.store, project)
+// HandleSessionStart starts a session and returns the agent's briefing
+func (h *Handlers) HandleSessionStart(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	project := mcp.ParseString(req, "project", "")
+	agent := mcp.ParseString(req, "agent", "")
 
-	go func() {
-		http.Serve(listener, srv)
-	}()
+	if project == "" || agent == "" {
+		return mcp.NewToolResultError("project and agent are required"), nil
+	}
 
-	return mcp.NewToolResultText(fmt.Sprintf("Web UI started at %s for project '%s'", url, project)), nil
+	// Brief first, so "since your last session" ignores any session abandoned below
+	briefing, err := h.store.Briefing(project, agent)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	// A session left open without session_end is closed by the new one
+	if prev, err := h.store.ActiveSession(project, agent); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	} else if prev != nil {
+		if _, err := h.store.EndSession(prev.ID, ""); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+	}
+
+	sess, err := h.store.StartSession(newSessionID(), project, agent, clientIdentity(ctx))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	h.setSession(sess.ID)
+
+	return mcp.NewToolResultText("Started session " + sess.ID + "\n\n" + workstream.RenderBriefing(briefing)), nil
+}
+
+// HandleSessionEnd ends the agent's active session
+func (h *Handlers) HandleSessionEnd(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	project := mcp.ParseString(req, "project", "")
+	agent := mcp.ParseString(req, "agent", "")
+	nextSteps := strings.TrimSpace(mcp.ParseString(req, "next_steps", ""))
+
+	if project == "" || agent == "" || nextSteps == "" {
+		return mcp.NewToolResultError("project, agent, and next_steps are required"), nil
+	}
+
+	active, err := h.store.ActiveSession(project, agent)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if active == nil {
+		return mcp.NewToolResultError(fmt.Sprintf("no active session for %s on %s (call session_start first)", agent, project)), nil
+	}
+
+	sess, err := h.store.EndSession(active.ID, nextSteps)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	h.mu.Lock()
+	if h.sessionID == sess.ID {
+		h.sessionID = h.serveID
+	}
+	h.mu.Unlock()
+
+	msg := fmt.Sprintf("Ended session %s after %s", sess.ID, workstream.FormatDuration(sess.Duration()))
+	if mcp.ParseBoolean(req, "release_claims", false) {
+		released, err := h.store.ReleaseClaims(project, agent)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if len(released) > 0 {
+			msg += " (released " + strings.Join(released, ", ") + ")"
+		}
+	}
+
+	return mcp.NewToolResultText(msg), nil
+}
+
+// HandleCompact summarises older log entries
+func (h *Handlers) HandleCompact(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	project := mcp.ParseString(req, "project", "")
+	name := mcp.ParseString(req, "name", "")
+	summary := mcp.ParseString(req, "summary", "")
+
+	if project == "" || name == "" || summary == "" {
+		return mcp.NewToolResultError("project, name, and summary are required"), nil
+	}
+
+	// A before cursor parses as a time too, covering entries up to its timestamp
+	var through time.Time
+	if t := mcp.ParseString(req, "through", ""); t != "" {
+		cursor, err := workstream.ParseLogCursor(t)
+		if err != nil {
+			return mcp.NewToolResultError("invalid through time: " + t), nil
+		}
+		through = cursor.Timestamp
+	}
+
+	n, err := h.store.Compact(project, name, summary, through)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Compacted workstream: %s/%s (summary covers %d more log entries)", project, name, n)), nil
+}
+
+// HandleStats reports a project's metrics
+func (h *Handlers) HandleStats(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	project := mcp.ParseString(req, "project", "")
+	days := mcp.ParseInt(req, "days", metrics.DefaultDays)
+
+	if project == "" {
+		return mcp.NewToolResultError("project is required"), nil
+	}
+	if days <= 0 {
+		return mcp.NewToolResultError("days must be positive"), nil
+	}
+
+	stats, err := metrics.Load(h.store, project, days, time.Now().UTC())
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	var b strings.Builder
+	stats.WriteText(&b)
+	return mcp.NewToolResultText(b.String()), nil
 }
 
 // HandleMilestoneCreate creates a new milestone
@@ -486,6 +927,7 @@ func (h *Handlers) HandleMilestoneList(ctx context.Context, req mcp.CallToolRequ
 	if err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
+	milestones = slices.DeleteFunc(milestones, func(m workstream.Milestone) bool { return !h.scope.allows(m.Project) })
 
 	type msSummary struct {
 		Project     string `json:"project"`
@@ -569,10 +1011,12 @@ func (h *Handlers) HandleMilestoneDelete(ctx context.Context, req mcp.CallToolRe
 	return mcp.NewToolResultText("Deleted milestone: " + project + "/" + name), nil
 }
 
-// NewServer creates a new MCP server with workstream tools
-func NewServer(st *store.Store) *server.MCPServer {
-	s := server.NewMCPServer("workstreams", "1.0.0")
+// NewServer creates a new MCP server with workstream tools. Call the
+// returned Handlers' SetScope to limit it to some projects, and its
+// StopWebServers when the server exits.
+func NewServer(st *store.Store) (*server.MCPServer, *Handlers) {
 	h := NewHandlers(st)
+	s := server.NewMCPServer("workstreams", "1.0.0", server.WithToolHandlerMiddleware(h.enforceScope))
 	h.RegisterTools(s)
-	return s
+	return s, h
 }
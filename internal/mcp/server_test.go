@@ -2,6 +2,10 @@ package mcp
 
 import (
 	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -10,6 +14,7 @@ import (
 	"github.com/faraz/streamctl/internal/store"
 	"github.com/faraz/streamctl/pkg/workstream"
 	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
 )
 
 func setupTestStore(t *testing.T) *store.Store {
@@ -104,6 +109,37 @@ func TestHandleListFilterState(t *testing.T) {
 	}
 }
 
+func TestHandleListFilterStale(t *testing.T) {
+	st := setupTestStore(t)
+	h := NewHandlers(st)
+	st.Create(&workstream.Workstream{Name: "Forgotten", Project: "testproject", State: workstream.StateInProgress, LastUpdate: time.Now().Add(-30 * 24 * time.Hour)})
+
+	req := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Arguments: map[string]any{"project": "testproject", "stale": true},
+		},
+	}
+	result, err := h.HandleList(context.Background(), req)
+	if err != nil || result.IsError {
+		t.Fatalf("HandleList() = %v, %v", result, err)
+	}
+	text := result.Content[0].(mcp.TextContent).Text
+	if !strings.Contains(text, "Forgotten") || strings.Contains(text, "Feature Two") {
+		t.Errorf("stale=true should list only the idle workstream:\n%s", text)
+	}
+
+	t.Setenv(workstream.StaleRulesEnv, "in_progress=60d")
+	result, _ = h.HandleList(context.Background(), req)
+	if text := result.Content[0].(mcp.TextContent).Text; strings.Contains(text, "Forgotten") {
+		t.Errorf("%s should raise the threshold:\n%s", workstream.StaleRulesEnv, text)
+	}
+
+	t.Setenv(workstream.StaleRulesEnv, "in_progress=whenever")
+	if result, _ = h.HandleList(context.Background(), req); !result.IsError {
+		t.Errorf("an invalid %s should be reported", workstream.StaleRulesEnv)
+	}
+}
+
 func TestHandleGet(t *testing.T) {
 	st := setupTestStore(t)
 	h := NewHandlers(st)
@@ -125,6 +161,286 @@ func TestHandleGet(t *testing.T) {
 	}
 }
 
+func TestHandleGetBudgeted(t *testing.T) {
+	st := setupTestStore(t)
+	h := NewHandlers(st)
+
+	for i := 0; i < 5; i++ {
+		entry := fmt.Sprintf("progress %d", i)
+		st.Update("testproject", "Feature Two", store.WorkstreamUpdate{LogEntry: &entry})
+	}
+
+	req := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Arguments: map[string]any{
+				"project":            "testproject",
+				"name":               "Feature Two",
+				"max_log_entries":    float64(2),
+				"include_done_tasks": false,
+			},
+		},
+	}
+	result, err := h.HandleGet(context.Background(), req)
+	if err != nil || result.IsError {
+		t.Fatalf("HandleGet() = %v, %v", result, err)
+	}
+	text := result.Content[0].(mcp.TextContent).Text
+	if !strings.Contains(text, "progress 4") || strings.Contains(text, "progress 2") {
+		t.Errorf("Expected the 2 newest entries only:\n%s", text)
+	}
+	if strings.Contains(text, "Done step") {
+		t.Errorf("Done task should be hidden:\n%s", text)
+	}
+	if !strings.Contains(text, "_4 older entries omitted") {
+		t.Fatalf("Missing omitted marker:\n%s", text)
+	}
+
+	// Follow the cursor
+	cursor := text[strings.Index(text, `before="`)+len(`before="`):]
+	cursor = cursor[:strings.Index(cursor, `"`)]
+	req.Params.Arguments = map[string]any{
+		"project": "testproject",
+		"name":    "Feature Two",
+		"before":  cursor,
+	}
+	result, _ = h.HandleGet(context.Background(), req)
+	text = result.Content[0].(mcp.TextContent).Text
+	if !strings.Contains(text, "progress 2") || !strings.Contains(text, "Started work.") || strings.Contains(text, "progress 3") {
+		t.Errorf("Next page should hold the remaining entries:\n%s", text)
+	}
+}
+
+func TestHandleGetInvalidSince(t *testing.T) {
+	st := setupTestStore(t)
+	h := NewHandlers(st)
+
+	req := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Arguments: map[string]any{"project": "testproject", "name": "Feature Two", "since": "last tuesday"},
+		},
+	}
+	result, _ := h.HandleGet(context.Background(), req)
+	if !result.IsError {
+		t.Errorf("Expected error for invalid since")
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	cases := map[string]time.Time{
+		"2026-10-01T08:00:00Z": time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC),
+		"2026-10-01":           time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+		"48h":                  now.Add(-48 * time.Hour),
+	}
+	for in, want := range cases {
+		got, err := parseSince(in, now)
+		if err != nil || !got.Equal(want) {
+			t.Errorf("parseSince(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+}
+
+func TestHandleCompact(t *testing.T) {
+	st := setupTestStore(t)
+	h := NewHandlers(st)
+
+	req := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Arguments: map[string]any{
+				"project": "testproject",
+				"name":    "Feature Two",
+				"summary": "Kicked off; nothing notable yet.",
+			},
+		},
+	}
+	result, err := h.HandleCompact(context.Background(), req)
+	if err != nil || result.IsError {
+		t.Fatalf("HandleCompact() = %v, %v", result, err)
+	}
+	if text := result.Content[0].(mcp.TextContent).Text; !strings.Contains(text, "1 more log entries") {
+		t.Errorf("unexpected result: %s", text)
+	}
+
+	get := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Arguments: map[string]any{"project": "testproject", "name": "Feature Two"},
+		},
+	}
+	result, _ = h.HandleGet(context.Background(), get)
+	text := result.Content[0].(mcp.TextContent).Text
+	if !strings.Contains(text, "Kicked off; nothing notable yet.") || strings.Contains(text, "Started work.") {
+		t.Errorf("workstream_get should show the summary instead of compacted entries:\n%s", text)
+	}
+
+	req.Params.Arguments = map[string]any{"project": "testproject", "name": "Feature Two"}
+	result, _ = h.HandleCompact(context.Background(), req)
+	if !result.IsError {
+		t.Errorf("Expected error without summary")
+	}
+}
+
+func TestHandleStats(t *testing.T) {
+	st := setupTestStore(t)
+	h := NewHandlers(st)
+	st.SetTaskStatus("testproject", "Feature One", 0, workstream.TaskDone)
+	blocked := workstream.StateBlocked
+	st.Update("testproject", "Feature Two", store.WorkstreamUpdate{State: &blocked})
+
+	req := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Arguments: map[string]any{"project": "testproject", "days": float64(3)},
+		},
+	}
+	result, err := h.HandleStats(context.Background(), req)
+	if err != nil || result.IsError {
+		t.Fatalf("HandleStats() = %v, %v", result, err)
+	}
+	text := result.Content[0].(mcp.TextContent).Text
+	for _, want := range []string{"last 3 days", "Throughput: 1 task done", "Feature Two", "(blocked now)"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("stats should contain %q:\n%s", want, text)
+		}
+	}
+
+	req.Params.Arguments = map[string]any{"project": "testproject", "days": float64(0)}
+	if result, _ := h.HandleStats(context.Background(), req); !result.IsError {
+		t.Errorf("days=0 should be an error")
+	}
+}
+
+func TestHandleSessionStartAndEnd(t *testing.T) {
+	st := setupTestStore(t)
+	h := NewHandlers(st)
+
+	start := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Arguments: map[string]any{"project": "testproject", "agent": "agent-123"},
+		},
+	}
+	result, err := h.HandleSessionStart(context.Background(), start)
+	if err != nil || result.IsError {
+		t.Fatalf("HandleSessionStart() = %v, %v", result, err)
+	}
+	text := result.Content[0].(mcp.TextContent).Text
+	if !strings.Contains(text, "# Session Briefing: agent-123 on testproject") || !strings.Contains(text, "- Feature Two (in_progress)") {
+		t.Errorf("briefing should list claimed work:\n%s", text)
+	}
+
+	sessions, _ := st.ActiveSessions("testproject")
+	if len(sessions) != 1 || sessions[0].Agent != "agent-123" {
+		t.Fatalf("ActiveSessions() = %+v", sessions)
+	}
+
+	// Entries logged during the session are linked to it
+	logReq := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Arguments: map[string]any{"project": "testproject", "name": "Feature Two", "log_entry": "In session"},
+		},
+	}
+	if result, _ := h.HandleUpdate(context.Background(), logReq); result.IsError {
+		t.Fatalf("HandleUpdate() = %v", result)
+	}
+	if ws, _ := st.Get("testproject", "Feature Two"); ws.Log[0].SessionID != sessions[0].ID {
+		t.Errorf("log entry session = %q, want started session %q", ws.Log[0].SessionID, sessions[0].ID)
+	}
+	if entries, _ := st.Activity(store.ActivityFilter{Project: "testproject", Session: sessions[0].ID}, 10, 0); len(entries) != 1 {
+		t.Errorf("Activity(session) = %d entries, want 1", len(entries))
+	}
+
+	end := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Arguments: map[string]any{"project": "testproject", "agent": "agent-123"},
+		},
+	}
+	result, _ = h.HandleSessionEnd(context.Background(), end)
+	if !result.IsError {
+		t.Errorf("session_end without next_steps should fail")
+	}
+
+	end.Params.Arguments = map[string]any{
+		"project":        "testproject",
+		"agent":          "agent-123",
+		"next_steps":     "Write the docs",
+		"release_claims": true,
+	}
+	result, err = h.HandleSessionEnd(context.Background(), end)
+	if err != nil || result.IsError {
+		t.Fatalf("HandleSessionEnd() = %v, %v", result, err)
+	}
+	if text := result.Content[0].(mcp.TextContent).Text; !strings.Contains(text, "released Feature Two") {
+		t.Errorf("unexpected result: %s", text)
+	}
+	if ws, _ := st.Get("testproject", "Feature Two"); ws.Owner != "" {
+		t.Errorf("claim should be released, owner = %q", ws.Owner)
+	}
+	if h.SessionID() == sessions[0].ID {
+		t.Errorf("entries after session_end should not be stamped with the ended session")
+	}
+
+	// Next briefing carries the handoff note
+	result, _ = h.HandleSessionStart(context.Background(), start)
+	if text := result.Content[0].(mcp.TextContent).Text; !strings.Contains(text, "Write the docs") {
+		t.Errorf("briefing should include previous next steps:\n%s", text)
+	}
+}
+
+func TestHandleSessionEndWithoutStart(t *testing.T) {
+	st := setupTestStore(t)
+	h := NewHandlers(st)
+
+	req := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Arguments: map[string]any{"project": "testproject", "agent": "nobody", "next_steps": "x"},
+		},
+	}
+	result, _ := h.HandleSessionEnd(context.Background(), req)
+	if !result.IsError {
+		t.Errorf("Expected error without an active session")
+	}
+}
+
+func TestHandleHelpFlow(t *testing.T) {
+	st := setupTestStore(t)
+	h := NewHandlers(st)
+
+	status := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Arguments: map[string]any{"project": "testproject", "name": "Feature One"},
+		},
+	}
+	result, _ := h.HandleHelpStatus(context.Background(), status)
+	if text := result.Content[0].(mcp.TextContent).Text; text != "No help requested" {
+		t.Errorf("status before asking = %q", text)
+	}
+
+	ask := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Arguments: map[string]any{
+				"project":       "testproject",
+				"name":          "Feature One",
+				"help_question": "Should we support IPv6?",
+				"author":        "agent-7",
+			},
+		},
+	}
+	result, err := h.HandleUpdate(context.Background(), ask)
+	if err != nil || result.IsError {
+		t.Fatalf("HandleUpdate() = %v, %v", result, err)
+	}
+
+	result, _ = h.HandleHelpStatus(context.Background(), status)
+	if text := result.Content[0].(mcp.TextContent).Text; !strings.Contains(text, "Waiting for an answer") || !strings.Contains(text, "IPv6") {
+		t.Errorf("status while waiting = %q", text)
+	}
+
+	st.AnswerHelp("testproject", "Feature One", "Yes, dual stack", "faraz")
+
+	result, _ = h.HandleHelpStatus(context.Background(), status)
+	if text := result.Content[0].(mcp.TextContent).Text; !strings.Contains(text, "Answered by faraz") || !strings.Contains(text, "Yes, dual stack") {
+		t.Errorf("status after answer = %q", text)
+	}
+}
+
 func TestHandleCreate(t *testing.T) {
 	st := setupTestStore(t)
 	h := NewHandlers(st)
@@ -360,6 +676,344 @@ func TestHandleUpdateTaskNotes(t *testing.T) {
 	}
 }
 
+func TestHandleUpdateTaskByID(t *testing.T) {
+	st := setupTestStore(t)
+	h := NewHandlers(st)
+
+	ws, _ := st.Get("testproject", "Feature One")
+	taskID := ws.Plan[0].ID
+
+	req := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Arguments: map[string]any{
+				"project":     "testproject",
+				"name":        "Feature One",
+				"task_status": map[string]any{"id": taskID, "status": "done"},
+				"task_notes":  map[string]any{"id": taskID, "notes": "Shipped"},
+				"task_edit":   map[string]any{"id": taskID, "text": "Step one (renamed)"},
+			},
+		},
+	}
+	result, err := h.HandleUpdate(context.Background(), req)
+	if err != nil {
+		t.Fatalf("HandleUpdate() error = %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("HandleUpdate() returned error result: %v", result.Content)
+	}
+
+	ws, _ = st.Get("testproject", "Feature One")
+	if ws.Plan[0].Status != workstream.TaskDone {
+		t.Errorf("Status = %q, want done", ws.Plan[0].Status)
+	}
+	if ws.Plan[0].Notes != "Shipped" {
+		t.Errorf("Notes = %q, want 'Shipped'", ws.Plan[0].Notes)
+	}
+	if ws.Plan[0].Text != "Step one (renamed)" {
+		t.Errorf("Text = %q, want 'Step one (renamed)'", ws.Plan[0].Text)
+	}
+}
+
+func TestHandleUpdateTaskAddReturnsID(t *testing.T) {
+	st := setupTestStore(t)
+	h := NewHandlers(st)
+
+	req := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Arguments: map[string]any{
+				"project":  "testproject",
+				"name":     "Feature One",
+				"task_add": "Another step",
+			},
+		},
+	}
+	result, _ := h.HandleUpdate(context.Background(), req)
+
+	ws, _ := st.Get("testproject", "Feature One")
+	text := result.Content[0].(mcp.TextContent).Text
+	if !strings.Contains(text, "added task "+ws.Plan[1].ID) {
+		t.Errorf("Result should report new task ID %s, got: %s", ws.Plan[1].ID, text)
+	}
+}
+
+func TestHandleUpdateTaskMoveAndRemoveByID(t *testing.T) {
+	st := setupTestStore(t)
+	h := NewHandlers(st)
+
+	st.AddTask("testproject", "Feature One", "Step two")
+	ws, _ := st.Get("testproject", "Feature One")
+	first, second := ws.Plan[0].ID, ws.Plan[1].ID
+
+	req := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Arguments: map[string]any{
+				"project":   "testproject",
+				"name":      "Feature One",
+				"task_move": map[string]any{"id": second, "to": float64(0)},
+			},
+		},
+	}
+	if result, _ := h.HandleUpdate(context.Background(), req); result.IsError {
+		t.Fatalf("task_move returned error result: %v", result.Content)
+	}
+	ws, _ = st.Get("testproject", "Feature One")
+	if ws.Plan[0].ID != second {
+		t.Errorf("Plan[0].ID = %s, want %s after move", ws.Plan[0].ID, second)
+	}
+
+	req.Params.Arguments = map[string]any{
+		"project":     "testproject",
+		"name":        "Feature One",
+		"task_remove": first,
+	}
+	if result, _ := h.HandleUpdate(context.Background(), req); result.IsError {
+		t.Fatalf("task_remove returned error result: %v", result.Content)
+	}
+	ws, _ = st.Get("testproject", "Feature One")
+	if len(ws.Plan) != 1 || ws.Plan[0].ID != second {
+		t.Errorf("Plan = %+v, want only %s", ws.Plan, second)
+	}
+}
+
+func TestHandleUpdateTaskUnknownID(t *testing.T) {
+	st := setupTestStore(t)
+	h := NewHandlers(st)
+
+	req := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Arguments: map[string]any{
+				"project":     "testproject",
+				"name":        "Feature One",
+				"task_status": map[string]any{"id": "t99999", "status": "done"},
+			},
+		},
+	}
+	result, err := h.HandleUpdate(context.Background(), req)
+	if err != nil {
+		t.Fatalf("HandleUpdate() error = %v", err)
+	}
+	if !result.IsError {
+		t.Error("HandleUpdate() should return error for unknown task ID")
+	}
+}
+
+func TestHandleUpdateTaskInvalidStatus(t *testing.T) {
+	st := setupTestStore(t)
+	h := NewHandlers(st)
+
+	ws, _ := st.Get("testproject", "Feature One")
+	taskID := ws.Plan[0].ID
+	for _, status := range []any{nil, "", "finished"} {
+		obj := map[string]any{"id": taskID}
+		if status != nil {
+			obj["status"] = status
+		}
+		req := mcp.CallToolRequest{
+			Params: mcp.CallToolParams{
+				Arguments: map[string]any{
+					"project":     "testproject",
+					"name":        "Feature One",
+					"task_status": obj,
+				},
+			},
+		}
+		result, err := h.HandleUpdate(context.Background(), req)
+		if err != nil {
+			t.Fatalf("HandleUpdate() error = %v", err)
+		}
+		if !result.IsError {
+			t.Errorf("HandleUpdate() should reject status %v", status)
+		}
+	}
+
+	ws, _ = st.Get("testproject", "Feature One")
+	if ws.Plan[0].Status != workstream.TaskPending {
+		t.Errorf("status = %q, want it left pending", ws.Plan[0].Status)
+	}
+}
+
+func TestHandleUpdateSubtaskAdd(t *testing.T) {
+	st := setupTestStore(t)
+	h := NewHandlers(st)
+
+	ws, _ := st.Get("testproject", "Feature One")
+	parentID := ws.Plan[0].ID
+
+	req := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Arguments: map[string]any{
+				"project":     "testproject",
+				"name":        "Feature One",
+				"subtask_add": map[string]any{"parent": parentID, "text": "Sub-step"},
+			},
+		},
+	}
+	result, err := h.HandleUpdate(context.Background(), req)
+	if err != nil {
+		t.Fatalf("HandleUpdate() error = %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("HandleUpdate() returned error result: %v", result.Content)
+	}
+
+	ws, _ = st.Get("testproject", "Feature One")
+	if len(ws.Plan[0].Children) != 1 {
+		t.Fatalf("Children length = %d, want 1", len(ws.Plan[0].Children))
+	}
+	child := ws.Plan[0].Children[0]
+	text := result.Content[0].(mcp.TextContent).Text
+	if !strings.Contains(text, "added subtask "+child.ID) {
+		t.Errorf("Result should report subtask ID %s, got: %s", child.ID, text)
+	}
+
+	// Subtasks are updated through the same ID-based parameters
+	req.Params.Arguments = map[string]any{
+		"project":     "testproject",
+		"name":        "Feature One",
+		"task_status": map[string]any{"id": child.ID, "status": "done"},
+	}
+	if result, _ := h.HandleUpdate(context.Background(), req); result.IsError {
+		t.Fatalf("task_status on subtask returned error result: %v", result.Content)
+	}
+	ws, _ = st.Get("testproject", "Feature One")
+	if ws.Plan[0].Status != workstream.TaskDone {
+		t.Errorf("parent Status = %q, want done (rolled up)", ws.Plan[0].Status)
+	}
+}
+
+func TestHandleUpdateDecisionEntry(t *testing.T) {
+	st := setupTestStore(t)
+	h := NewHandlers(st)
+
+	req := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Arguments: map[string]any{
+				"project":      "testproject",
+				"name":         "Feature One",
+				"log_entry":    "Use JWT for auth",
+				"log_type":     "decision",
+				"alternatives": []any{"server sessions", "opaque tokens"},
+				"rationale":    "stateless API",
+			},
+		},
+	}
+	result, err := h.HandleUpdate(context.Background(), req)
+	if err != nil {
+		t.Fatalf("HandleUpdate() error = %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("HandleUpdate() returned error: %v", result.Content)
+	}
+
+	ws, _ := st.Get("testproject", "Feature One")
+	if len(ws.Log) != 1 {
+		t.Fatalf("Log len = %d, want 1", len(ws.Log))
+	}
+	entry := ws.Log[0]
+	if entry.Type != workstream.EntryDecision {
+		t.Errorf("Type = %q, want decision", entry.Type)
+	}
+	if len(entry.Alternatives) != 2 || entry.Alternatives[1] != "opaque tokens" {
+		t.Errorf("Alternatives = %v", entry.Alternatives)
+	}
+	if entry.Rationale != "stateless API" {
+		t.Errorf("Rationale = %q", entry.Rationale)
+	}
+}
+
+func TestHandleUpdateInvalidLogType(t *testing.T) {
+	st := setupTestStore(t)
+	h := NewHandlers(st)
+
+	req := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Arguments: map[string]any{
+				"project":   "testproject",
+				"name":      "Feature One",
+				"log_entry": "Something",
+				"log_type":  "rant",
+			},
+		},
+	}
+	result, _ := h.HandleUpdate(context.Background(), req)
+	if !result.IsError {
+		t.Errorf("Expected error for invalid log_type")
+	}
+}
+
+func TestHandleUpdateRecordsAttribution(t *testing.T) {
+	st := setupTestStore(t)
+	h := NewHandlers(st)
+
+	session := server.NewInProcessSession("test", nil)
+	session.SetClientInfo(mcp.Implementation{Name: "claude-code", Version: "2.1.0"})
+	ctx := server.NewMCPServer("test", "1.0.0").WithContext(context.Background(), session)
+
+	req := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Arguments: map[string]any{
+				"project":   "testproject",
+				"name":      "Feature One",
+				"log_entry": "Claimed the parser work",
+				"author":    "agent-7",
+			},
+		},
+	}
+	result, err := h.HandleUpdate(ctx, req)
+	if err != nil || result.IsError {
+		t.Fatalf("HandleUpdate() = %v, %v", result, err)
+	}
+
+	ws, _ := st.Get("testproject", "Feature One")
+	entry := ws.Log[0]
+	if entry.Author != "agent-7" {
+		t.Errorf("Author = %q, want agent-7", entry.Author)
+	}
+	if entry.Client != "claude-code/2.1.0" {
+		t.Errorf("Client = %q, want claude-code/2.1.0", entry.Client)
+	}
+	if entry.SessionID == "" || entry.SessionID != h.SessionID() {
+		t.Errorf("SessionID = %q, want %q", entry.SessionID, h.SessionID())
+	}
+}
+
+func TestNewHandlersSessionIDUnique(t *testing.T) {
+	st := setupTestStore(t)
+	a, b := NewHandlers(st), NewHandlers(st)
+	if a.SessionID() == "" || a.SessionID() == b.SessionID() {
+		t.Errorf("session IDs should be non-empty and distinct: %q, %q", a.SessionID(), b.SessionID())
+	}
+}
+
+func TestHandleGetFilterLogType(t *testing.T) {
+	st := setupTestStore(t)
+	h := NewHandlers(st)
+
+	decision := "Chose SQLite"
+	st.Update("testproject", "Feature Two", store.WorkstreamUpdate{LogEntry: &decision, LogType: workstream.EntryDecision})
+
+	req := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Arguments: map[string]any{
+				"project":  "testproject",
+				"name":     "Feature Two",
+				"log_type": "decision",
+			},
+		},
+	}
+	result, err := h.HandleGet(context.Background(), req)
+	if err != nil {
+		t.Fatalf("HandleGet() error = %v", err)
+	}
+	text := result.Content[0].(mcp.TextContent).Text
+	if !strings.Contains(text, "## Decisions") || !strings.Contains(text, "Chose SQLite") {
+		t.Errorf("Expected decision in output:\n%s", text)
+	}
+	if strings.Contains(text, "Started work.") {
+		t.Errorf("Note entries should be filtered out:\n%s", text)
+	}
+}
+
 func TestHandleUpdateAddBlocker(t *testing.T) {
 	st := setupTestStore(t)
 	h := NewHandlers(st)
@@ -451,6 +1105,169 @@ func TestHandleWebServe(t *testing.T) {
 	}
 }
 
+func TestHandleWebServe_Edit(t *testing.T) {
+	st := setupTestStore(t)
+	h := NewHandlers(st)
+
+	req := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Arguments: map[string]any{
+				"project": "testproject",
+				"edit":    true,
+			},
+		},
+	}
+
+	result, err := h.HandleWebServe(context.Background(), req)
+	if err != nil || result.IsError {
+		t.Fatalf("HandleWebServe() = %v, %v", result, err)
+	}
+
+	// The URL carries the token that signs the human in
+	text := result.Content[0].(mcp.TextContent).Text
+	if !strings.Contains(text, "/?token=") {
+		t.Errorf("Result should contain a sign-in URL, got: %s", text)
+	}
+}
+
+func callWebTool(t *testing.T, handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), args map[string]any) (string, bool) {
+	t.Helper()
+	result, err := handler(context.Background(), mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: args}})
+	if err != nil {
+		t.Fatalf("tool error = %v", err)
+	}
+	return result.Content[0].(mcp.TextContent).Text, result.IsError
+}
+
+func TestHandleWebServe_Reuse(t *testing.T) {
+	st := setupTestStore(t)
+	h := NewHandlers(st)
+	t.Cleanup(h.StopWebServers)
+
+	first, isErr := callWebTool(t, h.HandleWebServe, map[string]any{"project": "testproject"})
+	if isErr {
+		t.Fatalf("web_serve: %s", first)
+	}
+	second, isErr := callWebTool(t, h.HandleWebServe, map[string]any{"project": "testproject"})
+	if isErr || !strings.Contains(second, "already running") {
+		t.Fatalf("second web_serve should reuse the server, got: %s", second)
+	}
+	url := strings.Fields(first)[3]
+	if !strings.Contains(second, url) {
+		t.Errorf("second web_serve should return %s, got: %s", url, second)
+	}
+	if len(h.web.running) != 1 {
+		t.Errorf("running = %d servers, want 1", len(h.web.running))
+	}
+
+	// Editing can't be bolted onto a read-only server
+	if text, isErr := callWebTool(t, h.HandleWebServe, map[string]any{"project": "testproject", "edit": true}); !isErr || !strings.Contains(text, "web_stop") {
+		t.Errorf("web_serve edit=true on a read-only server should point to web_stop, got: %s", text)
+	}
+}
+
+func TestHandleWebServe_FixedPort(t *testing.T) {
+	st := setupTestStore(t)
+	h := NewHandlers(st)
+	t.Cleanup(h.StopWebServers)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+
+	text, isErr := callWebTool(t, h.HandleWebServe, map[string]any{"project": "testproject", "port": float64(port), "bind": "127.0.0.1"})
+	if isErr || !strings.Contains(text, fmt.Sprintf("http://testproject.localhost:%d", port)) {
+		t.Fatalf("web_serve on port %d = %s", port, text)
+	}
+	if got := h.web.running["testproject"].addr; got != fmt.Sprintf("127.0.0.1:%d", port) {
+		t.Errorf("bound to %s", got)
+	}
+	if text, isErr := callWebTool(t, h.HandleWebServe, map[string]any{"project": "testproject", "port": float64(port + 1)}); !isErr {
+		t.Errorf("asking for another port while running should fail, got: %s", text)
+	}
+}
+
+func TestHandleWebServe_NonLoopbackNeedsAuth(t *testing.T) {
+	st := setupTestStore(t)
+	h := NewHandlers(st)
+	t.Cleanup(h.StopWebServers)
+	t.Setenv("STREAMCTL_AUTH", "")
+
+	text, isErr := callWebTool(t, h.HandleWebServe, map[string]any{"project": "testproject", "bind": "0.0.0.0", "edit": true})
+	if !isErr || !strings.Contains(text, "STREAMCTL_AUTH") {
+		t.Fatalf("web_serve on 0.0.0.0 without auth should be refused, got: %s", text)
+	}
+	if len(h.web.running) != 0 {
+		t.Errorf("running = %d servers, want 0", len(h.web.running))
+	}
+
+	authFile := filepath.Join(t.TempDir(), "auth.json")
+	os.WriteFile(authFile, []byte(`{"principals": [{"name": "faraz", "password": "pw", "roles": {"*": "write"}}]}`), 0600)
+	t.Setenv("STREAMCTL_AUTH", authFile)
+	if text, isErr := callWebTool(t, h.HandleWebServe, map[string]any{"project": "testproject", "bind": "0.0.0.0"}); isErr {
+		t.Errorf("web_serve on 0.0.0.0 with auth = %s", text)
+	}
+}
+
+func TestHandleWebServe_AllProjects(t *testing.T) {
+	st := setupTestStore(t)
+	h := NewHandlers(st)
+	t.Cleanup(h.StopWebServers)
+
+	text, isErr := callWebTool(t, h.HandleWebServe, map[string]any{})
+	if isErr || !strings.Contains(text, "http://localhost:") || !strings.Contains(text, "all projects") {
+		t.Fatalf("web_serve without a project = %s", text)
+	}
+
+	resp, err := http.Get("http://" + h.web.running[""].addr + "/p/testproject/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("GET /p/testproject/ status = %d", resp.StatusCode)
+	}
+}
+
+func TestHandleWebStop(t *testing.T) {
+	st := setupTestStore(t)
+	h := NewHandlers(st)
+	t.Cleanup(h.StopWebServers)
+
+	callWebTool(t, h.HandleWebServe, map[string]any{"project": "testproject"})
+	addr := h.web.running["testproject"].addr
+
+	// A live event stream doesn't hold up the shutdown
+	resp, err := http.Get("http://" + addr + "/api/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	start := time.Now()
+	if text, isErr := callWebTool(t, h.HandleWebStop, map[string]any{"project": "testproject"}); isErr {
+		t.Fatalf("web_stop: %s", text)
+	}
+	if elapsed := time.Since(start); elapsed >= webShutdownTimeout {
+		t.Errorf("web_stop took %s; open streams should end at once", elapsed)
+	}
+	if _, err := http.Get("http://" + addr + "/"); err == nil {
+		t.Errorf("server should no longer accept connections")
+	}
+
+	if text, isErr := callWebTool(t, h.HandleWebStop, map[string]any{"project": "testproject"}); !isErr {
+		t.Errorf("stopping twice should fail, got: %s", text)
+	}
+
+	// Serving again starts a fresh server
+	if text, isErr := callWebTool(t, h.HandleWebServe, map[string]any{"project": "testproject"}); isErr || strings.Contains(text, "already running") {
+		t.Errorf("web_serve after web_stop = %s", text)
+	}
+}
+
 func TestHandleMilestoneCreate(t *testing.T) {
 	st := setupTestStore(t)
 	h := NewHandlers(st)
@@ -0,0 +1,195 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/faraz/streamctl/internal/web"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// webShutdownTimeout bounds how long stopping a web UI waits for open
+// requests (including live event streams) to finish
+const webShutdownTimeout = 5 * time.Second
+
+// webServers are the web UIs started by web_serve in this process, keyed by
+// project ("" serves every project)
+type webServers struct {
+	mu      sync.Mutex
+	running map[string]*webServer
+}
+
+type webServer struct {
+	http   *http.Server
+	cancel context.CancelFunc // Ends requests, so live event streams close
+	addr   string             // host:port the listener is bound to
+	url    string             // What web_serve returns, including any sign-in token
+	edit   bool
+}
+
+// stop ends open requests and shuts the server down, forcing it closed if
+// that takes longer than ctx allows
+func (ws *webServer) stop(ctx context.Context) error {
+	ws.cancel()
+	if err := ws.http.Shutdown(ctx); err != nil {
+		ws.http.Close()
+		return err
+	}
+	return nil
+}
+
+// webURLHost is the host to put in a web UI's URL. Loopback and wildcard
+// binds use PROJECT.localhost, which browsers resolve locally.
+func webURLHost(bind, project string) string {
+	switch bind {
+	case "", "localhost", "127.0.0.1", "::1", "0.0.0.0", "::":
+		if project == "" {
+			return "localhost"
+		}
+		return project + ".localhost"
+	}
+	return bind
+}
+
+// isLoopback reports whether bind only accepts local connections
+func isLoopback(bind string) bool {
+	if bind == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(bind)
+	return ip != nil && ip.IsLoopback()
+}
+
+// HandleWebServe starts a web UI server and returns the URL. A project that
+// already has one running gets its existing URL back.
+func (h *Handlers) HandleWebServe(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	project := mcp.ParseString(req, "project", "")
+	edit := mcp.ParseBoolean(req, "edit", false)
+	port := mcp.ParseInt(req, "port", 0)
+	bind := mcp.ParseString(req, "bind", "localhost")
+
+	if port < 0 || port > 65535 {
+		return mcp.NewToolResultError("port must be between 0 and 65535"), nil
+	}
+	what := fmt.Sprintf("project '%s'", project)
+	if project == "" {
+		what = "all projects"
+	}
+
+	h.web.mu.Lock()
+	defer h.web.mu.Unlock()
+
+	if ws, ok := h.web.running[project]; ok {
+		if edit && !ws.edit {
+			return mcp.NewToolResultError(fmt.Sprintf("web UI for %s is already running read-only at %s; call web_stop first to restart it with editing", what, ws.url)), nil
+		}
+		if port != 0 {
+			if _, p, _ := net.SplitHostPort(ws.addr); p != strconv.Itoa(port) {
+				return mcp.NewToolResultError(fmt.Sprintf("web UI for %s is already running at %s; call web_stop first to move it to port %d", what, ws.url, port)), nil
+			}
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("Web UI already running at %s for %s", ws.url, what)), nil
+	}
+
+	// STREAMCTL_AUTH names an auth file, as for streamctl web
+	var auth *web.AuthConfig
+	if path := os.Getenv("STREAMCTL_AUTH"); path != "" {
+		var err error
+		if auth, err = web.LoadAuthConfig(path); err != nil {
+			return mcp.NewToolResultError("failed to load auth config: " + err.Error()), nil
+		}
+	}
+
+	// As with streamctl web, only loopback is served to anyone without sign-in
+	if auth == nil && !isLoopback(bind) {
+		return mcp.NewToolResultError(fmt.Sprintf("refusing to serve on %s without sign-in; set STREAMCTL_AUTH to an auth file, or bind to localhost", bind)), nil
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(bind, strconv.Itoa(port)))
+	if err != nil {
+		return mcp.NewToolResultError("failed to listen: " + err.Error()), nil
+	}
+
+	actualPort := listener.Addr().(*net.TCPAddr).Port
+	url := fmt.Sprintf("http://%s", net.JoinHostPort(webURLHost(bind, project), strconv.Itoa(actualPort)))
+
+	// Create and start the web server
+	var srv *web.Server
+	if project == "" {
+		srv = web.NewMultiServer(h.store)
+	} else {
+		srv = web.NewServer(h.store, project)
+	}
+	if auth != nil {
+		srv.EnableAuth(auth)
+	}
+	if h.scope != nil && !h.scope.AllowCrossProject {
+		srv.LimitLinks(h.scope.Projects)
+	}
+	if edit {
+		token := web.NewEditToken()
+		srv.EnableEditing(token)
+		url += "/?token=" + token
+	}
+
+	base, cancel := context.WithCancel(context.Background())
+	httpServer := &http.Server{
+		Handler:     srv,
+		BaseContext: func(net.Listener) context.Context { return base },
+	}
+	go httpServer.Serve(listener)
+
+	h.web.running[project] = &webServer{http: httpServer, cancel: cancel, addr: listener.Addr().String(), url: url, edit: edit}
+	return mcp.NewToolResultText(fmt.Sprintf("Web UI started at %s for %s", url, what)), nil
+}
+
+// HandleWebStop shuts down the web UI web_serve started for a project
+func (h *Handlers) HandleWebStop(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	project := mcp.ParseString(req, "project", "")
+
+	h.web.mu.Lock()
+	ws, ok := h.web.running[project]
+	delete(h.web.running, project)
+	h.web.mu.Unlock()
+
+	if !ok {
+		if project == "" {
+			return mcp.NewToolResultError("no web UI is running for all projects"), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("no web UI is running for project '%s'", project)), nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, webShutdownTimeout)
+	defer cancel()
+	if err := ws.stop(ctx); err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		return mcp.NewToolResultError("failed to stop web UI: " + err.Error()), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Stopped web UI at %s", ws.addr)), nil
+}
+
+// StopWebServers gracefully shuts down every web UI started by web_serve
+func (h *Handlers) StopWebServers() {
+	h.web.mu.Lock()
+	running := h.web.running
+	h.web.running = map[string]*webServer{}
+	h.web.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), webShutdownTimeout)
+	defer cancel()
+	var wg sync.WaitGroup
+	for _, ws := range running {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ws.stop(ctx)
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,334 @@
+// Package metrics computes a project's statistics from its workstreams and
+// their recorded events: tasks completed per day, cycle time, time spent
+// blocked, how often help is asked for, and burndown per milestone.
+//
+// Events are only recorded from the version that introduced them. A
+// workstream's state before its first recorded change is taken to be that
+// change's "from" state, and work finished before then has no cycle time.
+package metrics
+
+import (
+	"sort"
+	"time"
+
+	"github.com/faraz/streamctl/internal/store"
+	"github.com/faraz/streamctl/pkg/workstream"
+)
+
+// DefaultDays is how many days of throughput are reported by default
+const DefaultDays = 14
+
+// Stats are a project's metrics at a point in time
+type Stats struct {
+	Project string
+	Now     time.Time
+	Since   time.Time // Start of the first day in Throughput
+
+	Throughput []Day // Tasks completed per day, oldest first
+	TasksDone  int   // Over Throughput's days
+
+	CycleTimes      []CycleTime // Done workstreams, slowest first
+	CycleTimeMedian time.Duration
+	CycleTimeMean   time.Duration
+
+	Blocked      []BlockedTime // Workstreams that have been blocked, longest first
+	BlockedTotal time.Duration
+
+	Help       []HelpCount // needs_help raised per workstream, most first
+	HelpTotal  int
+	HelpRecent int // Raised since Since
+
+	Burndown []Burndown // One per milestone
+}
+
+// Day is a count for one UTC day
+type Day struct {
+	Date  time.Time
+	Count int
+}
+
+// CycleTime is how long a done workstream took from creation to done
+type CycleTime struct {
+	Workstream string
+	Created    time.Time
+	Done       time.Time
+	Duration   time.Duration
+}
+
+// BlockedTime is the total time a workstream has spent blocked
+type BlockedTime struct {
+	Workstream string
+	Duration   time.Duration
+	Current    bool // Still blocked
+}
+
+// HelpCount is how many times a workstream has raised needs_help
+type HelpCount struct {
+	Workstream string
+	Count      int
+}
+
+// Burndown is a milestone's remaining required workstreams, day by day
+type Burndown struct {
+	Milestone string
+	Total     int
+	Remaining int
+	Points    []BurndownPoint // From the day the milestone was created
+}
+
+// BurndownPoint is a milestone at the end of a day (or now, for today).
+// Scope counts the required workstreams that existed by then.
+type BurndownPoint struct {
+	Date      time.Time
+	Scope     int
+	Remaining int
+}
+
+// Load reads what Compute needs from the store. Milestones' required
+// workstreams in other projects are read too, for their burndown.
+func Load(st *store.Store, project string, days int, now time.Time) (*Stats, error) {
+	workstreams, err := st.List(store.Filter{Project: project})
+	if err != nil {
+		return nil, err
+	}
+	milestones, err := st.ListMilestones(project)
+	if err != nil {
+		return nil, err
+	}
+	events, err := st.EventsOfType(project, workstream.EventStateChanged, workstream.EventTaskDone, workstream.EventNeedsHelpRaised)
+	if err != nil {
+		return nil, err
+	}
+
+	others := map[string]bool{}
+	for _, m := range milestones {
+		for _, req := range m.Requirements {
+			if req.WorkstreamProject != project {
+				others[req.WorkstreamProject] = true
+			}
+		}
+	}
+	for other := range others {
+		ws, err := st.List(store.Filter{Project: other})
+		if err != nil {
+			return nil, err
+		}
+		changes, err := st.StateChanges(other)
+		if err != nil {
+			return nil, err
+		}
+		workstreams = append(workstreams, ws...)
+		events = append(events, changes...)
+	}
+
+	return Compute(project, workstreams, events, milestones, days, now), nil
+}
+
+// Compute derives project's metrics from its workstreams, milestones and
+// their state_changed, task_done and needs_help_raised events. Workstreams
+// and events from other projects only count towards burndown.
+func Compute(project string, workstreams []workstream.Workstream, events []workstream.Event, milestones []workstream.Milestone, days int, now time.Time) *Stats {
+	if days <= 0 {
+		days = DefaultDays
+	}
+	today := day(now)
+	st := &Stats{Project: project, Now: now, Since: today.AddDate(0, 0, 1-days)}
+
+	changes := map[string][]workstream.Event{}
+	help := map[string]int{}
+	for i := range days {
+		st.Throughput = append(st.Throughput, Day{Date: st.Since.AddDate(0, 0, i)})
+	}
+	for _, ev := range events {
+		if ev.Type == workstream.EventStateChanged {
+			key := ev.Project + "/" + ev.Workstream
+			changes[key] = append(changes[key], ev)
+			continue
+		}
+		if ev.Project != project {
+			continue
+		}
+		switch ev.Type {
+		case workstream.EventTaskDone:
+			if i := int(day(ev.CreatedAt).Sub(st.Since).Hours() / 24); i >= 0 && i < days {
+				st.Throughput[i].Count++
+				st.TasksDone++
+			}
+		case workstream.EventNeedsHelpRaised:
+			help[ev.Workstream]++
+			st.HelpTotal++
+			if !ev.CreatedAt.Before(st.Since) {
+				st.HelpRecent++
+			}
+		}
+	}
+
+	histories := map[string]history{}
+	for _, ws := range workstreams {
+		key := ws.Project + "/" + ws.Name
+		h := newHistory(ws, changes[key])
+		histories[key] = h
+		if ws.Project != project {
+			continue
+		}
+
+		if ws.State == workstream.StateDone {
+			if done := h.doneAt(); !done.IsZero() && !h.created.IsZero() {
+				st.CycleTimes = append(st.CycleTimes, CycleTime{Workstream: ws.Name, Created: h.created, Done: done, Duration: done.Sub(h.created)})
+			}
+		}
+
+		var blocked time.Duration
+		for _, sp := range h.spans(now) {
+			if sp.state == workstream.StateBlocked {
+				blocked += sp.to.Sub(sp.from)
+			}
+		}
+		if blocked > 0 {
+			st.Blocked = append(st.Blocked, BlockedTime{Workstream: ws.Name, Duration: blocked, Current: ws.State == workstream.StateBlocked})
+			st.BlockedTotal += blocked
+		}
+	}
+
+	sort.Slice(st.CycleTimes, func(i, j int) bool { return st.CycleTimes[i].Duration > st.CycleTimes[j].Duration })
+	if n := len(st.CycleTimes); n > 0 {
+		var total time.Duration
+		for _, ct := range st.CycleTimes {
+			total += ct.Duration
+		}
+		st.CycleTimeMean = total / time.Duration(n)
+		if n%2 == 1 {
+			st.CycleTimeMedian = st.CycleTimes[n/2].Duration
+		} else {
+			st.CycleTimeMedian = (st.CycleTimes[n/2-1].Duration + st.CycleTimes[n/2].Duration) / 2
+		}
+	}
+	sort.Slice(st.Blocked, func(i, j int) bool { return st.Blocked[i].Duration > st.Blocked[j].Duration })
+
+	for name, n := range help {
+		st.Help = append(st.Help, HelpCount{Workstream: name, Count: n})
+	}
+	sort.Slice(st.Help, func(i, j int) bool {
+		if st.Help[i].Count != st.Help[j].Count {
+			return st.Help[i].Count > st.Help[j].Count
+		}
+		return st.Help[i].Workstream < st.Help[j].Workstream
+	})
+
+	for _, m := range milestones {
+		if m.Project == project {
+			st.Burndown = append(st.Burndown, burndown(m, histories, st.Since, now))
+		}
+	}
+	return st
+}
+
+// burndown counts m's remaining required workstreams at the end of each day
+// since it was created. Requirements have no recorded time, so each counts
+// from when its workstream was created.
+func burndown(m workstream.Milestone, histories map[string]history, fallback, now time.Time) Burndown {
+	b := Burndown{Milestone: m.Name}
+	start := day(m.CreatedAt)
+	if m.CreatedAt.IsZero() {
+		start = fallback
+	}
+	var required []history
+	for _, req := range m.Requirements {
+		if h, ok := histories[req.WorkstreamProject+"/"+req.WorkstreamName]; ok {
+			required = append(required, h)
+		}
+	}
+
+	for d := start; !d.After(now); d = d.AddDate(0, 0, 1) {
+		at := d.AddDate(0, 0, 1)
+		if at.After(now) {
+			at = now
+		}
+		p := BurndownPoint{Date: d}
+		for _, h := range required {
+			state, existed := h.stateAt(at)
+			if !existed {
+				continue
+			}
+			p.Scope++
+			if state != workstream.StateDone {
+				p.Remaining++
+			}
+		}
+		b.Points = append(b.Points, p)
+	}
+	if n := len(b.Points); n > 0 {
+		b.Total, b.Remaining = b.Points[n-1].Scope, b.Points[n-1].Remaining
+	}
+	return b
+}
+
+// history is a workstream's states over time
+type history struct {
+	created time.Time // Zero if unknown: it has always existed
+	initial workstream.State
+	changes []workstream.Event // state_changed, oldest first
+}
+
+type span struct {
+	state    workstream.State
+	from, to time.Time
+}
+
+func newHistory(ws workstream.Workstream, changes []workstream.Event) history {
+	h := history{created: ws.CreatedAt, initial: ws.State, changes: changes}
+	if len(changes) > 0 {
+		h.initial = workstream.State(changes[0].Data["from"])
+		if h.created.IsZero() || changes[0].CreatedAt.Before(h.created) {
+			h.created = changes[0].CreatedAt
+		}
+	}
+	return h
+}
+
+// stateAt returns the state at t, and false if the workstream did not exist yet
+func (h history) stateAt(t time.Time) (workstream.State, bool) {
+	if t.Before(h.created) {
+		return "", false
+	}
+	state := h.initial
+	for _, ev := range h.changes {
+		if ev.CreatedAt.After(t) {
+			break
+		}
+		state = workstream.State(ev.Data["to"])
+	}
+	return state, true
+}
+
+// spans splits the time from creation to now by state
+func (h history) spans(now time.Time) []span {
+	var spans []span
+	from, state := h.created, h.initial
+	for _, ev := range h.changes {
+		if !from.IsZero() {
+			spans = append(spans, span{state: state, from: from, to: ev.CreatedAt})
+		}
+		from, state = ev.CreatedAt, workstream.State(ev.Data["to"])
+	}
+	if !from.IsZero() {
+		spans = append(spans, span{state: state, from: from, to: now})
+	}
+	return spans
+}
+
+// doneAt is when the workstream last moved to done, or zero if unrecorded
+func (h history) doneAt() time.Time {
+	for i := len(h.changes) - 1; i >= 0; i-- {
+		if workstream.State(h.changes[i].Data["to"]) == workstream.StateDone {
+			return h.changes[i].CreatedAt
+		}
+	}
+	return time.Time{}
+}
+
+// day truncates t to midnight UTC
+func day(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,135 @@
+package metrics
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/faraz/streamctl/internal/store"
+	"github.com/faraz/streamctl/pkg/workstream"
+)
+
+func TestCompute(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	at := func(days int) time.Time { return now.AddDate(0, 0, -days) }
+	change := func(name string, days int, from, to workstream.State) workstream.Event {
+		return workstream.Event{Type: workstream.EventStateChanged, Project: "p", Workstream: name,
+			Data: map[string]string{"from": string(from), "to": string(to)}, CreatedAt: at(days)}
+	}
+	workstreams := []workstream.Workstream{
+		{Project: "p", Name: "auth", State: workstream.StateDone, CreatedAt: at(9)},
+		{Project: "p", Name: "api", State: workstream.StateDone, CreatedAt: at(8)},
+		{Project: "p", Name: "ui", State: workstream.StateBlocked, CreatedAt: at(5)},
+		{Project: "other", Name: "sdk", State: workstream.StateDone, CreatedAt: at(9)},
+	}
+	events := []workstream.Event{
+		change("auth", 8, workstream.StatePending, workstream.StateInProgress),
+		change("auth", 5, workstream.StateInProgress, workstream.StateDone),
+		change("api", 7, workstream.StatePending, workstream.StateBlocked),
+		change("api", 5, workstream.StateBlocked, workstream.StateInProgress),
+		change("api", 2, workstream.StateInProgress, workstream.StateDone),
+		change("ui", 3, workstream.StatePending, workstream.StateBlocked),
+		{Type: workstream.EventStateChanged, Project: "other", Workstream: "sdk", Data: map[string]string{"from": "pending", "to": "done"}, CreatedAt: at(1)},
+		{Type: workstream.EventTaskDone, Project: "p", Workstream: "auth", CreatedAt: at(5)},
+		{Type: workstream.EventTaskDone, Project: "p", Workstream: "api", CreatedAt: at(2)},
+		{Type: workstream.EventTaskDone, Project: "p", Workstream: "api", CreatedAt: at(2).Add(time.Hour)},
+		{Type: workstream.EventTaskDone, Project: "p", Workstream: "api", CreatedAt: at(30)}, // Before the window
+		{Type: workstream.EventTaskDone, Project: "other", Workstream: "sdk", CreatedAt: at(1)},
+		{Type: workstream.EventNeedsHelpRaised, Project: "p", Workstream: "ui", CreatedAt: at(3)},
+		{Type: workstream.EventNeedsHelpRaised, Project: "p", Workstream: "ui", CreatedAt: at(1)},
+		{Type: workstream.EventNeedsHelpRaised, Project: "p", Workstream: "api", CreatedAt: at(20)},
+	}
+	milestones := []workstream.Milestone{{
+		Project: "p", Name: "beta", CreatedAt: at(9),
+		Requirements: []workstream.MilestoneRequirement{
+			{WorkstreamProject: "p", WorkstreamName: "auth"},
+			{WorkstreamProject: "p", WorkstreamName: "ui"},
+			{WorkstreamProject: "other", WorkstreamName: "sdk"},
+		},
+	}}
+
+	st := Compute("p", workstreams, events, milestones, 7, now)
+
+	if len(st.Throughput) != 7 || !st.Since.Equal(time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("throughput = %d days since %v, want 7 since Mar 4", len(st.Throughput), st.Since)
+	}
+	if st.TasksDone != 3 || st.Throughput[1].Count != 1 || st.Throughput[4].Count != 2 {
+		t.Errorf("throughput = %+v, want 1 on Mar 5 and 2 on Mar 8", st.Throughput)
+	}
+
+	if len(st.CycleTimes) != 2 || st.CycleTimes[0].Workstream != "api" || st.CycleTimes[0].Duration != 6*24*time.Hour {
+		t.Errorf("cycle times = %+v, want api at 6 days first", st.CycleTimes)
+	}
+	if st.CycleTimeMedian != 5*24*time.Hour || st.CycleTimeMean != 5*24*time.Hour {
+		t.Errorf("median = %v, mean = %v, want 5 days", st.CycleTimeMedian, st.CycleTimeMean)
+	}
+
+	if len(st.Blocked) != 2 || st.Blocked[0].Workstream != "ui" || !st.Blocked[0].Current || st.Blocked[0].Duration != 3*24*time.Hour {
+		t.Errorf("blocked = %+v, want ui blocked for 3 days and counting", st.Blocked)
+	}
+	if st.BlockedTotal != 5*24*time.Hour {
+		t.Errorf("blocked total = %v, want 5 days", st.BlockedTotal)
+	}
+
+	if st.HelpTotal != 3 || st.HelpRecent != 2 || st.Help[0] != (HelpCount{Workstream: "ui", Count: 2}) {
+		t.Errorf("help = %d (%d recent) %+v", st.HelpTotal, st.HelpRecent, st.Help)
+	}
+
+	if len(st.Burndown) != 1 {
+		t.Fatalf("burndown = %+v", st.Burndown)
+	}
+	b := st.Burndown[0]
+	if len(b.Points) != 10 || b.Total != 3 || b.Remaining != 1 {
+		t.Errorf("burndown = %d points, %d/%d remaining; want 10 points, 1/3", len(b.Points), b.Remaining, b.Total)
+	}
+	// ui only joins the scope once it exists; auth burns down when done
+	if p := b.Points[1]; p.Scope != 2 || p.Remaining != 2 {
+		t.Errorf("day 2 = %+v, want 2 of 2 remaining", p)
+	}
+	if p := b.Points[5]; p.Scope != 3 || p.Remaining != 2 {
+		t.Errorf("day 6 = %+v, want 2 of 3 remaining", p)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	s, err := store.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	s.Create(&workstream.Workstream{Project: "p", Name: "auth", State: workstream.StatePending, Plan: []workstream.PlanItem{{Text: "schema"}}})
+	s.Create(&workstream.Workstream{Project: "other", Name: "sdk", State: workstream.StatePending})
+	s.CreateMilestone(&workstream.Milestone{Project: "p", Name: "beta"})
+	s.AddMilestoneRequirement("p", "beta", "p", "auth")
+	s.AddMilestoneRequirement("p", "beta", "other", "sdk")
+	s.SetTaskStatus("p", "auth", 0, workstream.TaskDone)
+	s.RequestHelp("p", "auth", "Which DB?", "agent-1")
+	done := workstream.StateDone
+	s.Update("p", "auth", store.WorkstreamUpdate{State: &done})
+	s.Update("other", "sdk", store.WorkstreamUpdate{State: &done})
+
+	st, err := Load(s, "p", 0, time.Now().UTC())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(st.Throughput) != DefaultDays || st.TasksDone != 1 {
+		t.Errorf("throughput = %d days, %d tasks; want %d days, 1 task", len(st.Throughput), st.TasksDone, DefaultDays)
+	}
+	if len(st.CycleTimes) != 1 || st.HelpTotal != 1 {
+		t.Errorf("cycle times = %+v, help = %d", st.CycleTimes, st.HelpTotal)
+	}
+	if len(st.Burndown) != 1 || st.Burndown[0].Total != 2 || st.Burndown[0].Remaining != 0 {
+		t.Errorf("burndown = %+v, want both requirements done", st.Burndown)
+	}
+
+	var buf bytes.Buffer
+	st.WriteText(&buf)
+	for _, want := range []string{"Stats for p (last 14 days)", "Throughput: 1 task done", "median", "needs_help: raised 1 time", "beta", "0/2 remaining"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("report should contain %q:\n%s", want, buf.String())
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/faraz/streamctl/pkg/workstream"
+)
+
+// barWidth is the longest bar drawn by WriteText
+const barWidth = 30
+
+// WriteText writes st as a plain-text report with bar charts
+func (st *Stats) WriteText(w io.Writer) {
+	days := len(st.Throughput)
+	fmt.Fprintf(w, "Stats for %s (last %d days)\n\n", st.Project, days)
+
+	fmt.Fprintf(w, "Throughput: %d task%s done, %.1f/day\n", st.TasksDone, plural(st.TasksDone), float64(st.TasksDone)/float64(max(days, 1)))
+	most := 0
+	for _, d := range st.Throughput {
+		most = max(most, d.Count)
+	}
+	for _, d := range st.Throughput {
+		fmt.Fprintf(w, "  %s  %-*s %d\n", d.Date.Format("Jan 02"), barWidth, bar(d.Count, most), d.Count)
+	}
+
+	fmt.Fprintln(w)
+	if len(st.CycleTimes) == 0 {
+		fmt.Fprintln(w, "Cycle time (created → done): no done workstreams with a recorded history")
+	} else {
+		fmt.Fprintf(w, "Cycle time (created → done): median %s, mean %s over %d workstream%s\n",
+			workstream.FormatDuration(st.CycleTimeMedian), workstream.FormatDuration(st.CycleTimeMean), len(st.CycleTimes), plural(len(st.CycleTimes)))
+		for _, ct := range st.CycleTimes {
+			fmt.Fprintf(w, "  %-24s %s\n", ct.Workstream, workstream.FormatDuration(ct.Duration))
+		}
+	}
+
+	fmt.Fprintln(w)
+	if len(st.Blocked) == 0 {
+		fmt.Fprintln(w, "Time blocked: none")
+	} else {
+		fmt.Fprintf(w, "Time blocked: %s in total\n", workstream.FormatDuration(st.BlockedTotal))
+		for _, b := range st.Blocked {
+			current := ""
+			if b.Current {
+				current = " (blocked now)"
+			}
+			fmt.Fprintf(w, "  %-24s %s%s\n", b.Workstream, workstream.FormatDuration(b.Duration), current)
+		}
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "needs_help: raised %d time%s, %d in the last %d days\n", st.HelpTotal, plural(st.HelpTotal), st.HelpRecent, days)
+	for _, h := range st.Help {
+		fmt.Fprintf(w, "  %-24s %d\n", h.Workstream, h.Count)
+	}
+
+	fmt.Fprintln(w)
+	if len(st.Burndown) == 0 {
+		fmt.Fprintln(w, "Burndown: no milestones")
+		return
+	}
+	fmt.Fprintln(w, "Burndown (workstreams remaining):")
+	for _, b := range st.Burndown {
+		fmt.Fprintf(w, "  %-24s %d/%d remaining\n", b.Milestone, b.Remaining, b.Total)
+		// The last days, so a long-running milestone stays readable
+		points := b.Points[max(len(b.Points)-days, 0):]
+		for _, p := range points {
+			fmt.Fprintf(w, "    %s  %-*s %d/%d\n", p.Date.Format("Jan 02"), barWidth, bar(p.Remaining, b.Total), p.Remaining, p.Scope)
+		}
+	}
+}
+
+// bar draws n out of most as up to barWidth blocks
+func bar(n, most int) string {
+	if most == 0 {
+		return ""
+	}
+	return strings.Repeat("█", (n*barWidth+most-1)/most)
+}
+
+func plural(n int) string {
+	if n == 1 {
+		return ""
+	}
+	return "s"
+}
@@ -0,0 +1,53 @@
+package store
+
+import "time"
+
+// AuditEntry records a write made through the web UI or its APIs, and the
+// principal who made it
+type AuditEntry struct {
+	ID         int64
+	Principal  string
+	Project    string
+	Workstream string
+	Action     string
+	Detail     string
+	CreatedAt  time.Time
+}
+
+// RecordAudit appends a write to the audit log
+func (s *Store) RecordAudit(entry *AuditEntry) error {
+	entry.CreatedAt = time.Now().UTC()
+	res, err := s.db.Exec(`
+		INSERT INTO audit_log (principal, project, workstream, action, detail, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		entry.Principal, entry.Project, entry.Workstream, entry.Action, entry.Detail, entry.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	entry.ID, err = res.LastInsertId()
+	return err
+}
+
+// AuditLog returns the most recent audit entries, newest first, for one
+// project or (if project is "") all of them
+func (s *Store) AuditLog(project string, limit int) ([]AuditEntry, error) {
+	rows, err := s.db.Query(`
+		SELECT id, principal, project, workstream, action, detail, created_at
+		FROM audit_log WHERE ? = '' OR project = ?
+		ORDER BY id DESC LIMIT ?`, project, project, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []AuditEntry
+	for rows.Next() {
+		var e AuditEntry
+		if err := rows.Scan(&e.ID, &e.Principal, &e.Project, &e.Workstream, &e.Action, &e.Detail, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
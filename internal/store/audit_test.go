@@ -0,0 +1,38 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestAuditLog(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	s, _ := New(dbPath)
+	defer s.Close()
+
+	for _, e := range []AuditEntry{
+		{Principal: "alice", Project: "proj", Workstream: "auth", Action: "state", Detail: "state set to done"},
+		{Principal: "ci", Project: "other", Workstream: "docs", Action: "log", Detail: "logged"},
+		{Principal: "bob", Project: "proj", Workstream: "api", Action: "claim", Detail: "claimed for bob"},
+	} {
+		if err := s.RecordAudit(&e); err != nil {
+			t.Fatalf("RecordAudit() error = %v", err)
+		}
+		if e.ID == 0 || e.CreatedAt.IsZero() {
+			t.Errorf("RecordAudit() should set ID and CreatedAt, got %+v", e)
+		}
+	}
+
+	got, err := s.AuditLog("proj", 10)
+	if err != nil {
+		t.Fatalf("AuditLog() error = %v", err)
+	}
+	if len(got) != 2 || got[0].Principal != "bob" || got[1].Principal != "alice" {
+		t.Errorf("AuditLog(proj) = %+v, want bob then alice", got)
+	}
+
+	all, _ := s.AuditLog("", 2)
+	if len(all) != 2 || all[0].Principal != "bob" || all[1].Principal != "ci" {
+		t.Errorf("AuditLog(\"\", 2) = %+v, want the two newest", all)
+	}
+}
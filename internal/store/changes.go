@@ -0,0 +1,55 @@
+package store
+
+import (
+	"time"
+
+	"github.com/faraz/streamctl/pkg/workstream"
+)
+
+// Revision returns a token that changes whenever anything shown for a
+// project changes: workstreams, log entries, events, dependencies or
+// sessions. It is a handful of index lookups, so it can be polled often.
+func (s *Store) Revision(project string) (string, error) {
+	var rev string
+	err := s.db.QueryRow(`
+		SELECT
+			(SELECT COUNT(*) || '/' || COALESCE(MAX(last_update), '') FROM workstreams WHERE project = ?) || '/' ||
+			(SELECT COALESCE(MAX(l.id), 0) FROM log_entries l JOIN workstreams w ON w.id = l.workstream_id WHERE w.project = ?) || '/' ||
+			(SELECT COALESCE(MAX(id), 0) FROM events WHERE project = ?) || '/' ||
+			(SELECT COUNT(*) FROM workstream_dependencies) || '/' ||
+			(SELECT COUNT(*) || '/' || COALESCE(MAX(started_at), '') || '/' || COALESCE(MAX(ended_at), '') FROM sessions WHERE project = ?)`,
+		project, project, project, project,
+	).Scan(&rev)
+	return rev, err
+}
+
+// Snapshots returns the headline status of a project's workstreams updated
+// after since (all of them for a zero time), ordered by name
+func (s *Store) Snapshots(project string, since time.Time) ([]workstream.Snapshot, error) {
+	query := `
+		SELECT project, name, state, owner, needs_help, last_update,
+			state = ? OR EXISTS (SELECT 1 FROM workstream_dependencies d WHERE d.blocked_id = workstreams.id)
+		FROM workstreams WHERE project = ?`
+	args := []any{string(workstream.StateBlocked), project}
+	if !since.IsZero() {
+		query += ` AND last_update > ?`
+		args = append(args, since.UTC())
+	}
+	query += ` ORDER BY name`
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var snaps []workstream.Snapshot
+	for rows.Next() {
+		var snap workstream.Snapshot
+		if err := rows.Scan(&snap.Project, &snap.Name, &snap.State, &snap.Owner, &snap.NeedsHelp, &snap.LastUpdate, &snap.Blocked); err != nil {
+			return nil, err
+		}
+		snaps = append(snaps, snap)
+	}
+	return snaps, rows.Err()
+}
@@ -0,0 +1,81 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/faraz/streamctl/pkg/workstream"
+)
+
+func TestRevisionChangesWithData(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	s, _ := New(dbPath)
+	defer s.Close()
+
+	rev := func() string {
+		t.Helper()
+		r, err := s.Revision("proj")
+		if err != nil {
+			t.Fatalf("Revision() error = %v", err)
+		}
+		return r
+	}
+
+	r0 := rev()
+	if r0 != rev() {
+		t.Errorf("Revision() should be stable without changes")
+	}
+
+	s.Create(&workstream.Workstream{Name: "ws1", Project: "proj"})
+	s.Create(&workstream.Workstream{Name: "ws2", Project: "proj"})
+	r1 := rev()
+	if r1 == r0 {
+		t.Errorf("Revision() unchanged after Create")
+	}
+
+	s.AddDependency("proj", "ws1", "proj", "ws2")
+	r2 := rev()
+	if r2 == r1 {
+		t.Errorf("Revision() unchanged after AddDependency")
+	}
+
+	s.StartSession("sess1", "proj", "agent-1", "")
+	if rev() == r2 {
+		t.Errorf("Revision() unchanged after StartSession")
+	}
+
+	r3 := rev()
+	s.Create(&workstream.Workstream{Name: "elsewhere", Project: "other"})
+	if rev() != r3 {
+		t.Errorf("Revision() changed for another project's workstream")
+	}
+}
+
+func TestSnapshots(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	s, _ := New(dbPath)
+	defer s.Close()
+
+	s.Create(&workstream.Workstream{Name: "a", Project: "proj", State: workstream.StateInProgress, Owner: "agent-1"})
+	s.Create(&workstream.Workstream{Name: "b", Project: "proj", State: workstream.StatePending})
+	s.AddDependency("proj", "a", "proj", "b")
+
+	snaps, err := s.Snapshots("proj", time.Time{})
+	if err != nil {
+		t.Fatalf("Snapshots() error = %v", err)
+	}
+	if len(snaps) != 2 || snaps[0].Name != "a" || snaps[0].Owner != "agent-1" || snaps[0].Blocked || !snaps[1].Blocked {
+		t.Errorf("Snapshots() = %+v", snaps)
+	}
+
+	since := time.Now().UTC()
+	time.Sleep(time.Millisecond)
+	help := true
+	s.Update("proj", "b", WorkstreamUpdate{NeedsHelp: &help})
+
+	changed, _ := s.Snapshots("proj", since)
+	if len(changed) != 1 || changed[0].Name != "b" || !changed[0].NeedsHelp {
+		t.Errorf("Snapshots(since) = %+v", changed)
+	}
+}
@@ -0,0 +1,544 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/faraz/streamctl/pkg/workstream"
+)
+
+// recordEvent appends an event to the events table and queues a delivery for
+// every webhook subscribed to it, inside the transaction that made the change
+func recordEvent(tx *sql.Tx, ev workstream.Event) error {
+	if ev.CreatedAt.IsZero() {
+		ev.CreatedAt = time.Now().UTC()
+	}
+	data, err := json.Marshal(ev.Data)
+	if err != nil {
+		return err
+	}
+	res, err := tx.Exec(`
+		INSERT INTO events (type, project, workstream, milestone, data, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		string(ev.Type), ev.Project, ev.Workstream, ev.Milestone, string(data), ev.CreatedAt)
+	if err != nil {
+		return err
+	}
+	eventID, _ := res.LastInsertId()
+
+	rows, err := tx.Query(`SELECT id, events FROM webhooks WHERE project = '' OR project = ?`, ev.Project)
+	if err != nil {
+		return err
+	}
+	var hookIDs []int64
+	for rows.Next() {
+		var hook Webhook
+		var events string
+		if err := rows.Scan(&hook.ID, &events); err != nil {
+			rows.Close()
+			return err
+		}
+		hook.Events = splitEventTypes(events)
+		if hook.Wants(ev.Type) {
+			hookIDs = append(hookIDs, hook.ID)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, hookID := range hookIDs {
+		_, err := tx.Exec(`
+			INSERT INTO webhook_deliveries (event_id, webhook_id, status, next_attempt, updated_at)
+			VALUES (?, ?, ?, ?, ?)`,
+			eventID, hookID, string(DeliveryPending), ev.CreatedAt, ev.CreatedAt)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordCompletedMilestones records a milestone_completed event for each
+// milestone that wsID has just completed by moving to done
+func recordCompletedMilestones(tx *sql.Tx, wsID int64) error {
+	rows, err := tx.Query(`
+		SELECT m.project, m.name FROM milestones m
+		JOIN milestone_requirements mr ON mr.milestone_id = m.id
+		WHERE mr.workstream_id = ? AND NOT EXISTS (
+			SELECT 1 FROM milestone_requirements r
+			JOIN workstreams w ON w.id = r.workstream_id
+			WHERE r.milestone_id = m.id AND w.state != ?
+		)`, wsID, string(workstream.StateDone))
+	if err != nil {
+		return err
+	}
+	var completed []workstream.Event
+	for rows.Next() {
+		ev := workstream.Event{Type: workstream.EventMilestoneCompleted}
+		if err := rows.Scan(&ev.Project, &ev.Milestone); err != nil {
+			rows.Close()
+			return err
+		}
+		completed = append(completed, ev)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, ev := range completed {
+		if err := recordEvent(tx, ev); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Events returns events recorded after afterID, oldest first. An empty
+// project returns events from all projects.
+func (s *Store) Events(project string, afterID int64, limit int) ([]workstream.Event, error) {
+	query := `SELECT id, type, project, workstream, milestone, data, created_at FROM events WHERE id > ?`
+	args := []any{afterID}
+	if project != "" {
+		query += ` AND project = ?`
+		args = append(args, project)
+	}
+	query += ` ORDER BY id`
+	if limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, limit)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []workstream.Event
+	for rows.Next() {
+		ev, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, *ev)
+	}
+	return events, rows.Err()
+}
+
+// StateChanges returns the state_changed events of project's workstreams,
+// oldest first: each workstream's state history since events were recorded
+func (s *Store) StateChanges(project string) ([]workstream.Event, error) {
+	return s.EventsOfType(project, workstream.EventStateChanged)
+}
+
+// EventsOfType returns project's events of the given types, oldest first
+func (s *Store) EventsOfType(project string, types ...workstream.EventType) ([]workstream.Event, error) {
+	if len(types) == 0 {
+		return nil, nil
+	}
+	args := []any{project}
+	for _, t := range types {
+		args = append(args, string(t))
+	}
+	rows, err := s.db.Query(`
+		SELECT id, type, project, workstream, milestone, data, created_at FROM events
+		WHERE project = ? AND type IN (?`+strings.Repeat(", ?", len(types)-1)+`) ORDER BY id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []workstream.Event
+	for rows.Next() {
+		ev, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, *ev)
+	}
+	return events, rows.Err()
+}
+
+// EventFilter selects events for RecentEvents
+type EventFilter struct {
+	Project    string
+	Types      []workstream.EventType // Only events of these types
+	Workstream string                 // Only events for this workstream
+	Milestone  string                 // Only events for workstreams this milestone (in Project) requires
+}
+
+// RecentEvents returns the latest events matching the filter, newest first
+func (s *Store) RecentEvents(filter EventFilter, limit int) ([]workstream.Event, error) {
+	query := `SELECT id, type, project, workstream, milestone, data, created_at FROM events WHERE project = ?`
+	args := []any{filter.Project}
+	if len(filter.Types) > 0 {
+		query += ` AND type IN (?` + strings.Repeat(", ?", len(filter.Types)-1) + `)`
+		for _, t := range filter.Types {
+			args = append(args, string(t))
+		}
+	}
+	if filter.Workstream != "" {
+		query += ` AND workstream = ?`
+		args = append(args, filter.Workstream)
+	}
+	if filter.Milestone != "" {
+		query += ` AND workstream IN (
+			SELECT w.name FROM milestone_requirements mr
+			JOIN milestones m ON mr.milestone_id = m.id
+			JOIN workstreams w ON mr.workstream_id = w.id
+			WHERE m.project = ? AND m.name = ? AND w.project = ?)`
+		args = append(args, filter.Project, filter.Milestone, filter.Project)
+	}
+	query += ` ORDER BY id DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []workstream.Event
+	for rows.Next() {
+		ev, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, *ev)
+	}
+	return events, rows.Err()
+}
+
+// LatestIDs returns the highest event and log entry IDs, so a poller can
+// start from the present
+func (s *Store) LatestIDs() (eventID, entryID int64, err error) {
+	err = s.db.QueryRow(`SELECT (SELECT COALESCE(MAX(id), 0) FROM events), (SELECT COALESCE(MAX(id), 0) FROM log_entries)`).
+		Scan(&eventID, &entryID)
+	return eventID, entryID, err
+}
+
+func scanEvent(row interface{ Scan(...any) error }, extra ...any) (*workstream.Event, error) {
+	ev := &workstream.Event{}
+	var evType, data string
+	dest := append([]any{&ev.ID, &evType, &ev.Project, &ev.Workstream, &ev.Milestone, &data, &ev.CreatedAt}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	ev.Type = workstream.EventType(evType)
+	if err := json.Unmarshal([]byte(data), &ev.Data); err != nil {
+		return nil, err
+	}
+	return ev, nil
+}
+
+// Webhook is an HTTP endpoint that receives events as JSON
+type Webhook struct {
+	ID        int64
+	URL       string
+	Secret    string                 // HMAC-SHA256 signing key; empty sends unsigned requests
+	Project   string                 // Only events from this project; empty for all
+	Events    []workstream.EventType // Only these event types; empty for all
+	CreatedAt time.Time
+}
+
+// Wants reports whether the webhook subscribes to events of type t
+func (w Webhook) Wants(t workstream.EventType) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, e := range w.Events {
+		if e == t {
+			return true
+		}
+	}
+	return false
+}
+
+// DeliveryStatus is the state of a webhook delivery
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliveryDelivered DeliveryStatus = "delivered"
+	DeliveryFailed    DeliveryStatus = "failed" // Gave up after the last retry
+)
+
+// Delivery is one event queued for one webhook, with the outcome of its
+// latest attempt
+type Delivery struct {
+	ID           int64
+	Event        workstream.Event
+	WebhookID    int64
+	URL          string
+	Secret       string
+	Status       DeliveryStatus
+	Attempts     int
+	NextAttempt  time.Time
+	ResponseCode int
+	LastError    string
+	UpdatedAt    time.Time
+}
+
+// AddWebhook registers a webhook target
+func (s *Store) AddWebhook(hook *Webhook) error {
+	if !strings.HasPrefix(hook.URL, "http://") && !strings.HasPrefix(hook.URL, "https://") {
+		return fmt.Errorf("webhook URL must be http or https: %s", hook.URL)
+	}
+	hook.CreatedAt = time.Now().UTC()
+	res, err := s.db.Exec(`
+		INSERT INTO webhooks (url, secret, project, events, created_at)
+		VALUES (?, ?, ?, ?, ?)`,
+		hook.URL, hook.Secret, hook.Project, joinEventTypes(hook.Events), hook.CreatedAt)
+	if err != nil {
+		return err
+	}
+	hook.ID, _ = res.LastInsertId()
+	return nil
+}
+
+// ListWebhooks returns all registered webhooks
+func (s *Store) ListWebhooks() ([]Webhook, error) {
+	rows, err := s.db.Query(`SELECT id, url, secret, project, events, created_at FROM webhooks ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hooks []Webhook
+	for rows.Next() {
+		var hook Webhook
+		var events string
+		if err := rows.Scan(&hook.ID, &hook.URL, &hook.Secret, &hook.Project, &events, &hook.CreatedAt); err != nil {
+			return nil, err
+		}
+		hook.Events = splitEventTypes(events)
+		hooks = append(hooks, hook)
+	}
+	return hooks, rows.Err()
+}
+
+// RemoveWebhook deletes a webhook and drops its undelivered events. Its
+// delivery log is kept.
+func (s *Store) RemoveWebhook(id int64) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`DELETE FROM webhooks WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("webhook not found: %d", id)
+	}
+	_, err = tx.Exec(`DELETE FROM webhook_deliveries WHERE webhook_id = ? AND status = ?`, id, string(DeliveryPending))
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+const deliveryColumns = `
+	e.id, e.type, e.project, e.workstream, e.milestone, e.data, e.created_at,
+	d.id, d.webhook_id, COALESCE(h.url, ''), COALESCE(h.secret, ''), d.status, d.attempts,
+	d.next_attempt, d.response_code, d.last_error, d.updated_at`
+
+func scanDelivery(row interface{ Scan(...any) error }) (*Delivery, error) {
+	d := &Delivery{}
+	var status string
+	ev, err := scanEvent(row, &d.ID, &d.WebhookID, &d.URL, &d.Secret, &status, &d.Attempts,
+		&d.NextAttempt, &d.ResponseCode, &d.LastError, &d.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	d.Event = *ev
+	d.Status = DeliveryStatus(status)
+	return d, nil
+}
+
+// ClaimDeliveries returns up to limit pending deliveries that are due, and
+// counts an attempt against each. Claimed deliveries are not handed out
+// again until lease has passed, so several processes can deliver from the
+// same database without sending an event twice.
+func (s *Store) ClaimDeliveries(lease time.Duration, limit int) ([]Delivery, error) {
+	now := time.Now().UTC()
+	rows, err := s.db.Query(`
+		SELECT `+deliveryColumns+`
+		FROM webhook_deliveries d
+		JOIN events e ON e.id = d.event_id
+		JOIN webhooks h ON h.id = d.webhook_id
+		WHERE d.status = ? AND d.next_attempt <= ?
+		ORDER BY d.next_attempt, d.id LIMIT ?`,
+		string(DeliveryPending), now, limit)
+	if err != nil {
+		return nil, err
+	}
+	var due []Delivery
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		due = append(due, *d)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var claimed []Delivery
+	for _, d := range due {
+		// The attempts check makes the claim fail if another process got there first
+		res, err := s.db.Exec(`
+			UPDATE webhook_deliveries SET attempts = attempts + 1, next_attempt = ?, updated_at = ?
+			WHERE id = ? AND status = ? AND attempts = ?`,
+			now.Add(lease), now, d.ID, string(DeliveryPending), d.Attempts)
+		if err != nil {
+			return nil, err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			continue
+		}
+		d.Attempts++
+		claimed = append(claimed, d)
+	}
+	return claimed, nil
+}
+
+// FinishDelivery records the outcome of a delivery attempt. An empty errMsg
+// marks it delivered; otherwise it is retried at retryAt, or marked failed
+// if retryAt is zero.
+func (s *Store) FinishDelivery(id int64, responseCode int, errMsg string, retryAt time.Time) error {
+	now := time.Now().UTC()
+	status := DeliveryDelivered
+	next := now
+	switch {
+	case errMsg != "" && retryAt.IsZero():
+		status = DeliveryFailed
+	case errMsg != "":
+		status = DeliveryPending
+		next = retryAt.UTC()
+	}
+	_, err := s.db.Exec(`
+		UPDATE webhook_deliveries
+		SET status = ?, response_code = ?, last_error = ?, next_attempt = ?, updated_at = ?
+		WHERE id = ?`,
+		string(status), responseCode, errMsg, next, now, id)
+	return err
+}
+
+// Deliveries returns the webhook delivery log, most recently updated first
+func (s *Store) Deliveries(limit int) ([]Delivery, error) {
+	rows, err := s.db.Query(`
+		SELECT `+deliveryColumns+`
+		FROM webhook_deliveries d
+		JOIN events e ON e.id = d.event_id
+		LEFT JOIN webhooks h ON h.id = d.webhook_id
+		ORDER BY d.updated_at DESC, d.id DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []Delivery
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, *d)
+	}
+	return deliveries, rows.Err()
+}
+
+// ExpireClaims releases the claim on every unfinished workstream whose owner
+// has not updated it for ttl, logging and recording a claim_expired event
+// for each. Returns the expired events.
+func (s *Store) ExpireClaims(ttl time.Duration) ([]workstream.Event, error) {
+	now := time.Now().UTC()
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+		SELECT id, project, name, owner, last_update FROM workstreams
+		WHERE owner != '' AND state != ? AND last_update < ?`,
+		string(workstream.StateDone), now.Add(-ttl))
+	if err != nil {
+		return nil, err
+	}
+	type claim struct {
+		id                   int64
+		project, name, owner string
+		lastUpdate           time.Time
+	}
+	var stale []claim
+	for rows.Next() {
+		var c claim
+		if err := rows.Scan(&c.id, &c.project, &c.name, &c.owner, &c.lastUpdate); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		stale = append(stale, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var events []workstream.Event
+	for _, c := range stale {
+		if _, err := tx.Exec(`UPDATE workstreams SET owner = '', last_update = ? WHERE id = ?`, now, c.id); err != nil {
+			return nil, err
+		}
+		idle := workstream.FormatDuration(now.Sub(c.lastUpdate))
+		_, err := tx.Exec(`INSERT INTO log_entries (workstream_id, timestamp, content) VALUES (?, ?, ?)`,
+			c.id, now, fmt.Sprintf("Claim by %s expired after %s without updates", c.owner, idle))
+		if err != nil {
+			return nil, err
+		}
+		ev := workstream.Event{
+			Type:       workstream.EventClaimExpired,
+			Project:    c.project,
+			Workstream: c.name,
+			Data:       map[string]string{"owner": c.owner, "idle": idle},
+			CreatedAt:  now,
+		}
+		if err := recordEvent(tx, ev); err != nil {
+			return nil, err
+		}
+		events = append(events, ev)
+	}
+
+	return events, tx.Commit()
+}
+
+func joinEventTypes(types []workstream.EventType) string {
+	parts := make([]string, len(types))
+	for i, t := range types {
+		parts[i] = string(t)
+	}
+	return strings.Join(parts, ",")
+}
+
+func splitEventTypes(s string) []workstream.EventType {
+	if s == "" {
+		return nil
+	}
+	var types []workstream.EventType
+	for _, part := range strings.Split(s, ",") {
+		types = append(types, workstream.EventType(part))
+	}
+	return types
+}
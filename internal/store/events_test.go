@@ -0,0 +1,263 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/faraz/streamctl/pkg/workstream"
+)
+
+func eventTypes(events []workstream.Event) []workstream.EventType {
+	var types []workstream.EventType
+	for _, ev := range events {
+		types = append(types, ev.Type)
+	}
+	return types
+}
+
+func TestEventsRecordedOnChanges(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	s, _ := New(dbPath)
+	defer s.Close()
+
+	s.Create(&workstream.Workstream{Name: "ws1", Project: "proj", State: workstream.StatePending})
+	s.Create(&workstream.Workstream{Name: "ws2", Project: "proj", State: workstream.StateDone})
+	s.CreateMilestone(&workstream.Milestone{Project: "proj", Name: "v1"})
+	s.AddMilestoneRequirement("proj", "v1", "proj", "ws1")
+	s.AddMilestoneRequirement("proj", "v1", "proj", "ws2")
+	taskID, _ := s.AddTask("proj", "ws1", "Write code")
+
+	inProgress := workstream.StateInProgress
+	s.Update("proj", "ws1", WorkstreamUpdate{State: &inProgress})
+	s.Update("proj", "ws1", WorkstreamUpdate{State: &inProgress}) // unchanged: no event
+	s.RequestHelp("proj", "ws1", "Which DB?", "agent-1")
+	s.AnswerHelp("proj", "ws1", "SQLite", "faraz")
+	s.SetTaskStatusByID("proj", "ws1", taskID, workstream.TaskDone)
+	s.SetTaskStatusByID("proj", "ws1", taskID, workstream.TaskDone) // already done: no event
+	done := workstream.StateDone
+	s.Update("proj", "ws1", WorkstreamUpdate{State: &done})
+
+	events, err := s.Events("proj", 0, 0)
+	if err != nil {
+		t.Fatalf("Events() error = %v", err)
+	}
+	want := []workstream.EventType{
+		workstream.EventStateChanged,
+		workstream.EventNeedsHelpRaised,
+		workstream.EventNeedsHelpCleared,
+		workstream.EventTaskDone,
+		workstream.EventStateChanged,
+		workstream.EventMilestoneCompleted,
+	}
+	got := eventTypes(events)
+	if len(got) != len(want) {
+		t.Fatalf("Events() types = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("event %d = %s, want %s", i, got[i], want[i])
+		}
+	}
+
+	if events[0].Data["from"] != "pending" || events[0].Data["to"] != "in_progress" {
+		t.Errorf("state_changed data = %v", events[0].Data)
+	}
+	if events[1].Data["question"] != "Which DB?" || events[2].Data["answer"] != "SQLite" {
+		t.Errorf("needs_help data = %v, %v", events[1].Data, events[2].Data)
+	}
+	if events[3].Data["task_id"] != taskID || events[3].Data["task"] != "Write code" {
+		t.Errorf("task_done data = %v", events[3].Data)
+	}
+	if events[5].Milestone != "v1" || events[5].Workstream != "" {
+		t.Errorf("milestone_completed = %+v", events[5])
+	}
+
+	// Paging by ID
+	later, _ := s.Events("proj", events[3].ID, 0)
+	if len(later) != 2 {
+		t.Errorf("Events(after %d) = %d events, want 2", events[3].ID, len(later))
+	}
+	if other, _ := s.Events("other", 0, 0); len(other) != 0 {
+		t.Errorf("Events(other) = %d events, want 0", len(other))
+	}
+}
+
+func TestNeedsHelpFlagEvents(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	s, _ := New(dbPath)
+	defer s.Close()
+
+	s.Create(&workstream.Workstream{Name: "ws1", Project: "proj"})
+	on, off := true, false
+	s.Update("proj", "ws1", WorkstreamUpdate{NeedsHelp: &on})
+	s.Update("proj", "ws1", WorkstreamUpdate{NeedsHelp: &on})
+	s.Update("proj", "ws1", WorkstreamUpdate{NeedsHelp: &off})
+
+	events, _ := s.Events("", 0, 0)
+	got := eventTypes(events)
+	if len(got) != 2 || got[0] != workstream.EventNeedsHelpRaised || got[1] != workstream.EventNeedsHelpCleared {
+		t.Errorf("Events() types = %v", got)
+	}
+}
+
+func TestWebhookSubscriptions(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	s, _ := New(dbPath)
+	defer s.Close()
+
+	all := &Webhook{URL: "http://localhost/all"}
+	helpOnly := &Webhook{URL: "http://localhost/help", Events: []workstream.EventType{workstream.EventNeedsHelpRaised}}
+	otherProject := &Webhook{URL: "http://localhost/other", Project: "other"}
+	for _, hook := range []*Webhook{all, helpOnly, otherProject} {
+		if err := s.AddWebhook(hook); err != nil {
+			t.Fatalf("AddWebhook() error = %v", err)
+		}
+	}
+	if err := s.AddWebhook(&Webhook{URL: "ftp://example.com"}); err == nil {
+		t.Errorf("AddWebhook() should reject non-HTTP URLs")
+	}
+
+	hooks, _ := s.ListWebhooks()
+	if len(hooks) != 3 || len(hooks[1].Events) != 1 || hooks[2].Project != "other" {
+		t.Errorf("ListWebhooks() = %+v", hooks)
+	}
+
+	s.Create(&workstream.Workstream{Name: "ws1", Project: "proj"})
+	s.RequestHelp("proj", "ws1", "Help?", "agent-1")
+	blocked := workstream.StateBlocked
+	s.Update("proj", "ws1", WorkstreamUpdate{State: &blocked})
+
+	claimed, err := s.ClaimDeliveries(time.Minute, 10)
+	if err != nil {
+		t.Fatalf("ClaimDeliveries() error = %v", err)
+	}
+	perURL := map[string]int{}
+	for _, d := range claimed {
+		perURL[d.URL]++
+		if d.Attempts != 1 {
+			t.Errorf("claimed delivery attempts = %d, want 1", d.Attempts)
+		}
+	}
+	if perURL[all.URL] != 2 || perURL[helpOnly.URL] != 1 || perURL[otherProject.URL] != 0 {
+		t.Errorf("deliveries per webhook = %v", perURL)
+	}
+
+	// Claimed deliveries are leased, not handed out twice
+	if again, _ := s.ClaimDeliveries(time.Minute, 10); len(again) != 0 {
+		t.Errorf("second ClaimDeliveries() = %d, want 0", len(again))
+	}
+
+	if err := s.RemoveWebhook(helpOnly.ID); err != nil {
+		t.Fatalf("RemoveWebhook() error = %v", err)
+	}
+	if err := s.RemoveWebhook(helpOnly.ID); err == nil {
+		t.Errorf("RemoveWebhook() of a missing webhook should fail")
+	}
+}
+
+func TestExpireClaims(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	s, _ := New(dbPath)
+	defer s.Close()
+
+	owner := "agent-1"
+	s.Create(&workstream.Workstream{Name: "idle", Project: "proj", State: workstream.StateInProgress})
+	s.Update("proj", "idle", WorkstreamUpdate{Owner: &owner})
+	s.Create(&workstream.Workstream{Name: "finished", Project: "proj", State: workstream.StateDone})
+	s.Update("proj", "finished", WorkstreamUpdate{Owner: &owner})
+
+	if expired, _ := s.ExpireClaims(time.Hour); len(expired) != 0 {
+		t.Errorf("ExpireClaims(1h) = %d, want 0 for fresh claims", len(expired))
+	}
+
+	time.Sleep(time.Millisecond)
+	expired, err := s.ExpireClaims(time.Nanosecond)
+	if err != nil {
+		t.Fatalf("ExpireClaims() error = %v", err)
+	}
+	if len(expired) != 1 || expired[0].Workstream != "idle" || expired[0].Data["owner"] != "agent-1" {
+		t.Fatalf("ExpireClaims() = %+v", expired)
+	}
+
+	ws, _ := s.Get("proj", "idle")
+	if ws.Owner != "" {
+		t.Errorf("owner = %q, want released", ws.Owner)
+	}
+	if ws.Log[0].Content == "" {
+		t.Errorf("expiry should be logged")
+	}
+	if ws, _ := s.Get("proj", "finished"); ws.Owner != owner {
+		t.Errorf("done workstream claims should not expire")
+	}
+}
+
+func TestStateChanges(t *testing.T) {
+	s, _ := New(filepath.Join(t.TempDir(), "test.db"))
+	defer s.Close()
+
+	s.Create(&workstream.Workstream{Name: "ws1", Project: "proj", State: workstream.StatePending})
+	s.Create(&workstream.Workstream{Name: "other", Project: "elsewhere", State: workstream.StatePending})
+	s.RequestHelp("proj", "ws1", "Which DB?", "agent-1")
+	for _, state := range []workstream.State{workstream.StateInProgress, workstream.StateDone} {
+		s.Update("proj", "ws1", WorkstreamUpdate{State: &state})
+	}
+	inProgress := workstream.StateInProgress
+	s.Update("elsewhere", "other", WorkstreamUpdate{State: &inProgress})
+
+	changes, err := s.StateChanges("proj")
+	if err != nil {
+		t.Fatalf("StateChanges() error = %v", err)
+	}
+	if len(changes) != 2 || changes[0].Data["from"] != "pending" || changes[1].Data["to"] != "done" {
+		t.Errorf("changes = %+v, want pending → in_progress → done", changes)
+	}
+
+	events, err := s.EventsOfType("proj", workstream.EventNeedsHelpRaised, workstream.EventStateChanged)
+	if err != nil {
+		t.Fatalf("EventsOfType() error = %v", err)
+	}
+	if len(events) != 3 || events[0].Type != workstream.EventNeedsHelpRaised {
+		t.Errorf("events = %+v, want the help request then both state changes", events)
+	}
+
+	ws, _ := s.Get("proj", "ws1")
+	if ws.CreatedAt.IsZero() || time.Since(ws.CreatedAt) > time.Minute {
+		t.Errorf("CreatedAt = %v, want about now", ws.CreatedAt)
+	}
+}
+
+func TestRecentEvents(t *testing.T) {
+	s, _ := New(filepath.Join(t.TempDir(), "test.db"))
+	defer s.Close()
+
+	for _, name := range []string{"auth", "api", "docs"} {
+		s.Create(&workstream.Workstream{Name: name, Project: "proj", State: workstream.StatePending})
+		inProgress := workstream.StateInProgress
+		s.Update("proj", name, WorkstreamUpdate{State: &inProgress})
+	}
+	s.RequestHelp("proj", "auth", "Which DB?", "agent-1")
+	s.CreateMilestone(&workstream.Milestone{Name: "beta", Project: "proj"})
+	s.AddMilestoneRequirement("proj", "beta", "proj", "auth")
+	s.AddMilestoneRequirement("proj", "beta", "proj", "api")
+
+	stateAndHelp := []workstream.EventType{workstream.EventStateChanged, workstream.EventNeedsHelpRaised}
+	all, err := s.RecentEvents(EventFilter{Project: "proj", Types: stateAndHelp}, 10)
+	if err != nil {
+		t.Fatalf("RecentEvents() error = %v", err)
+	}
+	if len(all) != 4 || all[0].Type != workstream.EventNeedsHelpRaised || all[3].Workstream != "auth" {
+		t.Errorf("events = %+v, want 4 newest first", all)
+	}
+
+	if got, _ := s.RecentEvents(EventFilter{Project: "proj", Types: stateAndHelp}, 2); len(got) != 2 || got[1].Workstream != "docs" {
+		t.Errorf("limited events = %+v", got)
+	}
+	if got, _ := s.RecentEvents(EventFilter{Project: "proj", Workstream: "auth", Types: stateAndHelp}, 10); len(got) != 2 {
+		t.Errorf("auth events = %+v, want its state change and help request", got)
+	}
+	got, _ := s.RecentEvents(EventFilter{Project: "proj", Milestone: "beta", Types: []workstream.EventType{workstream.EventStateChanged}}, 10)
+	if len(got) != 2 || got[0].Workstream != "api" || got[1].Workstream != "auth" {
+		t.Errorf("beta events = %+v, want api and auth only", got)
+	}
+}
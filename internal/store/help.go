@@ -0,0 +1,178 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/faraz/streamctl/pkg/workstream"
+)
+
+// openHelpQuestion selects the question of a workstream's latest unanswered
+// help request, for use in a query over workstreams
+const openHelpQuestion = `(SELECT h.question FROM help_requests h
+	WHERE h.workstream_id = workstreams.id AND h.answered_at IS NULL
+	ORDER BY h.id DESC LIMIT 1)`
+
+// RequestHelp flags a workstream as needing help with a question for a human.
+// The question is also logged as a question entry.
+func (s *Store) RequestHelp(project, name, question, askedBy string) (*workstream.HelpRequest, error) {
+	question = strings.TrimSpace(question)
+	if question == "" {
+		return nil, fmt.Errorf("question is required")
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	wsID, err := lookupWorkstream(tx, project, name)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	req := &workstream.HelpRequest{Question: question, AskedBy: askedBy, AskedAt: now}
+	res, err := tx.Exec(`INSERT INTO help_requests (workstream_id, question, asked_by, asked_at) VALUES (?, ?, ?, ?)`,
+		wsID, question, askedBy, now)
+	if err != nil {
+		return nil, err
+	}
+	req.ID, _ = res.LastInsertId()
+
+	if _, err := tx.Exec(`UPDATE workstreams SET needs_help = TRUE, last_update = ? WHERE id = ?`, now, wsID); err != nil {
+		return nil, err
+	}
+	_, err = tx.Exec(`INSERT INTO log_entries (workstream_id, timestamp, content, type, author) VALUES (?, ?, ?, ?, ?)`,
+		wsID, now, "Needs help: "+question, string(workstream.EntryQuestion), askedBy)
+	if err != nil {
+		return nil, err
+	}
+	err = recordEvent(tx, workstream.Event{
+		Type:       workstream.EventNeedsHelpRaised,
+		Project:    project,
+		Workstream: name,
+		Data:       map[string]string{"question": question, "asked_by": askedBy},
+		CreatedAt:  now,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return req, tx.Commit()
+}
+
+// AnswerHelp records a human's answer to a workstream's open help request,
+// logs it, and clears the needs_help flag. A bare needs_help flag with no
+// question can be answered too.
+func (s *Store) AnswerHelp(project, name, answer, answeredBy string) (*workstream.HelpRequest, error) {
+	answer = strings.TrimSpace(answer)
+	if answer == "" {
+		return nil, fmt.Errorf("answer is required")
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	wsID, err := lookupWorkstream(tx, project, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var needsHelp bool
+	if err := tx.QueryRow(`SELECT needs_help FROM workstreams WHERE id = ?`, wsID).Scan(&needsHelp); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	req := &workstream.HelpRequest{}
+	err = tx.QueryRow(`
+		SELECT id, question, asked_by, asked_at FROM help_requests
+		WHERE workstream_id = ? AND answered_at IS NULL ORDER BY id DESC LIMIT 1`, wsID,
+	).Scan(&req.ID, &req.Question, &req.AskedBy, &req.AskedAt)
+	switch {
+	case err == sql.ErrNoRows && !needsHelp:
+		return nil, fmt.Errorf("workstream does not need help: %s/%s", project, name)
+	case err == sql.ErrNoRows:
+		// Bare flag: record an unprompted request so help status reports the answer
+		req.AskedAt = now
+		res, err := tx.Exec(`INSERT INTO help_requests (workstream_id, asked_at) VALUES (?, ?)`, wsID, now)
+		if err != nil {
+			return nil, err
+		}
+		req.ID, _ = res.LastInsertId()
+	case err != nil:
+		return nil, err
+	}
+
+	req.Answer, req.AnsweredBy, req.AnsweredAt = answer, answeredBy, now
+	// Answering resolves every open request on the workstream
+	if _, err := tx.Exec(`UPDATE help_requests SET answer = ?, answered_by = ?, answered_at = ? WHERE workstream_id = ? AND answered_at IS NULL`,
+		answer, answeredBy, now, wsID); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(`UPDATE workstreams SET needs_help = FALSE, last_update = ? WHERE id = ?`, now, wsID); err != nil {
+		return nil, err
+	}
+	_, err = tx.Exec(`INSERT INTO log_entries (workstream_id, timestamp, content, author) VALUES (?, ?, ?, ?)`,
+		wsID, now, "Answer: "+answer, answeredBy)
+	if err != nil {
+		return nil, err
+	}
+	err = recordEvent(tx, workstream.Event{
+		Type:       workstream.EventNeedsHelpCleared,
+		Project:    project,
+		Workstream: name,
+		Data:       map[string]string{"question": req.Question, "answer": answer, "answered_by": answeredBy},
+		CreatedAt:  now,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return req, tx.Commit()
+}
+
+// HelpStatus returns a workstream's latest help request, or nil if it has
+// never asked for help
+func (s *Store) HelpStatus(project, name string) (*workstream.HelpRequest, error) {
+	var wsID int64
+	err := s.db.QueryRow(`SELECT id FROM workstreams WHERE project = ? AND name = ?`, project, name).Scan(&wsID)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("workstream not found: %s/%s", project, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	req := &workstream.HelpRequest{}
+	var answeredAt sql.NullTime
+	err = s.db.QueryRow(`
+		SELECT id, question, asked_by, asked_at, answer, answered_by, answered_at
+		FROM help_requests WHERE workstream_id = ? ORDER BY id DESC LIMIT 1`, wsID,
+	).Scan(&req.ID, &req.Question, &req.AskedBy, &req.AskedAt, &req.Answer, &req.AnsweredBy, &answeredAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	req.AnsweredAt = answeredAt.Time
+	return req, nil
+}
+
+// lookupWorkstream returns the row ID of a workstream within a transaction
+func lookupWorkstream(tx *sql.Tx, project, name string) (int64, error) {
+	var wsID int64
+	err := tx.QueryRow(`SELECT id FROM workstreams WHERE project = ? AND name = ?`, project, name).Scan(&wsID)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("workstream not found: %s/%s", project, name)
+	}
+	return wsID, err
+}
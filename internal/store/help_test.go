@@ -0,0 +1,100 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/faraz/streamctl/pkg/workstream"
+)
+
+func TestHelpRequestAndAnswer(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	s, _ := New(dbPath)
+	defer s.Close()
+
+	s.Create(&workstream.Workstream{Name: "ws1", Project: "proj", State: workstream.StateInProgress})
+
+	if help, _ := s.HelpStatus("proj", "ws1"); help != nil {
+		t.Fatalf("HelpStatus() before asking = %+v, want nil", help)
+	}
+
+	if _, err := s.RequestHelp("proj", "ws1", "Which database?", "agent-1"); err != nil {
+		t.Fatalf("RequestHelp() error = %v", err)
+	}
+
+	ws, _ := s.Get("proj", "ws1")
+	if !ws.NeedsHelp || ws.HelpQuestion != "Which database?" {
+		t.Errorf("after RequestHelp: NeedsHelp=%v HelpQuestion=%q", ws.NeedsHelp, ws.HelpQuestion)
+	}
+	if ws.Log[0].Type != workstream.EntryQuestion || ws.Log[0].Author != "agent-1" {
+		t.Errorf("question should be logged, got %+v", ws.Log[0])
+	}
+
+	help, _ := s.HelpStatus("proj", "ws1")
+	if help == nil || help.Answered() {
+		t.Fatalf("HelpStatus() = %+v, want open request", help)
+	}
+
+	if _, err := s.AnswerHelp("proj", "ws1", "Postgres", "faraz"); err != nil {
+		t.Fatalf("AnswerHelp() error = %v", err)
+	}
+
+	ws, _ = s.Get("proj", "ws1")
+	if ws.NeedsHelp || ws.HelpQuestion != "" {
+		t.Errorf("after AnswerHelp: NeedsHelp=%v HelpQuestion=%q", ws.NeedsHelp, ws.HelpQuestion)
+	}
+	if ws.Log[0].Content != "Answer: Postgres" || ws.Log[0].Author != "faraz" {
+		t.Errorf("answer should be logged, got %+v", ws.Log[0])
+	}
+
+	help, _ = s.HelpStatus("proj", "ws1")
+	if !help.Answered() || help.Answer != "Postgres" || help.AnsweredBy != "faraz" || help.Question != "Which database?" {
+		t.Errorf("HelpStatus() = %+v", help)
+	}
+
+	if _, err := s.AnswerHelp("proj", "ws1", "again", "faraz"); err == nil {
+		t.Errorf("answering without needs_help should fail")
+	}
+	if _, err := s.RequestHelp("proj", "ws1", " ", "agent-1"); err == nil {
+		t.Errorf("empty question should fail")
+	}
+}
+
+func TestAnswerBareNeedsHelp(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	s, _ := New(dbPath)
+	defer s.Close()
+
+	help := true
+	s.Create(&workstream.Workstream{Name: "ws1", Project: "proj", State: workstream.StateInProgress})
+	s.Update("proj", "ws1", WorkstreamUpdate{NeedsHelp: &help})
+
+	if _, err := s.AnswerHelp("proj", "ws1", "Try restarting", "faraz"); err != nil {
+		t.Fatalf("AnswerHelp() error = %v", err)
+	}
+	status, _ := s.HelpStatus("proj", "ws1")
+	if status == nil || status.Answer != "Try restarting" {
+		t.Errorf("HelpStatus() = %+v", status)
+	}
+}
+
+func TestClearingNeedsHelpWithdrawsRequest(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	s, _ := New(dbPath)
+	defer s.Close()
+
+	s.Create(&workstream.Workstream{Name: "ws1", Project: "proj", State: workstream.StateInProgress})
+	s.RequestHelp("proj", "ws1", "Stuck?", "agent-1")
+
+	clear := false
+	s.Update("proj", "ws1", WorkstreamUpdate{NeedsHelp: &clear})
+
+	ws, _ := s.Get("proj", "ws1")
+	if ws.HelpQuestion != "" {
+		t.Errorf("HelpQuestion = %q, want empty after withdrawal", ws.HelpQuestion)
+	}
+	status, _ := s.HelpStatus("proj", "ws1")
+	if !status.Answered() || status.Answer != "" {
+		t.Errorf("HelpStatus() = %+v, want withdrawn", status)
+	}
+}
@@ -0,0 +1,187 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/faraz/streamctl/pkg/workstream"
+)
+
+// briefingActivityLimit caps the activity included in a session briefing
+const briefingActivityLimit = 20
+
+// StartSession records the start of an agent's session on a project
+func (s *Store) StartSession(id, project, agent, client string) (*workstream.Session, error) {
+	sess := &workstream.Session{
+		ID:        id,
+		Project:   project,
+		Agent:     agent,
+		Client:    client,
+		StartedAt: time.Now().UTC(),
+	}
+	_, err := s.db.Exec(`
+		INSERT INTO sessions (id, project, agent, client, started_at)
+		VALUES (?, ?, ?, ?, ?)`,
+		sess.ID, sess.Project, sess.Agent, sess.Client, sess.StartedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return sess, nil
+}
+
+// EndSession ends a session, recording the handoff note
+func (s *Store) EndSession(id, nextSteps string) (*workstream.Session, error) {
+	res, err := s.db.Exec(`UPDATE sessions SET ended_at = ?, next_steps = ? WHERE id = ? AND ended_at IS NULL`,
+		time.Now().UTC(), nextSteps, id)
+	if err != nil {
+		return nil, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, fmt.Errorf("active session not found: %s", id)
+	}
+	return s.GetSession(id)
+}
+
+// GetSession retrieves a session by ID
+func (s *Store) GetSession(id string) (*workstream.Session, error) {
+	row := s.db.QueryRow(`
+		SELECT id, project, agent, client, started_at, ended_at, next_steps
+		FROM sessions WHERE id = ?`, id)
+	sess, err := scanSession(row)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("session not found: %s", id)
+	}
+	return sess, err
+}
+
+// ActiveSession returns the agent's most recent unended session on a
+// project, or nil if there is none
+func (s *Store) ActiveSession(project, agent string) (*workstream.Session, error) {
+	row := s.db.QueryRow(`
+		SELECT id, project, agent, client, started_at, ended_at, next_steps
+		FROM sessions WHERE project = ? AND agent = ? AND ended_at IS NULL
+		ORDER BY started_at DESC LIMIT 1`, project, agent)
+	sess, err := scanSession(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return sess, err
+}
+
+// LastSession returns the agent's most recently ended session on a project,
+// or nil if there is none
+func (s *Store) LastSession(project, agent string) (*workstream.Session, error) {
+	row := s.db.QueryRow(`
+		SELECT id, project, agent, client, started_at, ended_at, next_steps
+		FROM sessions WHERE project = ? AND agent = ? AND ended_at IS NOT NULL
+		ORDER BY ended_at DESC LIMIT 1`, project, agent)
+	sess, err := scanSession(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return sess, err
+}
+
+// ActiveSessions returns the sessions on a project that have not ended,
+// oldest first
+func (s *Store) ActiveSessions(project string) ([]workstream.Session, error) {
+	rows, err := s.db.Query(`
+		SELECT id, project, agent, client, started_at, ended_at, next_steps
+		FROM sessions WHERE project = ? AND ended_at IS NULL
+		ORDER BY started_at`, project)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []workstream.Session
+	for rows.Next() {
+		sess, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, *sess)
+	}
+	return sessions, rows.Err()
+}
+
+// scanSession scans a sessions row selected in column order
+func scanSession(row interface{ Scan(...any) error }) (*workstream.Session, error) {
+	var sess workstream.Session
+	var endedAt sql.NullTime
+	if err := row.Scan(&sess.ID, &sess.Project, &sess.Agent, &sess.Client, &sess.StartedAt, &endedAt, &sess.NextSteps); err != nil {
+		return nil, err
+	}
+	sess.EndedAt = endedAt.Time
+	return &sess, nil
+}
+
+// Briefing gathers what an agent needs at the start of a session: its
+// claimed work, in-progress tasks, unresolved needs_help flags, and activity
+// by others since its previous session
+func (s *Store) Briefing(project, agent string) (*workstream.Briefing, error) {
+	br := &workstream.Briefing{Project: project, Agent: agent}
+
+	prev, err := s.LastSession(project, agent)
+	if err != nil {
+		return nil, err
+	}
+	br.Previous = prev
+
+	workstreams, err := s.List(Filter{Project: project})
+	if err != nil {
+		return nil, err
+	}
+	for _, ws := range workstreams {
+		if ws.Owner == agent {
+			br.Claimed = append(br.Claimed, ws)
+			br.InProgressTasks = appendInProgress(br.InProgressTasks, ws.Name, ws.Plan)
+		}
+		if ws.NeedsHelp {
+			br.NeedsHelp = append(br.NeedsHelp, ws)
+		}
+	}
+
+	filter := ActivityFilter{Project: project, ExcludeAuthor: agent}
+	if prev != nil {
+		filter.Since = prev.EndedAt
+	}
+	br.RecentActivity, err = s.Activity(filter, briefingActivityLimit, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return br, nil
+}
+
+// appendInProgress appends the in-progress tasks of a plan, depth first
+func appendInProgress(tasks []workstream.BriefingTask, wsName string, items []workstream.PlanItem) []workstream.BriefingTask {
+	for _, item := range items {
+		if item.Status == workstream.TaskInProgress && len(item.Children) == 0 {
+			tasks = append(tasks, workstream.BriefingTask{Workstream: wsName, Task: item})
+		}
+		tasks = appendInProgress(tasks, wsName, item.Children)
+	}
+	return tasks
+}
+
+// ReleaseClaims clears the owner of every workstream the agent owns in a
+// project, returning the names of the released workstreams
+func (s *Store) ReleaseClaims(project, agent string) ([]string, error) {
+	owned, err := s.List(Filter{Project: project, Owner: agent})
+	if err != nil {
+		return nil, err
+	}
+
+	var released []string
+	empty := ""
+	for _, ws := range owned {
+		if err := s.Update(project, ws.Name, WorkstreamUpdate{Owner: &empty}); err != nil {
+			return released, err
+		}
+		released = append(released, ws.Name)
+	}
+	return released, nil
+}
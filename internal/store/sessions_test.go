@@ -0,0 +1,124 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/faraz/streamctl/pkg/workstream"
+)
+
+func TestSessionLifecycle(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	s, _ := New(dbPath)
+	defer s.Close()
+
+	sess, err := s.StartSession("abc123", "proj", "agent-1", "claude-code/2.0")
+	if err != nil {
+		t.Fatalf("StartSession() error = %v", err)
+	}
+	if !sess.Active() {
+		t.Errorf("new session should be active")
+	}
+
+	active, _ := s.ActiveSession("proj", "agent-1")
+	if active == nil || active.ID != "abc123" {
+		t.Fatalf("ActiveSession() = %+v, want abc123", active)
+	}
+	sessions, _ := s.ActiveSessions("proj")
+	if len(sessions) != 1 || sessions[0].Client != "claude-code/2.0" {
+		t.Errorf("ActiveSessions() = %+v", sessions)
+	}
+
+	ended, err := s.EndSession("abc123", "Finish the parser")
+	if err != nil {
+		t.Fatalf("EndSession() error = %v", err)
+	}
+	if ended.Active() || ended.NextSteps != "Finish the parser" {
+		t.Errorf("ended session = %+v", ended)
+	}
+	if ended.Duration() < 0 {
+		t.Errorf("Duration() = %v", ended.Duration())
+	}
+
+	if _, err := s.EndSession("abc123", "again"); err == nil {
+		t.Errorf("ending an ended session should fail")
+	}
+	if active, _ := s.ActiveSession("proj", "agent-1"); active != nil {
+		t.Errorf("ActiveSession() after end = %+v, want nil", active)
+	}
+	last, _ := s.LastSession("proj", "agent-1")
+	if last == nil || last.ID != "abc123" {
+		t.Errorf("LastSession() = %+v, want abc123", last)
+	}
+}
+
+func TestBriefing(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	s, _ := New(dbPath)
+	defer s.Close()
+
+	s.Create(&workstream.Workstream{
+		Name: "mine", Project: "proj", State: workstream.StateInProgress, Owner: "agent-1",
+		Plan: []workstream.PlanItem{
+			{Text: "Parent", Status: workstream.TaskInProgress, Children: []workstream.PlanItem{
+				{Text: "Child working", Status: workstream.TaskInProgress},
+				{Text: "Child pending", Status: workstream.TaskPending},
+			}},
+		},
+	})
+	s.Create(&workstream.Workstream{Name: "theirs", Project: "proj", State: workstream.StateInProgress, Owner: "agent-2"})
+	help := true
+	s.Update("proj", "theirs", WorkstreamUpdate{NeedsHelp: &help})
+
+	s.StartSession("s1", "proj", "agent-1", "")
+	before := "before my last session"
+	s.Update("proj", "theirs", WorkstreamUpdate{LogEntry: &before, LogAuthor: "agent-2"})
+	s.EndSession("s1", "Pick up the child task")
+	time.Sleep(10 * time.Millisecond)
+
+	mine := "my own entry"
+	s.Update("proj", "mine", WorkstreamUpdate{LogEntry: &mine, LogAuthor: "agent-1"})
+	theirs := "their new entry"
+	s.Update("proj", "theirs", WorkstreamUpdate{LogEntry: &theirs, LogAuthor: "agent-2"})
+
+	br, err := s.Briefing("proj", "agent-1")
+	if err != nil {
+		t.Fatalf("Briefing() error = %v", err)
+	}
+	if br.Previous == nil || br.Previous.NextSteps != "Pick up the child task" {
+		t.Errorf("Previous = %+v", br.Previous)
+	}
+	if len(br.Claimed) != 1 || br.Claimed[0].Name != "mine" {
+		t.Errorf("Claimed = %+v", br.Claimed)
+	}
+	if len(br.InProgressTasks) != 1 || br.InProgressTasks[0].Task.Text != "Child working" {
+		t.Errorf("InProgressTasks = %+v, want only the in-progress leaf", br.InProgressTasks)
+	}
+	if len(br.NeedsHelp) != 1 || br.NeedsHelp[0].Name != "theirs" {
+		t.Errorf("NeedsHelp = %+v", br.NeedsHelp)
+	}
+	if len(br.RecentActivity) != 1 || br.RecentActivity[0].Content != "their new entry" {
+		t.Errorf("RecentActivity = %+v, want only others' entries since last session", br.RecentActivity)
+	}
+}
+
+func TestReleaseClaims(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	s, _ := New(dbPath)
+	defer s.Close()
+
+	s.Create(&workstream.Workstream{Name: "a", Project: "proj", State: workstream.StatePending, Owner: "agent-1"})
+	s.Create(&workstream.Workstream{Name: "b", Project: "proj", State: workstream.StatePending, Owner: "agent-2"})
+
+	released, err := s.ReleaseClaims("proj", "agent-1")
+	if err != nil {
+		t.Fatalf("ReleaseClaims() error = %v", err)
+	}
+	if len(released) != 1 || released[0] != "a" {
+		t.Errorf("released = %v, want [a]", released)
+	}
+	if ws, _ := s.Get("proj", "b"); ws.Owner != "agent-2" {
+		t.Errorf("other agent's claim should be untouched")
+	}
+}
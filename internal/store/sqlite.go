@@ -2,7 +2,9 @@ package store
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -11,11 +13,25 @@ import (
 	"github.com/faraz/streamctl/pkg/workstream"
 )
 
+// ActivityFilter narrows the activity feed
+type ActivityFilter struct {
+	Project    string
+	Workstream string               // Only entries for this workstream
+	Type       workstream.EntryType // Only entries of this type
+	Author     string               // Only entries by this author (or client, if no author was given)
+	Session    string               // Only entries recorded by this serve session
+	Milestone  string               // Only entries for workstreams this milestone (in Project) requires
+
+	Since         time.Time // Only entries after this time
+	ExcludeAuthor string    // Leave out entries by this author (or client)
+}
+
 // Filter for listing workstreams
 type Filter struct {
 	Project string
 	State   workstream.State
 	Owner   string
+	Stale   workstream.StaleRules // If set, only workstreams stale under these rules
 }
 
 // WorkstreamUpdate for partial updates
@@ -24,7 +40,18 @@ type WorkstreamUpdate struct {
 	Owner     *string
 	LogEntry  *string // Append to log
 	PlanIndex *int    // Toggle plan item completion
+	PlanTask  *string // Toggle plan item completion by stable task ID
 	NeedsHelp *bool   // Flag for at-risk/stuck workstreams
+
+	// Classification of LogEntry (defaults to note) and, for decisions, its details
+	LogType         workstream.EntryType
+	LogAlternatives []string
+	LogRationale    string
+
+	// Attribution of LogEntry
+	LogAuthor  string
+	LogClient  string
+	LogSession string
 }
 
 // Store provides SQLite-backed CRUD operations for workstreams
@@ -69,6 +96,8 @@ func (s *Store) migrate() error {
 		decisions TEXT DEFAULT '',
 		last_update DATETIME,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		summary TEXT NOT NULL DEFAULT '',
+		summary_through DATETIME,
 		UNIQUE(project, name)
 	);
 
@@ -79,14 +108,22 @@ func (s *Store) migrate() error {
 		text TEXT NOT NULL,
 		complete BOOLEAN DEFAULT FALSE,
 		status TEXT NOT NULL DEFAULT 'pending',
-		notes TEXT NOT NULL DEFAULT ''
+		notes TEXT NOT NULL DEFAULT '',
+		parent_id INTEGER REFERENCES plan_items(id) ON DELETE CASCADE
 	);
 
 	CREATE TABLE IF NOT EXISTS log_entries (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		workstream_id INTEGER NOT NULL REFERENCES workstreams(id) ON DELETE CASCADE,
 		timestamp DATETIME NOT NULL,
-		content TEXT NOT NULL
+		content TEXT NOT NULL,
+		type TEXT NOT NULL DEFAULT 'note',
+		alternatives TEXT NOT NULL DEFAULT '',
+		rationale TEXT NOT NULL DEFAULT '',
+		author TEXT NOT NULL DEFAULT '',
+		client TEXT NOT NULL DEFAULT '',
+		session_id TEXT NOT NULL DEFAULT '',
+		superseded BOOLEAN NOT NULL DEFAULT FALSE
 	);
 
 	CREATE TABLE IF NOT EXISTS workstream_dependencies (
@@ -122,6 +159,78 @@ func (s *Store) migrate() error {
 
 	CREATE INDEX IF NOT EXISTS idx_milestones_project ON milestones(project);
 	CREATE INDEX IF NOT EXISTS idx_milestone_reqs_milestone ON milestone_requirements(milestone_id);
+
+	CREATE TABLE IF NOT EXISTS sessions (
+		id TEXT PRIMARY KEY,
+		project TEXT NOT NULL,
+		agent TEXT NOT NULL,
+		client TEXT NOT NULL DEFAULT '',
+		started_at DATETIME NOT NULL,
+		ended_at DATETIME,
+		next_steps TEXT NOT NULL DEFAULT ''
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_sessions_project_agent ON sessions(project, agent);
+
+	CREATE TABLE IF NOT EXISTS help_requests (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		workstream_id INTEGER NOT NULL REFERENCES workstreams(id) ON DELETE CASCADE,
+		question TEXT NOT NULL DEFAULT '',
+		asked_by TEXT NOT NULL DEFAULT '',
+		asked_at DATETIME NOT NULL,
+		answer TEXT NOT NULL DEFAULT '',
+		answered_by TEXT NOT NULL DEFAULT '',
+		answered_at DATETIME
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_help_requests_workstream ON help_requests(workstream_id);
+
+	CREATE TABLE IF NOT EXISTS events (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		type TEXT NOT NULL,
+		project TEXT NOT NULL,
+		workstream TEXT NOT NULL DEFAULT '',
+		milestone TEXT NOT NULL DEFAULT '',
+		data TEXT NOT NULL DEFAULT '{}',
+		created_at DATETIME NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_events_project ON events(project);
+
+	CREATE TABLE IF NOT EXISTS webhooks (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		url TEXT NOT NULL,
+		secret TEXT NOT NULL DEFAULT '',
+		project TEXT NOT NULL DEFAULT '',
+		events TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS webhook_deliveries (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		event_id INTEGER NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+		webhook_id INTEGER NOT NULL,
+		status TEXT NOT NULL DEFAULT 'pending',
+		attempts INTEGER NOT NULL DEFAULT 0,
+		next_attempt DATETIME NOT NULL,
+		response_code INTEGER NOT NULL DEFAULT 0,
+		last_error TEXT NOT NULL DEFAULT '',
+		updated_at DATETIME NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(status, next_attempt);
+
+	CREATE TABLE IF NOT EXISTS audit_log (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		principal TEXT NOT NULL,
+		project TEXT NOT NULL,
+		workstream TEXT NOT NULL DEFAULT '',
+		action TEXT NOT NULL,
+		detail TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_audit_log_project ON audit_log(project);
 	`
 	if _, err := s.db.Exec(schema); err != nil {
 		return err
@@ -145,6 +254,62 @@ func (s *Store) migrate() error {
 		}
 	}
 
+	// Migration: Add parent_id column to plan_items if missing (subtasks)
+	if !s.columnExists("plan_items", "parent_id") {
+		if _, err := s.db.Exec(`ALTER TABLE plan_items ADD COLUMN parent_id INTEGER REFERENCES plan_items(id) ON DELETE CASCADE`); err != nil {
+			return err
+		}
+	}
+	if _, err := s.db.Exec(`CREATE INDEX IF NOT EXISTS idx_plan_items_parent ON plan_items(parent_id)`); err != nil {
+		return err
+	}
+
+	// Migration: Add entry type and decision fields to log_entries if missing
+	if !s.columnExists("log_entries", "type") {
+		if _, err := s.db.Exec(`ALTER TABLE log_entries ADD COLUMN type TEXT NOT NULL DEFAULT 'note'`); err != nil {
+			return err
+		}
+	}
+	if !s.columnExists("log_entries", "alternatives") {
+		if _, err := s.db.Exec(`ALTER TABLE log_entries ADD COLUMN alternatives TEXT NOT NULL DEFAULT ''`); err != nil {
+			return err
+		}
+	}
+	if !s.columnExists("log_entries", "rationale") {
+		if _, err := s.db.Exec(`ALTER TABLE log_entries ADD COLUMN rationale TEXT NOT NULL DEFAULT ''`); err != nil {
+			return err
+		}
+	}
+	if _, err := s.db.Exec(`CREATE INDEX IF NOT EXISTS idx_log_entries_type ON log_entries(type)`); err != nil {
+		return err
+	}
+
+	// Migration: Add attribution columns to log_entries if missing
+	for _, col := range []string{"author", "client", "session_id"} {
+		if !s.columnExists("log_entries", col) {
+			if _, err := s.db.Exec(`ALTER TABLE log_entries ADD COLUMN ` + col + ` TEXT NOT NULL DEFAULT ''`); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Migration: Add summary columns and log superseded flag if missing
+	if !s.columnExists("workstreams", "summary") {
+		if _, err := s.db.Exec(`ALTER TABLE workstreams ADD COLUMN summary TEXT NOT NULL DEFAULT ''`); err != nil {
+			return err
+		}
+	}
+	if !s.columnExists("workstreams", "summary_through") {
+		if _, err := s.db.Exec(`ALTER TABLE workstreams ADD COLUMN summary_through DATETIME`); err != nil {
+			return err
+		}
+	}
+	if !s.columnExists("log_entries", "superseded") {
+		if _, err := s.db.Exec(`ALTER TABLE log_entries ADD COLUMN superseded BOOLEAN NOT NULL DEFAULT FALSE`); err != nil {
+			return err
+		}
+	}
+
 	// Migration: Add needs_help column to workstreams if missing
 	if !s.columnExists("workstreams", "needs_help") {
 		if _, err := s.db.Exec(`ALTER TABLE workstreams ADD COLUMN needs_help BOOLEAN DEFAULT FALSE`); err != nil {
@@ -202,7 +367,33 @@ func (s *Store) Create(ws *workstream.Workstream) error {
 	}
 
 	// Insert plan items
-	for i, item := range ws.Plan {
+	if err := insertPlanItems(tx, wsID, sql.NullInt64{}, ws.Plan); err != nil {
+		return err
+	}
+
+	// Insert log entries
+	for _, entry := range ws.Log {
+		entryType := entry.Type
+		if entryType == "" {
+			entryType = workstream.EntryNote
+		}
+		_, err := tx.Exec(`
+			INSERT INTO log_entries (workstream_id, timestamp, content, type, alternatives, rationale, author, client, session_id)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			wsID, entry.Timestamp, entry.Content, string(entryType), encodeAlternatives(entry.Alternatives), entry.Rationale,
+			entry.Author, entry.Client, entry.SessionID,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// insertPlanItems inserts plan items and their subtasks under the given parent
+func insertPlanItems(tx *sql.Tx, wsID int64, parent sql.NullInt64, items []workstream.PlanItem) error {
+	for i, item := range items {
 		status := item.Status
 		if status == "" {
 			if item.Complete {
@@ -211,82 +402,58 @@ func (s *Store) Create(ws *workstream.Workstream) error {
 				status = workstream.TaskPending
 			}
 		}
-		_, err := tx.Exec(`
-			INSERT INTO plan_items (workstream_id, position, text, complete, status)
-			VALUES (?, ?, ?, ?, ?)`,
-			wsID, i, item.Text, item.Complete, string(status),
+		result, err := tx.Exec(`
+			INSERT INTO plan_items (workstream_id, parent_id, position, text, complete, status, notes)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			wsID, parent, i, item.Text, item.Complete, string(status), item.Notes,
 		)
 		if err != nil {
 			return err
 		}
-	}
-
-	// Insert log entries
-	for _, entry := range ws.Log {
-		_, err := tx.Exec(`
-			INSERT INTO log_entries (workstream_id, timestamp, content)
-			VALUES (?, ?, ?)`,
-			wsID, entry.Timestamp, entry.Content,
-		)
-		if err != nil {
-			return err
+		if len(item.Children) > 0 {
+			rowID, err := result.LastInsertId()
+			if err != nil {
+				return err
+			}
+			if err := insertPlanItems(tx, wsID, sql.NullInt64{Int64: rowID, Valid: true}, item.Children); err != nil {
+				return err
+			}
 		}
 	}
-
-	return tx.Commit()
+	return nil
 }
 
 // Get retrieves a workstream by project and name
 func (s *Store) Get(project, name string) (*workstream.Workstream, error) {
 	ws := &workstream.Workstream{}
 	var wsID int64
+	var createdAt, summaryThrough sql.NullTime
+	var helpQuestion sql.NullString
 
 	err := s.db.QueryRow(`
-		SELECT id, project, name, state, owner, needs_help, objective, last_update
+		SELECT id, project, name, state, owner, needs_help, objective, last_update, created_at, summary, summary_through,
+			`+openHelpQuestion+`
 		FROM workstreams WHERE project = ? AND name = ?`,
 		project, name,
-	).Scan(&wsID, &ws.Project, &ws.Name, &ws.State, &ws.Owner, &ws.NeedsHelp, &ws.Objective, &ws.LastUpdate)
+	).Scan(&wsID, &ws.Project, &ws.Name, &ws.State, &ws.Owner, &ws.NeedsHelp, &ws.Objective, &ws.LastUpdate, &createdAt, &ws.Summary, &summaryThrough, &helpQuestion)
 	if err != nil {
 		return nil, err
 	}
+	ws.CreatedAt = createdAt.Time
+	ws.SummaryThrough = summaryThrough.Time
+	ws.HelpQuestion = helpQuestion.String
 
 	// Load plan items
-	rows, err := s.db.Query(`
-		SELECT text, complete, status, notes FROM plan_items
-		WHERE workstream_id = ? ORDER BY position`,
-		wsID,
-	)
+	ws.Plan, err = s.loadPlan(wsID)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var item workstream.PlanItem
-		if err := rows.Scan(&item.Text, &item.Complete, &item.Status, &item.Notes); err != nil {
-			return nil, err
-		}
-		ws.Plan = append(ws.Plan, item)
-	}
 
 	// Load log entries
-	logRows, err := s.db.Query(`
-		SELECT timestamp, content FROM log_entries
-		WHERE workstream_id = ? ORDER BY timestamp DESC`,
-		wsID,
-	)
+	ws.Log, err = s.loadLog(wsID)
 	if err != nil {
 		return nil, err
 	}
-	defer logRows.Close()
-
-	for logRows.Next() {
-		var entry workstream.LogEntry
-		if err := logRows.Scan(&entry.Timestamp, &entry.Content); err != nil {
-			return nil, err
-		}
-		ws.Log = append(ws.Log, entry)
-	}
 
 	// Load BlockedBy (workstreams that block this one)
 	blockedByRows, err := s.db.Query(`
@@ -331,6 +498,159 @@ func (s *Store) Get(project, name string) (*workstream.Workstream, error) {
 	return ws, nil
 }
 
+// loadPlan loads the plan items of a workstream as a tree, each level in position order
+func (s *Store) loadPlan(wsID int64) ([]workstream.PlanItem, error) {
+	rows, err := s.db.Query(`
+		SELECT id, parent_id, text, complete, status, notes FROM plan_items
+		WHERE workstream_id = ? ORDER BY position`,
+		wsID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type node struct {
+		item   workstream.PlanItem
+		rowID  int64
+		parent sql.NullInt64
+	}
+	var nodes []node
+	children := map[int64][]int{} // parent row ID -> indexes into nodes
+	var roots []int
+	for rows.Next() {
+		var n node
+		if err := rows.Scan(&n.rowID, &n.parent, &n.item.Text, &n.item.Complete, &n.item.Status, &n.item.Notes); err != nil {
+			return nil, err
+		}
+		n.item.ID = formatTaskID(n.rowID)
+		if n.parent.Valid {
+			children[n.parent.Int64] = append(children[n.parent.Int64], len(nodes))
+		} else {
+			roots = append(roots, len(nodes))
+		}
+		nodes = append(nodes, n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var build func(idxs []int) []workstream.PlanItem
+	build = func(idxs []int) []workstream.PlanItem {
+		var items []workstream.PlanItem
+		for _, i := range idxs {
+			item := nodes[i].item
+			item.Children = build(children[nodes[i].rowID])
+			items = append(items, item)
+		}
+		return items
+	}
+	return build(roots), nil
+}
+
+// loadLog loads the log entries of a workstream, newest first
+func (s *Store) loadLog(wsID int64) ([]workstream.LogEntry, error) {
+	rows, err := s.db.Query(`
+		SELECT id, timestamp, content, type, alternatives, rationale, author, client, session_id, superseded FROM log_entries
+		WHERE workstream_id = ? ORDER BY timestamp DESC, id DESC`,
+		wsID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var log []workstream.LogEntry
+	for rows.Next() {
+		var entry workstream.LogEntry
+		var alternatives string
+		if err := rows.Scan(&entry.ID, &entry.Timestamp, &entry.Content, &entry.Type, &alternatives, &entry.Rationale, &entry.Author, &entry.Client, &entry.SessionID, &entry.Superseded); err != nil {
+			return nil, err
+		}
+		entry.Alternatives = decodeAlternatives(alternatives)
+		log = append(log, entry)
+	}
+	return log, rows.Err()
+}
+
+// encodeAlternatives stores a decision's rejected alternatives as a JSON
+// array, or "" if there are none
+func encodeAlternatives(alts []string) string {
+	if len(alts) == 0 {
+		return ""
+	}
+	data, _ := json.Marshal(alts)
+	return string(data)
+}
+
+// decodeAlternatives reads alternatives stored by encodeAlternatives, falling
+// back to the newline-separated form older databases used
+func decodeAlternatives(s string) []string {
+	if s == "" {
+		return nil
+	}
+	var alts []string
+	if strings.HasPrefix(s, "[") && json.Unmarshal([]byte(s), &alts) == nil {
+		return alts
+	}
+	return strings.Split(s, "\n")
+}
+
+// Compact replaces the log entries up to through (or all entries, if through
+// is zero) with a summary. The entries are kept and marked superseded so they
+// stay available to history and search. The summary replaces any previous
+// one, so it should carry forward whatever the earlier summary covered. A
+// through earlier than the previous summary's keeps the previous one.
+// Returns the number of entries newly superseded.
+func (s *Store) Compact(project, name, summary string, through time.Time) (int, error) {
+	if strings.TrimSpace(summary) == "" {
+		return 0, fmt.Errorf("summary is required")
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var wsID int64
+	var previous sql.NullTime
+	err = tx.QueryRow(`SELECT id, summary_through FROM workstreams WHERE project = ? AND name = ?`, project, name).Scan(&wsID, &previous)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("workstream not found: %s/%s", project, name)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now().UTC()
+	if through.IsZero() {
+		through = now
+	}
+	// Entries an earlier summary covered stay superseded, so it never moves back
+	if previous.Valid && previous.Time.After(through) {
+		through = previous.Time
+	}
+
+	res, err := tx.Exec(`
+		UPDATE log_entries SET superseded = TRUE
+		WHERE workstream_id = ? AND timestamp <= ? AND NOT superseded`,
+		wsID, through.UTC(),
+	)
+	if err != nil {
+		return 0, err
+	}
+	superseded, _ := res.RowsAffected()
+
+	_, err = tx.Exec(`UPDATE workstreams SET summary = ?, summary_through = ?, last_update = ? WHERE id = ?`,
+		summary, through.UTC(), now, wsID)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(superseded), tx.Commit()
+}
+
 // ListProjects returns all distinct project names
 func (s *Store) ListProjects() ([]string, error) {
 	rows, err := s.db.Query(`SELECT DISTINCT project FROM workstreams ORDER BY project`)
@@ -352,7 +672,7 @@ func (s *Store) ListProjects() ([]string, error) {
 
 // List returns workstreams matching the filter
 func (s *Store) List(filter Filter) ([]workstream.Workstream, error) {
-	query := `SELECT id, project, name, state, owner, needs_help, objective, last_update FROM workstreams WHERE 1=1`
+	query := `SELECT id, project, name, state, owner, needs_help, objective, last_update, created_at, summary, summary_through, ` + openHelpQuestion + ` FROM workstreams WHERE 1=1`
 	var args []any
 
 	if filter.Project != "" {
@@ -367,6 +687,15 @@ func (s *Store) List(filter Filter) ([]workstream.Workstream, error) {
 		query += " AND owner = ?"
 		args = append(args, filter.Owner)
 	}
+	if filter.Stale != nil {
+		conds := []string{"0"}
+		now := time.Now().UTC()
+		for _, state := range filter.Stale.States() {
+			conds = append(conds, "(state = ? AND last_update <= ?)")
+			args = append(args, string(state), now.Add(-filter.Stale[state]))
+		}
+		query += " AND (" + strings.Join(conds, " OR ") + ")"
+	}
 
 	query += " ORDER BY project, name"
 
@@ -380,33 +709,26 @@ func (s *Store) List(filter Filter) ([]workstream.Workstream, error) {
 	for rows.Next() {
 		var ws workstream.Workstream
 		var wsID int64
-		if err := rows.Scan(&wsID, &ws.Project, &ws.Name, &ws.State, &ws.Owner, &ws.NeedsHelp, &ws.Objective, &ws.LastUpdate); err != nil {
+		var createdAt, summaryThrough sql.NullTime
+		var helpQuestion sql.NullString
+		if err := rows.Scan(&wsID, &ws.Project, &ws.Name, &ws.State, &ws.Owner, &ws.NeedsHelp, &ws.Objective, &ws.LastUpdate, &createdAt, &ws.Summary, &summaryThrough, &helpQuestion); err != nil {
 			return nil, err
 		}
+		ws.CreatedAt = createdAt.Time
+		ws.SummaryThrough = summaryThrough.Time
+		ws.HelpQuestion = helpQuestion.String
 
 		// Load plan items
-		planRows, err := s.db.Query(`SELECT text, complete, status, notes FROM plan_items WHERE workstream_id = ? ORDER BY position`, wsID)
+		ws.Plan, err = s.loadPlan(wsID)
 		if err != nil {
 			return nil, err
 		}
-		for planRows.Next() {
-			var item workstream.PlanItem
-			planRows.Scan(&item.Text, &item.Complete, &item.Status, &item.Notes)
-			ws.Plan = append(ws.Plan, item)
-		}
-		planRows.Close()
 
 		// Load log entries
-		logRows, err := s.db.Query(`SELECT timestamp, content FROM log_entries WHERE workstream_id = ? ORDER BY timestamp DESC`, wsID)
+		ws.Log, err = s.loadLog(wsID)
 		if err != nil {
 			return nil, err
 		}
-		for logRows.Next() {
-			var entry workstream.LogEntry
-			logRows.Scan(&entry.Timestamp, &entry.Content)
-			ws.Log = append(ws.Log, entry)
-		}
-		logRows.Close()
 
 		results = append(results, ws)
 	}
@@ -429,6 +751,12 @@ func (s *Store) Update(project, name string, updates WorkstreamUpdate) error {
 	}
 	defer tx.Rollback()
 
+	var oldState string
+	var oldNeedsHelp bool
+	if err := tx.QueryRow(`SELECT state, needs_help FROM workstreams WHERE id = ?`, wsID).Scan(&oldState, &oldNeedsHelp); err != nil {
+		return err
+	}
+
 	// Update state
 	if updates.State != nil {
 		_, err := tx.Exec(`UPDATE workstreams SET state = ?, last_update = ? WHERE id = ?`,
@@ -436,6 +764,22 @@ func (s *Store) Update(project, name string, updates WorkstreamUpdate) error {
 		if err != nil {
 			return err
 		}
+		if string(*updates.State) != oldState {
+			err := recordEvent(tx, workstream.Event{
+				Type:       workstream.EventStateChanged,
+				Project:    project,
+				Workstream: name,
+				Data:       map[string]string{"from": oldState, "to": string(*updates.State)},
+			})
+			if err != nil {
+				return err
+			}
+			if *updates.State == workstream.StateDone {
+				if err := recordCompletedMilestones(tx, wsID); err != nil {
+					return err
+				}
+			}
+		}
 	}
 
 	// Update owner
@@ -454,6 +798,23 @@ func (s *Store) Update(project, name string, updates WorkstreamUpdate) error {
 		if err != nil {
 			return err
 		}
+		// Clearing the flag without an answer withdraws any open help request
+		if !*updates.NeedsHelp {
+			_, err := tx.Exec(`UPDATE help_requests SET answered_at = ? WHERE workstream_id = ? AND answered_at IS NULL`,
+				time.Now().UTC(), wsID)
+			if err != nil {
+				return err
+			}
+		}
+		if *updates.NeedsHelp != oldNeedsHelp {
+			evType := workstream.EventNeedsHelpCleared
+			if *updates.NeedsHelp {
+				evType = workstream.EventNeedsHelpRaised
+			}
+			if err := recordEvent(tx, workstream.Event{Type: evType, Project: project, Workstream: name}); err != nil {
+				return err
+			}
+		}
 	}
 
 	// Append log entry
@@ -461,8 +822,15 @@ func (s *Store) Update(project, name string, updates WorkstreamUpdate) error {
 		// Unescape literal \n and \u000A to actual newlines (MCP sends escaped newlines)
 		content := strings.ReplaceAll(*updates.LogEntry, "\\n", "\n")
 		content = strings.ReplaceAll(content, "\\u000A", "\n")
-		_, err := tx.Exec(`INSERT INTO log_entries (workstream_id, timestamp, content) VALUES (?, ?, ?)`,
-			wsID, time.Now().UTC(), content)
+		entryType := updates.LogType
+		if entryType == "" {
+			entryType = workstream.EntryNote
+		}
+		_, err := tx.Exec(`
+			INSERT INTO log_entries (workstream_id, timestamp, content, type, alternatives, rationale, author, client, session_id)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			wsID, time.Now().UTC(), content, string(entryType), encodeAlternatives(updates.LogAlternatives), updates.LogRationale,
+			updates.LogAuthor, updates.LogClient, updates.LogSession)
 		if err != nil {
 			return err
 		}
@@ -474,13 +842,41 @@ func (s *Store) Update(project, name string, updates WorkstreamUpdate) error {
 
 	// Toggle plan item
 	if updates.PlanIndex != nil {
-		_, err := tx.Exec(`
-			UPDATE plan_items SET complete = NOT complete
-			WHERE workstream_id = ? AND position = ?`,
-			wsID, *updates.PlanIndex)
+		var rowID int64
+		err := tx.QueryRow(`SELECT id FROM plan_items WHERE workstream_id = ? AND parent_id IS NULL AND position = ?`,
+			wsID, *updates.PlanIndex).Scan(&rowID)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("no task at position %d in %s/%s", *updates.PlanIndex, project, name)
+		}
+		if err != nil {
+			return err
+		}
+		if err := toggleTask(tx, project, name, rowID); err != nil {
+			return err
+		}
+		_, err = tx.Exec(`UPDATE workstreams SET last_update = ? WHERE id = ?`, time.Now().UTC(), wsID)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Toggle plan item by stable ID
+	if updates.PlanTask != nil {
+		rowID, err := parseTaskID(*updates.PlanTask)
+		if err != nil {
+			return err
+		}
+		var found bool
+		err = tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM plan_items WHERE workstream_id = ? AND id = ?)`, wsID, rowID).Scan(&found)
 		if err != nil {
 			return err
 		}
+		if !found {
+			return fmt.Errorf("task not found: %s in %s/%s", *updates.PlanTask, project, name)
+		}
+		if err := toggleTask(tx, project, name, rowID); err != nil {
+			return err
+		}
 		_, err = tx.Exec(`UPDATE workstreams SET last_update = ? WHERE id = ?`, time.Now().UTC(), wsID)
 		if err != nil {
 			return err
@@ -490,39 +886,180 @@ func (s *Store) Update(project, name string, updates WorkstreamUpdate) error {
 	return tx.Commit()
 }
 
-// AddTask adds a new task to a workstream
-func (s *Store) AddTask(project, name, text string) error {
+// AddTask adds a new task to a workstream and returns its stable ID
+func (s *Store) AddTask(project, name, text string) (string, error) {
 	var wsID int64
 	err := s.db.QueryRow(`SELECT id FROM workstreams WHERE project = ? AND name = ?`, project, name).Scan(&wsID)
 	if err != nil {
-		return err
+		return "", err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	rowID, err := addPlanItem(tx, wsID, sql.NullInt64{}, text)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = tx.Exec(`UPDATE workstreams SET last_update = ? WHERE id = ?`, time.Now().UTC(), wsID)
+	if err != nil {
+		return "", err
+	}
+	return formatTaskID(rowID), tx.Commit()
+}
+
+// AddSubtask adds a new subtask under the given parent task and returns its stable ID
+func (s *Store) AddSubtask(project, name, parentID, text string) (string, error) {
+	wsID, parentRow, err := s.taskRow(project, name, parentID)
+	if err != nil {
+		return "", err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	parent := sql.NullInt64{Int64: parentRow, Valid: true}
+	rowID, err := addPlanItem(tx, wsID, parent, text)
+	if err != nil {
+		return "", err
+	}
+
+	// A new pending subtask can reopen a finished parent
+	if err := rollUp(tx, parent); err != nil {
+		return "", err
+	}
+
+	_, err = tx.Exec(`UPDATE workstreams SET last_update = ? WHERE id = ?`, time.Now().UTC(), wsID)
+	if err != nil {
+		return "", err
 	}
+	return formatTaskID(rowID), tx.Commit()
+}
 
+// addPlanItem appends a pending plan item after its last sibling
+func addPlanItem(tx *sql.Tx, wsID int64, parent sql.NullInt64, text string) (int64, error) {
 	// Get next position
 	var maxPos sql.NullInt64
-	s.db.QueryRow(`SELECT MAX(position) FROM plan_items WHERE workstream_id = ?`, wsID).Scan(&maxPos)
+	tx.QueryRow(`SELECT MAX(position) FROM plan_items WHERE workstream_id = ? AND parent_id IS ?`, wsID, parent).Scan(&maxPos)
 	nextPos := 0
 	if maxPos.Valid {
 		nextPos = int(maxPos.Int64) + 1
 	}
 
-	_, err = s.db.Exec(`
-		INSERT INTO plan_items (workstream_id, position, text, complete, status)
-		VALUES (?, ?, ?, FALSE, 'pending')`,
-		wsID, nextPos, text,
+	result, err := tx.Exec(`
+		INSERT INTO plan_items (workstream_id, parent_id, position, text, complete, status)
+		VALUES (?, ?, ?, ?, FALSE, 'pending')`,
+		wsID, parent, nextPos, text,
 	)
 	if err != nil {
-		return err
+		return 0, err
 	}
+	return result.LastInsertId()
+}
 
-	_, err = s.db.Exec(`UPDATE workstreams SET last_update = ? WHERE id = ?`, time.Now().UTC(), wsID)
-	return err
+// rollUp recomputes the status of parent from its subtasks, then repeats for
+// each ancestor in turn. A parent whose last subtask was removed keeps its status.
+func rollUp(tx *sql.Tx, parent sql.NullInt64) error {
+	for parent.Valid {
+		rows, err := tx.Query(`SELECT status FROM plan_items WHERE parent_id = ?`, parent.Int64)
+		if err != nil {
+			return err
+		}
+		var statuses []workstream.TaskStatus
+		for rows.Next() {
+			var st workstream.TaskStatus
+			if err := rows.Scan(&st); err != nil {
+				rows.Close()
+				return err
+			}
+			statuses = append(statuses, st)
+		}
+		rows.Close()
+
+		if status := workstream.RollUpStatus(statuses); status != "" {
+			_, err := tx.Exec(`UPDATE plan_items SET status = ?, complete = ? WHERE id = ?`,
+				string(status), status == workstream.TaskDone, parent.Int64)
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := tx.QueryRow(`SELECT parent_id FROM plan_items WHERE id = ?`, parent.Int64).Scan(&parent); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TaskID returns the stable ID of the top-level task at the given position
+func (s *Store) TaskID(project, name string, position int) (string, error) {
+	var rowID int64
+	err := s.db.QueryRow(`
+		SELECT p.id FROM plan_items p
+		JOIN workstreams w ON p.workstream_id = w.id
+		WHERE w.project = ? AND w.name = ? AND p.parent_id IS NULL AND p.position = ?`,
+		project, name, position,
+	).Scan(&rowID)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("no task at position %d in %s/%s", position, project, name)
+	}
+	if err != nil {
+		return "", err
+	}
+	return formatTaskID(rowID), nil
+}
+
+// taskRow resolves a stable task ID to its plan_items row, verifying it belongs to the workstream
+func (s *Store) taskRow(project, name, taskID string) (wsID, rowID int64, err error) {
+	rowID, err = parseTaskID(taskID)
+	if err != nil {
+		return 0, 0, err
+	}
+	err = s.db.QueryRow(`
+		SELECT w.id FROM plan_items p
+		JOIN workstreams w ON p.workstream_id = w.id
+		WHERE w.project = ? AND w.name = ? AND p.id = ?`,
+		project, name, rowID,
+	).Scan(&wsID)
+	if err == sql.ErrNoRows {
+		return 0, 0, fmt.Errorf("task not found: %s in %s/%s", taskID, project, name)
+	}
+	return wsID, rowID, err
+}
+
+// formatTaskID converts a plan_items row ID into its public short form
+func formatTaskID(rowID int64) string {
+	return "t" + strconv.FormatInt(rowID, 10)
+}
+
+// parseTaskID converts a public task ID ("t12") back into a plan_items row ID
+func parseTaskID(id string) (int64, error) {
+	rowID, err := strconv.ParseInt(strings.TrimPrefix(id, "t"), 10, 64)
+	if err != nil || !strings.HasPrefix(id, "t") {
+		return 0, fmt.Errorf("invalid task ID %q (expected e.g. \"t12\")", id)
+	}
+	return rowID, nil
 }
 
 // RemoveTask removes a task at the given position and reorders remaining tasks
 func (s *Store) RemoveTask(project, name string, position int) error {
-	var wsID int64
-	err := s.db.QueryRow(`SELECT id FROM workstreams WHERE project = ? AND name = ?`, project, name).Scan(&wsID)
+	taskID, err := s.TaskID(project, name, position)
+	if err != nil {
+		return err
+	}
+	return s.RemoveTaskByID(project, name, taskID)
+}
+
+// RemoveTaskByID removes a task and its subtasks by stable ID and closes the gap in positions
+func (s *Store) RemoveTaskByID(project, name, taskID string) error {
+	wsID, rowID, err := s.taskRow(project, name, taskID)
 	if err != nil {
 		return err
 	}
@@ -533,14 +1070,81 @@ func (s *Store) RemoveTask(project, name string, position int) error {
 	}
 	defer tx.Rollback()
 
-	// Delete the task at position
-	_, err = tx.Exec(`DELETE FROM plan_items WHERE workstream_id = ? AND position = ?`, wsID, position)
+	var position int
+	var parent sql.NullInt64
+	if err := tx.QueryRow(`SELECT position, parent_id FROM plan_items WHERE id = ?`, rowID).Scan(&position, &parent); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		DELETE FROM plan_items WHERE id IN (
+			WITH RECURSIVE subtree(id) AS (
+				SELECT ?
+				UNION ALL
+				SELECT p.id FROM plan_items p JOIN subtree ON p.parent_id = subtree.id
+			)
+			SELECT id FROM subtree
+		)`, rowID)
 	if err != nil {
 		return err
 	}
 
-	// Reorder remaining tasks (decrement position for all tasks after the deleted one)
-	_, err = tx.Exec(`UPDATE plan_items SET position = position - 1 WHERE workstream_id = ? AND position > ?`, wsID, position)
+	// Reorder remaining siblings (decrement position for all tasks after the deleted one)
+	_, err = tx.Exec(`UPDATE plan_items SET position = position - 1 WHERE workstream_id = ? AND parent_id IS ? AND position > ?`, wsID, parent, position)
+	if err != nil {
+		return err
+	}
+
+	if err := rollUp(tx, parent); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`UPDATE workstreams SET last_update = ? WHERE id = ?`, time.Now().UTC(), wsID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// MoveTask moves a task to a new 0-indexed position among its siblings, shifting the tasks in between
+func (s *Store) MoveTask(project, name, taskID string, to int) error {
+	wsID, rowID, err := s.taskRow(project, name, taskID)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var from, count int
+	var parent sql.NullInt64
+	if err := tx.QueryRow(`SELECT position, parent_id FROM plan_items WHERE id = ?`, rowID).Scan(&from, &parent); err != nil {
+		return err
+	}
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM plan_items WHERE workstream_id = ? AND parent_id IS ?`, wsID, parent).Scan(&count); err != nil {
+		return err
+	}
+	if to < 0 {
+		to = 0
+	}
+	if to > count-1 {
+		to = count - 1
+	}
+
+	if to < from {
+		_, err = tx.Exec(`UPDATE plan_items SET position = position + 1 WHERE workstream_id = ? AND parent_id IS ? AND position >= ? AND position < ?`, wsID, parent, to, from)
+	} else {
+		_, err = tx.Exec(`UPDATE plan_items SET position = position - 1 WHERE workstream_id = ? AND parent_id IS ? AND position > ? AND position <= ?`, wsID, parent, from, to)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`UPDATE plan_items SET position = ? WHERE id = ?`, to, rowID)
 	if err != nil {
 		return err
 	}
@@ -555,23 +1159,102 @@ func (s *Store) RemoveTask(project, name string, position int) error {
 
 // SetTaskStatus sets the status of a task at the given position
 func (s *Store) SetTaskStatus(project, name string, position int, status workstream.TaskStatus) error {
-	var wsID int64
-	err := s.db.QueryRow(`SELECT id FROM workstreams WHERE project = ? AND name = ?`, project, name).Scan(&wsID)
+	taskID, err := s.TaskID(project, name, position)
+	if err != nil {
+		return err
+	}
+	return s.SetTaskStatusByID(project, name, taskID, status)
+}
+
+// SetTaskStatusByID sets the status of a task by stable ID and rolls the
+// change up through its parent tasks
+func (s *Store) SetTaskStatusByID(project, name, taskID string, status workstream.TaskStatus) error {
+	wsID, rowID, err := s.taskRow(project, name, taskID)
 	if err != nil {
 		return err
 	}
 
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := setTaskStatus(tx, project, name, rowID, status); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`UPDATE workstreams SET last_update = ? WHERE id = ?`, time.Now().UTC(), wsID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// toggleTask flips a task between done and pending
+func toggleTask(tx *sql.Tx, project, name string, rowID int64) error {
+	var status workstream.TaskStatus
+	if err := tx.QueryRow(`SELECT status FROM plan_items WHERE id = ?`, rowID).Scan(&status); err != nil {
+		return err
+	}
+	if status == workstream.TaskDone {
+		return setTaskStatus(tx, project, name, rowID, workstream.TaskPending)
+	}
+	return setTaskStatus(tx, project, name, rowID, workstream.TaskDone)
+}
+
+// setTaskStatus sets a task's status, rolls the change up through its parent
+// tasks and records a task_done event when the task is newly finished
+func setTaskStatus(tx *sql.Tx, project, name string, rowID int64, status workstream.TaskStatus) error {
+	var oldStatus, text string
+	if err := tx.QueryRow(`SELECT status, text FROM plan_items WHERE id = ?`, rowID).Scan(&oldStatus, &text); err != nil {
+		return err
+	}
+
 	// Update status and complete (complete = true when status is done)
 	complete := status == workstream.TaskDone
-	_, err = s.db.Exec(`
-		UPDATE plan_items SET status = ?, complete = ?
-		WHERE workstream_id = ? AND position = ?`,
-		string(status), complete, wsID, position,
+	_, err := tx.Exec(`UPDATE plan_items SET status = ?, complete = ? WHERE id = ?`,
+		string(status), complete, rowID,
 	)
 	if err != nil {
 		return err
 	}
 
+	var parent sql.NullInt64
+	if err := tx.QueryRow(`SELECT parent_id FROM plan_items WHERE id = ?`, rowID).Scan(&parent); err != nil {
+		return err
+	}
+	if err := rollUp(tx, parent); err != nil {
+		return err
+	}
+
+	if status == workstream.TaskDone && oldStatus != string(workstream.TaskDone) {
+		err := recordEvent(tx, workstream.Event{
+			Type:       workstream.EventTaskDone,
+			Project:    project,
+			Workstream: name,
+			Data:       map[string]string{"task_id": formatTaskID(rowID), "task": text},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetTaskText replaces the text of a task in place
+func (s *Store) SetTaskText(project, name, taskID, text string) error {
+	wsID, rowID, err := s.taskRow(project, name, taskID)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec(`UPDATE plan_items SET text = ? WHERE id = ?`, text, rowID)
+	if err != nil {
+		return err
+	}
+
 	_, err = s.db.Exec(`UPDATE workstreams SET last_update = ? WHERE id = ?`, time.Now().UTC(), wsID)
 	return err
 }
@@ -594,6 +1277,32 @@ func (s *Store) AddDependency(blockerProject, blockerName, blockedProject, block
 	return err
 }
 
+// Dependencies returns every dependency touching project: edges where the
+// blocker or the blocked workstream belongs to it
+func (s *Store) Dependencies(project string) ([]workstream.Dependency, error) {
+	rows, err := s.db.Query(`
+		SELECT blocker.project, blocker.name, blocked.project, blocked.name
+		FROM workstream_dependencies d
+		JOIN workstreams blocker ON d.blocker_id = blocker.id
+		JOIN workstreams blocked ON d.blocked_id = blocked.id
+		WHERE blocker.project = ? OR blocked.project = ?
+		ORDER BY blocker.project, blocker.name, blocked.project, blocked.name`, project, project)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deps []workstream.Dependency
+	for rows.Next() {
+		var dep workstream.Dependency
+		if err := rows.Scan(&dep.BlockerProject, &dep.BlockerName, &dep.BlockedProject, &dep.BlockedName); err != nil {
+			return nil, err
+		}
+		deps = append(deps, dep)
+	}
+	return deps, rows.Err()
+}
+
 // RemoveDependency removes a blocking relationship between two workstreams
 func (s *Store) RemoveDependency(blockerProject, blockerName, blockedProject, blockedName string) error {
 	var blockerID, blockedID int64
@@ -614,14 +1323,21 @@ func (s *Store) RemoveDependency(blockerProject, blockerName, blockedProject, bl
 
 // SetTaskNotes sets the notes for a task at the given position
 func (s *Store) SetTaskNotes(project, name string, position int, notes string) error {
-	var wsID int64
-	err := s.db.QueryRow(`SELECT id FROM workstreams WHERE project = ? AND name = ?`, project, name).Scan(&wsID)
+	taskID, err := s.TaskID(project, name, position)
 	if err != nil {
 		return err
 	}
+	return s.SetTaskNotesByID(project, name, taskID, notes)
+}
 
-	_, err = s.db.Exec(`UPDATE plan_items SET notes = ? WHERE workstream_id = ? AND position = ?`,
-		notes, wsID, position)
+// SetTaskNotesByID sets the notes for a task by stable ID
+func (s *Store) SetTaskNotesByID(project, name, taskID, notes string) error {
+	wsID, rowID, err := s.taskRow(project, name, taskID)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec(`UPDATE plan_items SET notes = ? WHERE id = ?`, notes, rowID)
 	if err != nil {
 		return err
 	}
@@ -659,8 +1375,32 @@ func (s *Store) Delete(project, name string) error {
 
 // RecentActivity returns recent log entries across all workstreams for a project
 func (s *Store) RecentActivity(project string, limit, offset int) ([]workstream.ActivityEntry, error) {
-	rows, err := s.db.Query(`
-		SELECT w.name, w.project, l.timestamp, l.content, w.needs_help,
+	return s.Activity(ActivityFilter{Project: project}, limit, offset)
+}
+
+// Activity returns recent log entries across all workstreams matching the filter
+func (s *Store) Activity(filter ActivityFilter, limit, offset int) ([]workstream.ActivityEntry, error) {
+	query, args := activityQuery(filter)
+	query += " ORDER BY l.timestamp DESC LIMIT ? OFFSET ?"
+	args = append(args, limit, offset)
+	return s.queryActivity(query, args)
+}
+
+// ActivityAfter returns up to limit entries with IDs above afterID, oldest
+// first, for readers that follow the feed with a cursor. Callers page by
+// passing the last ID back until fewer than limit entries are returned.
+func (s *Store) ActivityAfter(filter ActivityFilter, afterID int64, limit int) ([]workstream.ActivityEntry, error) {
+	query, args := activityQuery(filter)
+	query += " AND l.id > ? ORDER BY l.id ASC LIMIT ?"
+	args = append(args, afterID, limit)
+	return s.queryActivity(query, args)
+}
+
+// activityQuery builds the unordered activity query for filter
+func activityQuery(filter ActivityFilter) (string, []any) {
+	query := `
+		SELECT l.id, w.name, w.project, l.timestamp, l.content, l.type, l.alternatives, l.rationale,
+			l.author, l.client, l.session_id, w.needs_help,
 			(SELECT b.project || '/' || b.name
 			 FROM workstream_dependencies d
 			 JOIN workstreams b ON d.blocker_id = b.id
@@ -668,11 +1408,46 @@ func (s *Store) RecentActivity(project string, limit, offset int) ([]workstream.
 			 LIMIT 1)
 		FROM log_entries l
 		JOIN workstreams w ON l.workstream_id = w.id
-		WHERE w.project = ?
-		ORDER BY l.timestamp DESC
-		LIMIT ? OFFSET ?`,
-		project, limit, offset,
-	)
+		WHERE w.project = ?`
+	args := []any{filter.Project}
+
+	if filter.Workstream != "" {
+		query += " AND w.name = ?"
+		args = append(args, filter.Workstream)
+	}
+	if filter.Type != "" {
+		query += " AND l.type = ?"
+		args = append(args, string(filter.Type))
+	}
+	if filter.Author != "" {
+		query += " AND (l.author = ? OR (l.author = '' AND l.client = ?))"
+		args = append(args, filter.Author, filter.Author)
+	}
+	if filter.Session != "" {
+		query += " AND l.session_id = ?"
+		args = append(args, filter.Session)
+	}
+	if filter.Milestone != "" {
+		query += ` AND w.id IN (
+			SELECT mr.workstream_id FROM milestone_requirements mr
+			JOIN milestones m ON mr.milestone_id = m.id
+			WHERE m.project = ? AND m.name = ?)`
+		args = append(args, filter.Project, filter.Milestone)
+	}
+	if !filter.Since.IsZero() {
+		query += " AND l.timestamp > ?"
+		args = append(args, filter.Since.UTC())
+	}
+	if filter.ExcludeAuthor != "" {
+		query += " AND NOT (l.author = ? OR (l.author = '' AND l.client = ?))"
+		args = append(args, filter.ExcludeAuthor, filter.ExcludeAuthor)
+	}
+	return query, args
+}
+
+// queryActivity runs an activity query and scans its entries
+func (s *Store) queryActivity(query string, args []any) ([]workstream.ActivityEntry, error) {
+	rows, err := s.db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -681,13 +1456,16 @@ func (s *Store) RecentActivity(project string, limit, offset int) ([]workstream.
 	var entries []workstream.ActivityEntry
 	for rows.Next() {
 		var entry workstream.ActivityEntry
+		var alternatives string
 		var blockedBy *string
-		if err := rows.Scan(&entry.WorkstreamName, &entry.WorkstreamProject, &entry.Timestamp, &entry.Content, &entry.NeedsHelp, &blockedBy); err != nil {
+		if err := rows.Scan(&entry.ID, &entry.WorkstreamName, &entry.WorkstreamProject, &entry.Timestamp, &entry.Content, &entry.Type, &alternatives, &entry.Rationale,
+			&entry.Author, &entry.Client, &entry.SessionID, &entry.NeedsHelp, &blockedBy); err != nil {
 			return nil, err
 		}
 		if blockedBy != nil {
 			entry.BlockedBy = *blockedBy
 		}
+		entry.Alternatives = decodeAlternatives(alternatives)
 		entry.RelativeTime = relativeTime(entry.Timestamp)
 		entries = append(entries, entry)
 	}
@@ -716,6 +1494,7 @@ type SearchResult struct {
 	Content        string    `json:"content"`
 	Timestamp      time.Time `json:"timestamp,omitempty"`
 	TaskPosition   int       `json:"taskPosition,omitempty"`
+	TaskID         string    `json:"taskId,omitempty"`
 	TaskStatus     string    `json:"taskStatus,omitempty"`
 	RelativeTime   string    `json:"relativeTime,omitempty"`
 }
@@ -765,7 +1544,7 @@ func (s *Store) Search(project, query, wsFilter string) ([]SearchResult, error)
 
 	// Search tasks
 	taskQuery := `
-		SELECT w.name, p.position, p.text, p.status
+		SELECT w.name, p.id, p.position, p.text, p.status
 		FROM plan_items p
 		JOIN workstreams w ON p.workstream_id = w.id
 		WHERE w.project = ?`
@@ -790,10 +1569,12 @@ func (s *Store) Search(project, query, wsFilter string) ([]SearchResult, error)
 
 	for taskRows.Next() {
 		var r SearchResult
-		if err := taskRows.Scan(&r.WorkstreamName, &r.TaskPosition, &r.Content, &r.TaskStatus); err != nil {
+		var rowID int64
+		if err := taskRows.Scan(&r.WorkstreamName, &rowID, &r.TaskPosition, &r.Content, &r.TaskStatus); err != nil {
 			return nil, err
 		}
 		r.Type = "task"
+		r.TaskID = formatTaskID(rowID)
 		results = append(results, r)
 	}
 
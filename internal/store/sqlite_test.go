@@ -3,6 +3,7 @@ package store
 import (
 	"database/sql"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -180,6 +181,35 @@ func TestListFilterByOwner(t *testing.T) {
 	}
 }
 
+func TestListFilterStale(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	s, _ := New(dbPath)
+	defer s.Close()
+
+	weeksAgo := time.Now().Add(-20 * 24 * time.Hour)
+	s.Create(&workstream.Workstream{Name: "idle", Project: "proj", State: workstream.StateInProgress, LastUpdate: weeksAgo})
+	s.Create(&workstream.Workstream{Name: "busy", Project: "proj", State: workstream.StateInProgress, LastUpdate: time.Now()})
+	s.Create(&workstream.Workstream{Name: "parked", Project: "proj", State: workstream.StatePending, LastUpdate: weeksAgo})
+	s.Create(&workstream.Workstream{Name: "shipped", Project: "proj", State: workstream.StateDone, LastUpdate: weeksAgo})
+
+	stale, err := s.List(Filter{Project: "proj", Stale: workstream.DefaultStaleRules})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(stale) != 1 || stale[0].Name != "idle" {
+		t.Errorf("stale = %v, want only idle", stale)
+	}
+
+	stale, _ = s.List(Filter{Project: "proj", Stale: workstream.StaleRules{workstream.StatePending: 24 * time.Hour}})
+	if len(stale) != 1 || stale[0].Name != "parked" {
+		t.Errorf("stale pending = %v, want only parked", stale)
+	}
+
+	if stale, _ = s.List(Filter{Project: "proj", Stale: workstream.StaleRules{}}); len(stale) != 0 {
+		t.Errorf("no rules should match nothing, got %v", stale)
+	}
+}
+
 func TestUpdate(t *testing.T) {
 	dbPath := filepath.Join(t.TempDir(), "test.db")
 	s, _ := New(dbPath)
@@ -437,7 +467,7 @@ func TestAddTask(t *testing.T) {
 		State:   workstream.StatePending,
 	})
 
-	err := s.AddTask("proj", "Task Test", "New task")
+	_, err := s.AddTask("proj", "Task Test", "New task")
 	if err != nil {
 		t.Fatalf("AddTask() error = %v", err)
 	}
@@ -464,6 +494,341 @@ func TestAddTask(t *testing.T) {
 	}
 }
 
+func TestTaskIDsStableAcrossRemove(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	s, _ := New(dbPath)
+	defer s.Close()
+
+	s.Create(&workstream.Workstream{
+		Name:    "IDs",
+		Project: "proj",
+		State:   workstream.StatePending,
+		Plan: []workstream.PlanItem{
+			{Text: "Task 0"},
+			{Text: "Task 1"},
+			{Text: "Task 2"},
+		},
+	})
+
+	before, _ := s.Get("proj", "IDs")
+	lastID := before.Plan[2].ID
+	if lastID == "" {
+		t.Fatal("Plan[2].ID is empty, want stable task ID")
+	}
+
+	// An agent holding the old view removes the first task, then updates the last
+	if err := s.RemoveTaskByID("proj", "IDs", before.Plan[0].ID); err != nil {
+		t.Fatalf("RemoveTaskByID() error = %v", err)
+	}
+	if err := s.SetTaskStatusByID("proj", "IDs", lastID, workstream.TaskDone); err != nil {
+		t.Fatalf("SetTaskStatusByID() error = %v", err)
+	}
+
+	got, _ := s.Get("proj", "IDs")
+	if len(got.Plan) != 2 {
+		t.Fatalf("Plan length = %d, want 2", len(got.Plan))
+	}
+	if got.Plan[1].ID != lastID || got.Plan[1].Status != workstream.TaskDone {
+		t.Errorf("Plan[1] = %+v, want %s done", got.Plan[1], lastID)
+	}
+	if got.Plan[0].Status != workstream.TaskPending {
+		t.Errorf("Plan[0].Status = %q, want pending (wrong task updated)", got.Plan[0].Status)
+	}
+
+	// New tasks never reuse a removed ID
+	newID, err := s.AddTask("proj", "IDs", "Task 3")
+	if err != nil {
+		t.Fatalf("AddTask() error = %v", err)
+	}
+	if newID == before.Plan[0].ID {
+		t.Errorf("AddTask() reused removed ID %s", newID)
+	}
+}
+
+func TestTaskIDWrongWorkstream(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	s, _ := New(dbPath)
+	defer s.Close()
+
+	s.Create(&workstream.Workstream{Name: "a", Project: "proj", State: workstream.StatePending, Plan: []workstream.PlanItem{{Text: "A task"}}})
+	s.Create(&workstream.Workstream{Name: "b", Project: "proj", State: workstream.StatePending})
+
+	a, _ := s.Get("proj", "a")
+	if err := s.SetTaskNotesByID("proj", "b", a.Plan[0].ID, "notes"); err == nil {
+		t.Error("SetTaskNotesByID() should reject a task from another workstream")
+	}
+	if err := s.SetTaskStatusByID("proj", "a", "bogus", workstream.TaskDone); err == nil {
+		t.Error("SetTaskStatusByID() should reject a malformed ID")
+	}
+	if err := s.SetTaskStatus("proj", "a", 5, workstream.TaskDone); err == nil {
+		t.Error("SetTaskStatus() should reject an out-of-range position")
+	}
+}
+
+func TestMoveTask(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	s, _ := New(dbPath)
+	defer s.Close()
+
+	s.Create(&workstream.Workstream{
+		Name:    "Move",
+		Project: "proj",
+		State:   workstream.StatePending,
+		Plan: []workstream.PlanItem{
+			{Text: "A"}, {Text: "B"}, {Text: "C"}, {Text: "D"},
+		},
+	})
+	ws, _ := s.Get("proj", "Move")
+
+	// Move D to the front
+	if err := s.MoveTask("proj", "Move", ws.Plan[3].ID, 0); err != nil {
+		t.Fatalf("MoveTask() error = %v", err)
+	}
+	got, _ := s.Get("proj", "Move")
+	if texts := planTexts(got.Plan); texts != "DABC" {
+		t.Errorf("order after move to front = %s, want DABC", texts)
+	}
+
+	// Move A (now at 1) to the end, with an out-of-range target clamped
+	if err := s.MoveTask("proj", "Move", ws.Plan[0].ID, 99); err != nil {
+		t.Fatalf("MoveTask() error = %v", err)
+	}
+	got, _ = s.Get("proj", "Move")
+	if texts := planTexts(got.Plan); texts != "DBCA" {
+		t.Errorf("order after move to end = %s, want DBCA", texts)
+	}
+
+	// Positional addressing follows the new order
+	id, _ := s.TaskID("proj", "Move", 0)
+	if id != ws.Plan[3].ID {
+		t.Errorf("TaskID(0) = %s, want %s", id, ws.Plan[3].ID)
+	}
+}
+
+func planTexts(plan []workstream.PlanItem) string {
+	var b strings.Builder
+	for _, item := range plan {
+		b.WriteString(item.Text)
+	}
+	return b.String()
+}
+
+func TestSetTaskText(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	s, _ := New(dbPath)
+	defer s.Close()
+
+	s.Create(&workstream.Workstream{Name: "Edit", Project: "proj", State: workstream.StatePending, Plan: []workstream.PlanItem{{Text: "Typo'd tsak"}}})
+	ws, _ := s.Get("proj", "Edit")
+
+	if err := s.SetTaskText("proj", "Edit", ws.Plan[0].ID, "Fixed task"); err != nil {
+		t.Fatalf("SetTaskText() error = %v", err)
+	}
+	got, _ := s.Get("proj", "Edit")
+	if got.Plan[0].Text != "Fixed task" || got.Plan[0].ID != ws.Plan[0].ID {
+		t.Errorf("Plan[0] = %+v, want Fixed task with same ID", got.Plan[0])
+	}
+}
+
+func TestUpdatePlanTaskToggle(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	s, _ := New(dbPath)
+	defer s.Close()
+
+	s.Create(&workstream.Workstream{Name: "Toggle", Project: "proj", State: workstream.StatePending, Plan: []workstream.PlanItem{{Text: "A"}, {Text: "B"}}})
+	ws, _ := s.Get("proj", "Toggle")
+
+	if err := s.Update("proj", "Toggle", WorkstreamUpdate{PlanTask: &ws.Plan[1].ID}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	got, _ := s.Get("proj", "Toggle")
+	if got.Plan[0].Complete || !got.Plan[1].Complete {
+		t.Errorf("Complete = %v/%v, want false/true", got.Plan[0].Complete, got.Plan[1].Complete)
+	}
+
+	if got.Plan[1].Status != workstream.TaskDone {
+		t.Errorf("Plan[1].Status = %q, want done", got.Plan[1].Status)
+	}
+	events, _ := s.EventsOfType("proj", workstream.EventTaskDone)
+	if len(events) != 1 || events[0].Data["task_id"] != ws.Plan[1].ID {
+		t.Errorf("task_done events = %+v, want one for %s", events, ws.Plan[1].ID)
+	}
+
+	// Toggling back reopens the task
+	s.Update("proj", "Toggle", WorkstreamUpdate{PlanTask: &ws.Plan[1].ID})
+	got, _ = s.Get("proj", "Toggle")
+	if got.Plan[1].Complete || got.Plan[1].Status != workstream.TaskPending {
+		t.Errorf("Plan[1] = %v/%q, want false/pending", got.Plan[1].Complete, got.Plan[1].Status)
+	}
+
+	missing := "t99999"
+	if err := s.Update("proj", "Toggle", WorkstreamUpdate{PlanTask: &missing}); err == nil {
+		t.Error("Update() should fail for unknown task ID")
+	}
+	idx := 5
+	if err := s.Update("proj", "Toggle", WorkstreamUpdate{PlanIndex: &idx}); err == nil {
+		t.Error("Update() should fail for a position with no task")
+	}
+}
+
+func TestUpdateToggleRollsUp(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	s, _ := New(dbPath)
+	defer s.Close()
+
+	s.Create(&workstream.Workstream{Name: "Tree", Project: "proj", State: workstream.StatePending, Plan: []workstream.PlanItem{{Text: "Parent"}}})
+	ws, _ := s.Get("proj", "Tree")
+	childID, _ := s.AddSubtask("proj", "Tree", ws.Plan[0].ID, "Only child")
+
+	if err := s.Update("proj", "Tree", WorkstreamUpdate{PlanTask: &childID}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	got, _ := s.Get("proj", "Tree")
+	if got.Plan[0].Status != workstream.TaskDone || !got.Plan[0].Complete {
+		t.Errorf("parent = %q/%v, want done/true after its only subtask is toggled", got.Plan[0].Status, got.Plan[0].Complete)
+	}
+
+	events, _ := s.EventsOfType("proj", workstream.EventTaskDone)
+	if len(events) != 1 || events[0].Data["task_id"] != childID {
+		t.Errorf("task_done events = %+v, want one for %s", events, childID)
+	}
+}
+
+func TestAddSubtask(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	s, _ := New(dbPath)
+	defer s.Close()
+
+	s.Create(&workstream.Workstream{Name: "Tree", Project: "proj", State: workstream.StatePending, Plan: []workstream.PlanItem{{Text: "Parent"}, {Text: "Sibling"}}})
+	ws, _ := s.Get("proj", "Tree")
+	parentID := ws.Plan[0].ID
+
+	childID, err := s.AddSubtask("proj", "Tree", parentID, "Child")
+	if err != nil {
+		t.Fatalf("AddSubtask() error = %v", err)
+	}
+	grandchildID, err := s.AddSubtask("proj", "Tree", childID, "Grandchild")
+	if err != nil {
+		t.Fatalf("AddSubtask() nested error = %v", err)
+	}
+	s.AddSubtask("proj", "Tree", parentID, "Second child")
+
+	got, _ := s.Get("proj", "Tree")
+	if len(got.Plan) != 2 {
+		t.Fatalf("top-level Plan length = %d, want 2 (subtasks must not be top-level)", len(got.Plan))
+	}
+	children := got.Plan[0].Children
+	if len(children) != 2 || children[0].ID != childID || children[1].Text != "Second child" {
+		t.Fatalf("Children = %+v, want Child then Second child", children)
+	}
+	if len(children[0].Children) != 1 || children[0].Children[0].ID != grandchildID {
+		t.Errorf("Grandchildren = %+v, want %s", children[0].Children, grandchildID)
+	}
+
+	// Positions still address top-level tasks only
+	id, _ := s.TaskID("proj", "Tree", 1)
+	if id != got.Plan[1].ID {
+		t.Errorf("TaskID(1) = %s, want sibling %s", id, got.Plan[1].ID)
+	}
+}
+
+func TestCreateWithSubtasks(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	s, _ := New(dbPath)
+	defer s.Close()
+
+	s.Create(&workstream.Workstream{
+		Name: "Tree", Project: "proj", State: workstream.StatePending,
+		Plan: []workstream.PlanItem{
+			{Text: "Parent", Children: []workstream.PlanItem{
+				{Text: "Child", Status: workstream.TaskDone, Notes: "details"},
+			}},
+		},
+	})
+
+	got, _ := s.Get("proj", "Tree")
+	if len(got.Plan) != 1 || len(got.Plan[0].Children) != 1 {
+		t.Fatalf("Plan = %+v, want one parent with one child", got.Plan)
+	}
+	child := got.Plan[0].Children[0]
+	if child.Text != "Child" || child.Status != workstream.TaskDone || child.Notes != "details" {
+		t.Errorf("child = %+v, want Child/done/details", child)
+	}
+}
+
+func TestSubtaskStatusRollUp(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	s, _ := New(dbPath)
+	defer s.Close()
+
+	s.Create(&workstream.Workstream{Name: "Tree", Project: "proj", State: workstream.StatePending, Plan: []workstream.PlanItem{{Text: "Parent"}}})
+	ws, _ := s.Get("proj", "Tree")
+	parentID := ws.Plan[0].ID
+	a, _ := s.AddSubtask("proj", "Tree", parentID, "A")
+	b, _ := s.AddSubtask("proj", "Tree", parentID, "B")
+	b1, _ := s.AddSubtask("proj", "Tree", b, "B1")
+
+	status := func() workstream.TaskStatus {
+		got, _ := s.Get("proj", "Tree")
+		return got.Plan[0].Status
+	}
+
+	s.SetTaskStatusByID("proj", "Tree", a, workstream.TaskDone)
+	if got := status(); got != workstream.TaskInProgress {
+		t.Errorf("parent after one child done = %q, want in_progress", got)
+	}
+
+	// Finishing the grandchild rolls B up to done, which rolls the parent up to done
+	s.SetTaskStatusByID("proj", "Tree", b1, workstream.TaskDone)
+	if got := status(); got != workstream.TaskDone {
+		t.Errorf("parent after all descendants done = %q, want done", got)
+	}
+	got, _ := s.Get("proj", "Tree")
+	if !got.Plan[0].Complete {
+		t.Error("parent Complete = false, want true when rolled up to done")
+	}
+
+	// Adding new work reopens the parent
+	s.AddSubtask("proj", "Tree", parentID, "C")
+	if got := status(); got != workstream.TaskInProgress {
+		t.Errorf("parent after adding subtask = %q, want in_progress", got)
+	}
+}
+
+func TestRemoveTaskRemovesSubtasks(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	s, _ := New(dbPath)
+	defer s.Close()
+
+	s.Create(&workstream.Workstream{Name: "Tree", Project: "proj", State: workstream.StatePending, Plan: []workstream.PlanItem{{Text: "Parent"}}})
+	ws, _ := s.Get("proj", "Tree")
+	parentID := ws.Plan[0].ID
+	a, _ := s.AddSubtask("proj", "Tree", parentID, "A")
+	b, _ := s.AddSubtask("proj", "Tree", parentID, "B")
+	s.AddSubtask("proj", "Tree", a, "A1")
+	s.SetTaskStatusByID("proj", "Tree", b, workstream.TaskDone)
+
+	// Removing the unfinished subtree leaves only done work, so the parent rolls up
+	if err := s.RemoveTaskByID("proj", "Tree", a); err != nil {
+		t.Fatalf("RemoveTaskByID() error = %v", err)
+	}
+	got, _ := s.Get("proj", "Tree")
+	if len(got.Plan[0].Children) != 1 || got.Plan[0].Children[0].ID != b {
+		t.Fatalf("Children = %+v, want only %s", got.Plan[0].Children, b)
+	}
+	if got.Plan[0].Status != workstream.TaskDone {
+		t.Errorf("parent status = %q, want done", got.Plan[0].Status)
+	}
+
+	// Removing the parent removes the whole tree
+	s.RemoveTaskByID("proj", "Tree", parentID)
+	var count int
+	s.db.QueryRow(`SELECT COUNT(*) FROM plan_items`).Scan(&count)
+	if count != 0 {
+		t.Errorf("plan_items rows = %d, want 0 after removing parent", count)
+	}
+}
+
 func TestRemoveDependency(t *testing.T) {
 	dbPath := filepath.Join(t.TempDir(), "test.db")
 	s, _ := New(dbPath)
@@ -486,6 +851,38 @@ func TestRemoveDependency(t *testing.T) {
 	}
 }
 
+func TestDependencies(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	s, _ := New(dbPath)
+	defer s.Close()
+
+	s.Create(&workstream.Workstream{Name: "auth", Project: "proj", State: workstream.StatePending})
+	s.Create(&workstream.Workstream{Name: "api", Project: "proj", State: workstream.StatePending})
+	s.Create(&workstream.Workstream{Name: "sdk", Project: "other", State: workstream.StatePending})
+	s.Create(&workstream.Workstream{Name: "docs", Project: "other", State: workstream.StatePending})
+	s.AddDependency("proj", "auth", "proj", "api")
+	s.AddDependency("proj", "api", "other", "sdk")
+	s.AddDependency("other", "sdk", "other", "docs")
+
+	// Edges leaving or entering the project are included; others are not
+	deps, err := s.Dependencies("proj")
+	if err != nil {
+		t.Fatalf("Dependencies() error = %v", err)
+	}
+	want := []workstream.Dependency{
+		{BlockerProject: "proj", BlockerName: "api", BlockedProject: "other", BlockedName: "sdk"},
+		{BlockerProject: "proj", BlockerName: "auth", BlockedProject: "proj", BlockedName: "api"},
+	}
+	if len(deps) != len(want) {
+		t.Fatalf("Dependencies() = %+v, want %+v", deps, want)
+	}
+	for i := range want {
+		if deps[i] != want[i] {
+			t.Errorf("deps[%d] = %+v, want %+v", i, deps[i], want[i])
+		}
+	}
+}
+
 func TestAddDependency(t *testing.T) {
 	dbPath := filepath.Join(t.TempDir(), "test.db")
 	s, _ := New(dbPath)
@@ -683,6 +1080,254 @@ func TestRecentActivity(t *testing.T) {
 	}
 }
 
+func TestLogEntryTypes(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	s, _ := New(dbPath)
+	defer s.Close()
+
+	s.Create(&workstream.Workstream{
+		Name: "ws1", Project: "proj", State: workstream.StatePending,
+		Log: []workstream.LogEntry{
+			{Timestamp: time.Now().Add(-time.Hour), Content: "Plain entry"},
+			{
+				Timestamp: time.Now().Add(-30 * time.Minute), Content: "Picked Postgres", Type: workstream.EntryDecision,
+				Alternatives: []string{"MySQL\nvia RDS", "SQLite"}, Rationale: "JSONB support",
+			},
+		},
+	})
+
+	question := "Which region?"
+	s.Update("proj", "ws1", WorkstreamUpdate{LogEntry: &question, LogType: workstream.EntryQuestion})
+
+	ws, err := s.Get("proj", "ws1")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if len(ws.Log) != 3 {
+		t.Fatalf("Log len = %d, want 3", len(ws.Log))
+	}
+	if ws.Log[0].Type != workstream.EntryQuestion {
+		t.Errorf("Log[0].Type = %q, want question", ws.Log[0].Type)
+	}
+	decision := ws.Log[1]
+	if decision.Type != workstream.EntryDecision || decision.Rationale != "JSONB support" {
+		t.Errorf("decision = %+v", decision)
+	}
+	if len(decision.Alternatives) != 2 || decision.Alternatives[0] != "MySQL\nvia RDS" {
+		t.Errorf("Alternatives = %q", decision.Alternatives)
+	}
+	if ws.Log[2].Type != workstream.EntryNote {
+		t.Errorf("Untyped entry should default to note, got %q", ws.Log[2].Type)
+	}
+
+	decisions, err := s.Activity(ActivityFilter{Project: "proj", Type: workstream.EntryDecision}, 10, 0)
+	if err != nil {
+		t.Fatalf("Activity() error = %v", err)
+	}
+	if len(decisions) != 1 || decisions[0].Content != "Picked Postgres" {
+		t.Fatalf("Activity(decision) = %+v", decisions)
+	}
+	if len(decisions[0].Alternatives) != 2 || decisions[0].Rationale != "JSONB support" {
+		t.Errorf("Activity should include decision details, got %+v", decisions[0])
+	}
+}
+
+func TestDecodeAlternativesLegacy(t *testing.T) {
+	if got := decodeAlternatives("MySQL\nSQLite"); len(got) != 2 || got[1] != "SQLite" {
+		t.Errorf("decodeAlternatives(newline-separated) = %q", got)
+	}
+	if got := decodeAlternatives(""); got != nil {
+		t.Errorf("decodeAlternatives(\"\") = %q, want nil", got)
+	}
+}
+
+func TestActivityFilterByAuthorAndSession(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	s, _ := New(dbPath)
+	defer s.Close()
+
+	s.Create(&workstream.Workstream{Name: "ws1", Project: "proj", State: workstream.StatePending})
+
+	for _, u := range []WorkstreamUpdate{
+		{LogAuthor: "alice", LogClient: "claude-code/1.0", LogSession: "s1"},
+		{LogClient: "claude-code/1.0", LogSession: "s1"},
+		{LogAuthor: "bob", LogClient: "cursor/0.4", LogSession: "s2"},
+	} {
+		entry := "entry by " + u.LogAuthor + u.LogClient
+		u.LogEntry = &entry
+		if err := s.Update("proj", "ws1", u); err != nil {
+			t.Fatalf("Update() error = %v", err)
+		}
+	}
+
+	ws, _ := s.Get("proj", "ws1")
+	var alice *workstream.LogEntry
+	for i := range ws.Log {
+		if ws.Log[i].Author == "alice" {
+			alice = &ws.Log[i]
+		}
+	}
+	if alice == nil || alice.Client != "claude-code/1.0" || alice.SessionID != "s1" {
+		t.Fatalf("attribution not persisted: %+v", ws.Log)
+	}
+
+	cases := []struct {
+		filter ActivityFilter
+		want   int
+	}{
+		{ActivityFilter{Project: "proj", Author: "alice"}, 1},
+		{ActivityFilter{Project: "proj", Author: "claude-code/1.0"}, 1}, // only the entry without explicit author
+		{ActivityFilter{Project: "proj", Session: "s1"}, 2},
+		{ActivityFilter{Project: "proj", Session: "s2", Author: "bob"}, 1},
+		{ActivityFilter{Project: "proj", Session: "nope"}, 0},
+	}
+	for _, c := range cases {
+		got, err := s.Activity(c.filter, 10, 0)
+		if err != nil {
+			t.Fatalf("Activity(%+v) error = %v", c.filter, err)
+		}
+		if len(got) != c.want {
+			t.Errorf("Activity(%+v) = %d entries, want %d", c.filter, len(got), c.want)
+		}
+	}
+}
+
+func TestActivityFilterByMilestone(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	s, _ := New(dbPath)
+	defer s.Close()
+
+	for _, name := range []string{"auth", "api", "docs"} {
+		s.Create(&workstream.Workstream{Name: name, Project: "proj", State: workstream.StatePending})
+		entry := "work on " + name
+		s.Update("proj", name, WorkstreamUpdate{LogEntry: &entry})
+	}
+	s.CreateMilestone(&workstream.Milestone{Name: "beta", Project: "proj"})
+	s.AddMilestoneRequirement("proj", "beta", "proj", "auth")
+	s.AddMilestoneRequirement("proj", "beta", "proj", "api")
+
+	got, err := s.Activity(ActivityFilter{Project: "proj", Milestone: "beta"}, 10, 0)
+	if err != nil {
+		t.Fatalf("Activity() error = %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("Activity() = %d entries, want 2", len(got))
+	}
+	for _, e := range got {
+		if e.WorkstreamName == "docs" {
+			t.Errorf("docs is not required by beta, but its entry was returned")
+		}
+	}
+}
+
+func TestActivityFilterByWorkstream(t *testing.T) {
+	s, _ := New(filepath.Join(t.TempDir(), "test.db"))
+	defer s.Close()
+
+	for _, name := range []string{"auth", "api"} {
+		s.Create(&workstream.Workstream{Name: name, Project: "proj", State: workstream.StatePending})
+		entry := "work on " + name
+		s.Update("proj", name, WorkstreamUpdate{LogEntry: &entry})
+	}
+
+	got, err := s.Activity(ActivityFilter{Project: "proj", Workstream: "api"}, 10, 0)
+	if err != nil {
+		t.Fatalf("Activity() error = %v", err)
+	}
+	if len(got) != 1 || got[0].WorkstreamName != "api" {
+		t.Errorf("Activity() = %+v, want api's entry only", got)
+	}
+}
+
+func TestActivityAfter(t *testing.T) {
+	s, _ := New(filepath.Join(t.TempDir(), "test.db"))
+	defer s.Close()
+
+	// Entries out of timestamp order still page in ID order
+	now := time.Now().UTC()
+	s.Create(&workstream.Workstream{Name: "ws1", Project: "proj", State: workstream.StatePending, Log: []workstream.LogEntry{
+		{Timestamp: now, Content: "a"},
+		{Timestamp: now.Add(-time.Hour), Content: "b"},
+		{Timestamp: now, Content: "c"},
+	}})
+
+	first, err := s.ActivityAfter(ActivityFilter{Project: "proj"}, 0, 2)
+	if err != nil {
+		t.Fatalf("ActivityAfter() error = %v", err)
+	}
+	if len(first) != 2 || first[0].Content != "a" || first[1].Content != "b" {
+		t.Fatalf("first page = %+v, want a, b", first)
+	}
+	rest, _ := s.ActivityAfter(ActivityFilter{Project: "proj"}, first[1].ID, 2)
+	if len(rest) != 1 || rest[0].Content != "c" {
+		t.Errorf("second page = %+v, want c", rest)
+	}
+}
+
+func TestCompact(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	s, _ := New(dbPath)
+	defer s.Close()
+
+	base := time.Now().UTC().Add(-time.Hour)
+	s.Create(&workstream.Workstream{
+		Name: "ws1", Project: "proj", State: workstream.StateInProgress,
+		Log: []workstream.LogEntry{
+			{Timestamp: base, Content: "first"},
+			{Timestamp: base.Add(10 * time.Minute), Content: "second"},
+			{Timestamp: base.Add(20 * time.Minute), Content: "third"},
+		},
+	})
+
+	n, err := s.Compact("proj", "ws1", "Did first and second.", base.Add(10*time.Minute))
+	if err != nil {
+		t.Fatalf("Compact() error = %v", err)
+	}
+	if n != 2 {
+		t.Errorf("Compact() = %d, want 2", n)
+	}
+
+	ws, _ := s.Get("proj", "ws1")
+	if ws.Summary != "Did first and second." {
+		t.Errorf("Summary = %q", ws.Summary)
+	}
+	if !ws.SummaryThrough.Equal(base.Add(10 * time.Minute)) {
+		t.Errorf("SummaryThrough = %v", ws.SummaryThrough)
+	}
+	if len(ws.Log) != 3 {
+		t.Fatalf("Originals should be kept, got %d entries", len(ws.Log))
+	}
+	if ws.Log[0].Superseded || !ws.Log[1].Superseded || !ws.Log[2].Superseded {
+		t.Errorf("Superseded flags wrong: %+v", ws.Log)
+	}
+
+	// Originals remain searchable
+	results, _ := s.Search("proj", "second", "")
+	if len(results) != 1 {
+		t.Errorf("Superseded entries should still be searchable, got %d", len(results))
+	}
+
+	// An earlier through doesn't move the summary back over superseded entries
+	n, _ = s.Compact("proj", "ws1", "Did first and second, again.", base)
+	ws, _ = s.Get("proj", "ws1")
+	if n != 0 || !ws.SummaryThrough.Equal(base.Add(10*time.Minute)) {
+		t.Errorf("Compact(earlier) = %d, SummaryThrough = %v; want 0, unchanged", n, ws.SummaryThrough)
+	}
+
+	// Compacting everything supersedes only the remaining entry
+	n, _ = s.Compact("proj", "ws1", "All done.", time.Time{})
+	if n != 1 {
+		t.Errorf("second Compact() = %d, want 1", n)
+	}
+
+	if _, err := s.Compact("proj", "ws1", "  ", time.Time{}); err == nil {
+		t.Errorf("Expected error for empty summary")
+	}
+	if _, err := s.Compact("proj", "missing", "x", time.Time{}); err == nil {
+		t.Errorf("Expected error for missing workstream")
+	}
+}
+
 func TestNeedsHelp(t *testing.T) {
 	dbPath := filepath.Join(t.TempDir(), "test.db")
 	s, _ := New(dbPath)
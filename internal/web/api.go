@@ -0,0 +1,302 @@
+package web
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// apiPrefix is where the versioned REST API lives on single- and
+// multi-project servers alike; its paths name the project
+const apiPrefix = "/api/v1"
+
+// Page sizes for list endpoints
+const (
+	apiDefaultLimit = 50
+	apiMaxLimit     = 200
+)
+
+// apiRoute is one REST endpoint. The route table drives both routing and the
+// OpenAPI document, so every endpoint is documented as it is served.
+type apiRoute struct {
+	Method   string
+	Path     string // Under apiPrefix, with {wildcards} as in http.ServeMux
+	Summary  string
+	Write    bool       // Needs write access to the project
+	Query    []apiParam // Query parameters
+	Request  any        // Zero value of the JSON body, or nil for none
+	Response any        // Zero value of the response body, or nil for 204
+	Paged    bool       // Response is an apiPage of Response items
+	Status   int        // On success; defaults to 200
+
+	// etag returns the current version of the resource the route reads or
+	// changes, for ETag and If-Match; nil if it has none
+	etag   func(c *apiCall) (string, error)
+	handle func(c *apiCall) (any, error)
+}
+
+// apiParam is a query parameter
+type apiParam struct {
+	Name        string
+	Description string
+}
+
+var pageParams = []apiParam{
+	{"limit", "Items per page (default 50, at most 200)"},
+	{"cursor", "next_cursor from the previous page"},
+}
+
+// apiPage is one page of a list endpoint
+type apiPage struct {
+	Items      any    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"` // Absent on the last page
+}
+
+// apiError is the body of every API error response
+type apiError struct {
+	Status  int    `json:"-"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *apiError) Error() string { return e.Message }
+
+func errBadRequest(format string, args ...any) *apiError {
+	return &apiError{http.StatusBadRequest, "bad_request", fmt.Sprintf(format, args...)}
+}
+
+func errNotFound(format string, args ...any) *apiError {
+	return &apiError{http.StatusNotFound, "not_found", fmt.Sprintf(format, args...)}
+}
+
+func errForbidden(format string, args ...any) *apiError {
+	return &apiError{http.StatusForbidden, "forbidden", fmt.Sprintf(format, args...)}
+}
+
+func errConflict(format string, args ...any) *apiError {
+	return &apiError{http.StatusConflict, "conflict", fmt.Sprintf(format, args...)}
+}
+
+// storeError maps an error from a store write to an API error. The store
+// reports missing rows by message rather than a sentinel error.
+func storeError(err error) *apiError {
+	var ae *apiError
+	if errors.As(err, &ae) {
+		return ae
+	}
+	if strings.Contains(err.Error(), "not found") {
+		return errNotFound("%s", err)
+	}
+	return errBadRequest("%s", err)
+}
+
+// apiCall is one API request being handled
+type apiCall struct {
+	s       *Server
+	w       http.ResponseWriter
+	r       *http.Request
+	p       *Principal
+	project string
+}
+
+// by is who a write is attributed to: the principal when sign-in is
+// required, otherwise author if the client gave one
+func (c *apiCall) by(author string) string {
+	if author = strings.TrimSpace(author); c.s.authRequired || author == "" {
+		return c.p.Name
+	}
+	return author
+}
+
+// decode reads the JSON request body into v, rejecting unknown fields
+func (c *apiCall) decode(v any) error {
+	dec := json.NewDecoder(c.r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return errBadRequest("invalid request body: %v", err)
+	}
+	return nil
+}
+
+// page returns items[offset:offset+limit] for the request's limit and
+// cursor, with the cursor of the next page
+func page[T any](c *apiCall, items []T) (*apiPage, error) {
+	q := c.r.URL.Query()
+	limit := apiDefaultLimit
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > apiMaxLimit {
+			return nil, errBadRequest("limit must be between 1 and %d", apiMaxLimit)
+		}
+		limit = n
+	}
+	offset := 0
+	if v := q.Get("cursor"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return nil, errBadRequest("invalid cursor")
+		}
+		offset = n
+	}
+
+	pg := &apiPage{Items: []T{}}
+	if offset < len(items) {
+		end := min(offset+limit, len(items))
+		pg.Items = items[offset:end]
+		if end < len(items) {
+			pg.NextCursor = strconv.Itoa(end)
+		}
+	}
+	return pg, nil
+}
+
+// apiRoutes registers the REST API on s.mux
+func (s *Server) apiRoutes() {
+	s.api = http.NewServeMux()
+	for _, route := range s.apiTable() {
+		s.api.HandleFunc(route.Method+" "+apiPrefix+route.Path, func(w http.ResponseWriter, r *http.Request) {
+			s.serveAPIRoute(w, r, route)
+		})
+	}
+	s.api.HandleFunc("GET "+apiPrefix+"/openapi.json", s.handleOpenAPI)
+	s.mux.HandleFunc(apiPrefix+"/", s.serveAPI)
+}
+
+// serveAPI routes an API request, answering unknown paths and methods in
+// JSON rather than with the mux's plain-text errors
+func (s *Server) serveAPI(w http.ResponseWriter, r *http.Request) {
+	if _, pattern := s.api.Handler(r); pattern != "" {
+		s.api.ServeHTTP(w, r)
+		return
+	}
+	probe := &statusRecorder{header: http.Header{}}
+	s.api.ServeHTTP(probe, r)
+	if probe.status == http.StatusMethodNotAllowed {
+		w.Header().Set("Allow", probe.header.Get("Allow"))
+		writeAPIError(w, &apiError{http.StatusMethodNotAllowed, "method_not_allowed", r.Method + " is not supported here"})
+		return
+	}
+	writeAPIError(w, errNotFound("no such endpoint: %s", r.URL.Path))
+}
+
+// statusRecorder notes the status a handler responds with, discarding the body
+type statusRecorder struct {
+	header http.Header
+	status int
+}
+
+func (sr *statusRecorder) Header() http.Header         { return sr.header }
+func (sr *statusRecorder) Write(b []byte) (int, error) { return len(b), nil }
+func (sr *statusRecorder) WriteHeader(status int)      { sr.status = status }
+
+// serveAPIRoute checks access and preconditions, then runs route's handler
+func (s *Server) serveAPIRoute(w http.ResponseWriter, r *http.Request, route apiRoute) {
+	p, ambient := s.authenticate(r)
+	c := &apiCall{s: s, w: w, r: r, p: p, project: r.PathValue("project")}
+
+	// Projects a principal may not read, or that a single-project server
+	// doesn't serve, don't exist as far as the API is concerned
+	if c.project != "" && (!p.CanRead(c.project) || (s.project != "" && c.project != s.project)) {
+		writeAPIError(w, errNotFound("project not found: %s", c.project))
+		return
+	}
+
+	if route.Write {
+		switch {
+		case p == anonymous:
+			writeAPIError(w, &apiError{http.StatusUnauthorized, "unauthorized", "writes need a token or sign-in"})
+			return
+		case !p.CanWrite(c.project):
+			writeAPIError(w, &apiError{http.StatusForbidden, "forbidden", p.Name + " may not change " + c.project})
+			return
+		case ambient && (!sameOrigin(r) || !tokensEqual(r.Header.Get(csrfHeader), s.csrfFor(p))):
+			writeAPIError(w, &apiError{http.StatusForbidden, "forbidden", "missing or invalid CSRF token"})
+			return
+		}
+		// Serialise API writes so If-Match checks and the writes they guard
+		// don't interleave
+		s.apiMu.Lock()
+		defer s.apiMu.Unlock()
+	}
+
+	if route.etag != nil {
+		tag, err := route.etag(c)
+		if err != nil {
+			writeAPIError(w, storeError(err))
+			return
+		}
+		if route.Write {
+			if match := r.Header.Get("If-Match"); match != "" && !etagMatches(match, tag) {
+				writeAPIError(w, &apiError{http.StatusPreconditionFailed, "precondition_failed", "the resource has changed; fetch it again and retry"})
+				return
+			}
+		} else {
+			w.Header().Set("ETag", tag)
+			if match := r.Header.Get("If-None-Match"); match != "" && etagMatches(match, tag) {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+	}
+
+	body, err := route.handle(c)
+	if err != nil {
+		writeAPIError(w, storeError(err))
+		return
+	}
+	if route.Write && route.etag != nil && r.Method != http.MethodDelete && w.Header().Get("ETag") == "" {
+		if tag, err := route.etag(c); err == nil {
+			w.Header().Set("ETag", tag)
+		}
+	}
+
+	status := route.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	if body == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeAPIError(w http.ResponseWriter, e *apiError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(e.Status)
+	json.NewEncoder(w).Encode(map[string]*apiError{"error": e})
+}
+
+// etagOf returns a strong ETag for v's JSON encoding
+func etagOf(v any) string {
+	b, _ := json.Marshal(v)
+	sum := sha256.Sum256(b)
+	return `"` + hex.EncodeToString(sum[:8]) + `"`
+}
+
+// etagMatches reports whether an If-Match or If-None-Match header value
+// matches tag
+func etagMatches(header, tag string) bool {
+	for _, t := range strings.Split(header, ",") {
+		t = strings.TrimPrefix(strings.TrimSpace(t), "W/")
+		if t == "*" || t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// splitRef splits a "project/name" reference, defaulting the project
+func splitRef(ref, project string) (string, string) {
+	if p, n, found := strings.Cut(ref, "/"); found {
+		return p, n
+	}
+	return project, ref
+}
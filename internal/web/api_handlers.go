@@ -0,0 +1,597 @@
+package web
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/faraz/streamctl/internal/store"
+	"github.com/faraz/streamctl/pkg/workstream"
+)
+
+// apiClient is the client recorded against log entries written through the API
+const apiClient = "api"
+
+func (s *Server) workstreamETag(c *apiCall) (string, error) {
+	ws, err := s.apiWorkstream(c)
+	if err != nil {
+		return "", err
+	}
+	return etagOf(ws), nil
+}
+
+func (s *Server) milestoneETag(c *apiCall) (string, error) {
+	m, err := s.apiMilestone(c)
+	if err != nil {
+		return "", err
+	}
+	return etagOf(m), nil
+}
+
+// apiWorkstream loads the workstream named in c's path
+func (s *Server) apiWorkstream(c *apiCall) (*workstream.Workstream, error) {
+	name := c.r.PathValue("name")
+	ws, err := s.store.Get(c.project, name)
+	if err != nil {
+		return nil, errNotFound("workstream not found: %s/%s", c.project, name)
+	}
+	return ws, nil
+}
+
+// apiMilestone loads the milestone named in c's path
+func (s *Server) apiMilestone(c *apiCall) (*workstream.Milestone, error) {
+	name := c.r.PathValue("milestone")
+	m, err := s.store.GetMilestone(c.project, name)
+	if err != nil {
+		return nil, errNotFound("milestone not found: %s/%s", c.project, name)
+	}
+	return m, nil
+}
+
+// readableRef resolves a NAME or PROJECT/NAME reference from c's project,
+// hiding projects c's principal may not read
+func (c *apiCall) readableRef(ref string) (string, string, error) {
+	project, name := splitRef(strings.TrimSpace(ref), c.project)
+	if name == "" {
+		return "", "", errBadRequest("workstream reference is empty")
+	}
+	if !c.p.CanRead(project) {
+		return "", "", errNotFound("workstream not found: %s/%s", project, name)
+	}
+	return project, name, nil
+}
+
+// linkableRef is readableRef for a workstream the call's project will be
+// linked to, which in another project needs write access there and must be
+// within the server's link scope
+func (c *apiCall) linkableRef(ref string) (string, string, error) {
+	project, name, err := c.readableRef(ref)
+	if err != nil {
+		return "", "", err
+	}
+	if project != c.project {
+		if err := c.s.checkLink(c.p, project); err != nil {
+			return "", "", errForbidden("%s", err)
+		}
+	}
+	return project, name, nil
+}
+
+func parseState(s string) (workstream.State, error) {
+	switch state := workstream.State(s); state {
+	case workstream.StatePending, workstream.StateInProgress, workstream.StateBlocked, workstream.StateDone:
+		return state, nil
+	}
+	return "", errBadRequest("invalid state: %q", s)
+}
+
+func parseTaskStatus(s string) (workstream.TaskStatus, error) {
+	switch status := workstream.TaskStatus(s); status {
+	case workstream.TaskPending, workstream.TaskInProgress, workstream.TaskDone, workstream.TaskSkipped:
+		return status, nil
+	}
+	return "", errBadRequest("invalid task status: %q", s)
+}
+
+// findTask returns the task with id anywhere in items
+func findTask(items []apiTask, id string) *apiTask {
+	for i := range items {
+		if items[i].ID == id {
+			return &items[i]
+		}
+		if t := findTask(items[i].Subtasks, id); t != nil {
+			return t
+		}
+	}
+	return nil
+}
+
+func (s *Server) apiListProjects(c *apiCall) (any, error) {
+	projects, err := s.store.ListProjects()
+	if err != nil {
+		return nil, err
+	}
+	items := []apiProject{}
+	for _, p := range projects {
+		if c.p.CanRead(p) && (s.project == "" || p == s.project) {
+			items = append(items, apiProject{p})
+		}
+	}
+	return page(c, items)
+}
+
+func (s *Server) apiListWorkstreams(c *apiCall) (any, error) {
+	q := c.r.URL.Query()
+	filter := store.Filter{Project: c.project, Owner: q.Get("owner")}
+	if state := q.Get("state"); state != "" {
+		var err error
+		if filter.State, err = parseState(state); err != nil {
+			return nil, err
+		}
+	}
+	workstreams, err := s.store.List(filter)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]apiWorkstreamSummary, len(workstreams))
+	for i, ws := range workstreams {
+		items[i] = newAPIWorkstreamSummary(ws)
+	}
+	return page(c, items)
+}
+
+func (s *Server) apiCreateWorkstream(c *apiCall) (any, error) {
+	var body apiCreateWorkstream
+	if err := c.decode(&body); err != nil {
+		return nil, err
+	}
+	body.Name = strings.TrimSpace(body.Name)
+	if body.Name == "" || body.Objective == "" {
+		return nil, errBadRequest("name and objective are required")
+	}
+	if strings.Contains(body.Name, "/") {
+		return nil, errBadRequest("name may not contain /")
+	}
+	state := workstream.StatePending
+	if body.State != "" {
+		var err error
+		if state, err = parseState(body.State); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := s.store.Get(c.project, body.Name); err == nil {
+		return nil, errConflict("workstream already exists: %s/%s", c.project, body.Name)
+	}
+
+	ws := &workstream.Workstream{
+		Project:    c.project,
+		Name:       body.Name,
+		State:      state,
+		Owner:      body.Owner,
+		Objective:  body.Objective,
+		LastUpdate: time.Now().UTC().Truncate(time.Minute),
+	}
+	for _, text := range body.Tasks {
+		ws.Plan = append(ws.Plan, workstream.PlanItem{Text: text, Status: workstream.TaskPending})
+	}
+	if err := s.store.Create(ws); err != nil {
+		return nil, err
+	}
+	s.audit(c.p, c.project, ws.Name, "create", "created via the API")
+
+	created, err := s.store.Get(c.project, ws.Name)
+	if err != nil {
+		return nil, err
+	}
+	c.w.Header().Set("ETag", etagOf(created))
+	c.w.Header().Set("Location", apiPrefix+"/projects/"+url.PathEscape(c.project)+"/workstreams/"+url.PathEscape(ws.Name))
+	return newAPIWorkstream(created), nil
+}
+
+func (s *Server) apiGetWorkstream(c *apiCall) (any, error) {
+	ws, err := s.apiWorkstream(c)
+	if err != nil {
+		return nil, err
+	}
+	return newAPIWorkstream(ws), nil
+}
+
+func (s *Server) apiUpdateWorkstream(c *apiCall) (any, error) {
+	var body apiUpdateWorkstream
+	if err := c.decode(&body); err != nil {
+		return nil, err
+	}
+	name := c.r.PathValue("name")
+
+	var update store.WorkstreamUpdate
+	var changes []string
+	if body.State != nil {
+		state, err := parseState(*body.State)
+		if err != nil {
+			return nil, err
+		}
+		update.State = &state
+		changes = append(changes, "state set to "+*body.State)
+	}
+	if body.Owner != nil {
+		update.Owner = body.Owner
+		changes = append(changes, "owner set to "+*body.Owner)
+	}
+	if body.NeedsHelp != nil {
+		update.NeedsHelp = body.NeedsHelp
+		changes = append(changes, "needs help set")
+	}
+	if body.Name != nil {
+		newName := strings.TrimSpace(*body.Name)
+		if newName == "" || strings.Contains(newName, "/") {
+			return nil, errBadRequest("name must be non-empty and may not contain /")
+		}
+		if newName != name {
+			if _, err := s.store.Get(c.project, newName); err == nil {
+				return nil, errConflict("workstream already exists: %s/%s", c.project, newName)
+			}
+		}
+	}
+
+	if err := s.store.Update(c.project, name, update); err != nil {
+		return nil, err
+	}
+	if body.HelpQuestion != nil && *body.HelpQuestion != "" {
+		if _, err := s.store.RequestHelp(c.project, name, *body.HelpQuestion, c.by(body.Author)); err != nil {
+			return nil, err
+		}
+		changes = append(changes, "asked for help")
+	}
+	if body.Name != nil && strings.TrimSpace(*body.Name) != name {
+		newName := strings.TrimSpace(*body.Name)
+		if err := s.store.Rename(c.project, name, newName); err != nil {
+			return nil, err
+		}
+		changes = append(changes, "renamed to "+newName)
+		name = newName
+	}
+	s.audit(c.p, c.project, name, "update", strings.Join(changes, ", "))
+
+	ws, err := s.store.Get(c.project, name)
+	if err != nil {
+		return nil, err
+	}
+	c.w.Header().Set("ETag", etagOf(ws))
+	return newAPIWorkstream(ws), nil
+}
+
+func (s *Server) apiDeleteWorkstream(c *apiCall) (any, error) {
+	name := c.r.PathValue("name")
+	if err := s.store.Delete(c.project, name); err != nil {
+		return nil, err
+	}
+	s.audit(c.p, c.project, name, "delete", "deleted via the API")
+	return nil, nil
+}
+
+func (s *Server) apiListTasks(c *apiCall) (any, error) {
+	ws, err := s.apiWorkstream(c)
+	if err != nil {
+		return nil, err
+	}
+	return apiTaskList{Items: newAPITasks(ws.Plan)}, nil
+}
+
+func (s *Server) apiCreateTask(c *apiCall) (any, error) {
+	var body apiCreateTask
+	if err := c.decode(&body); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(body.Text) == "" {
+		return nil, errBadRequest("text is required")
+	}
+	name := c.r.PathValue("name")
+
+	var id string
+	var err error
+	if body.Parent != "" {
+		id, err = s.store.AddSubtask(c.project, name, body.Parent, body.Text)
+	} else {
+		id, err = s.store.AddTask(c.project, name, body.Text)
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.audit(c.p, c.project, name, "task_add", "added task "+id)
+	return s.apiTaskResult(c, id)
+}
+
+// apiTaskResult returns task id as it now stands
+func (s *Server) apiTaskResult(c *apiCall, id string) (any, error) {
+	ws, err := s.apiWorkstream(c)
+	if err != nil {
+		return nil, err
+	}
+	t := findTask(newAPITasks(ws.Plan), id)
+	if t == nil {
+		return nil, errNotFound("task not found: %s", id)
+	}
+	return t, nil
+}
+
+func (s *Server) apiUpdateTask(c *apiCall) (any, error) {
+	var body apiUpdateTask
+	if err := c.decode(&body); err != nil {
+		return nil, err
+	}
+	name, task := c.r.PathValue("name"), c.r.PathValue("task")
+
+	var changes []string
+	if body.Text != nil {
+		if strings.TrimSpace(*body.Text) == "" {
+			return nil, errBadRequest("text may not be empty")
+		}
+		if err := s.store.SetTaskText(c.project, name, task, *body.Text); err != nil {
+			return nil, err
+		}
+		changes = append(changes, "text updated")
+	}
+	if body.Status != nil {
+		status, err := parseTaskStatus(*body.Status)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.store.SetTaskStatusByID(c.project, name, task, status); err != nil {
+			return nil, err
+		}
+		changes = append(changes, "marked "+*body.Status)
+	}
+	if body.Notes != nil {
+		if err := s.store.SetTaskNotesByID(c.project, name, task, *body.Notes); err != nil {
+			return nil, err
+		}
+		changes = append(changes, "notes updated")
+	}
+	if body.Position != nil {
+		if err := s.store.MoveTask(c.project, name, task, *body.Position); err != nil {
+			return nil, err
+		}
+		changes = append(changes, "moved")
+	}
+	s.audit(c.p, c.project, name, "task_update", task+" "+strings.Join(changes, ", "))
+	return s.apiTaskResult(c, task)
+}
+
+func (s *Server) apiDeleteTask(c *apiCall) (any, error) {
+	name, task := c.r.PathValue("name"), c.r.PathValue("task")
+	if err := s.store.RemoveTaskByID(c.project, name, task); err != nil {
+		return nil, err
+	}
+	s.audit(c.p, c.project, name, "task_remove", "removed task "+task)
+	return nil, nil
+}
+
+func (s *Server) apiListLogs(c *apiCall) (any, error) {
+	ws, err := s.apiWorkstream(c)
+	if err != nil {
+		return nil, err
+	}
+	var entryType workstream.EntryType
+	if t := c.r.URL.Query().Get("type"); t != "" {
+		if entryType, err = workstream.ParseEntryType(t); err != nil {
+			return nil, errBadRequest("%s", err)
+		}
+	}
+	items := []apiLogEntry{}
+	for _, e := range ws.Log {
+		if entryType == "" || e.Type == entryType {
+			items = append(items, newAPILogEntry(e))
+		}
+	}
+	return page(c, items)
+}
+
+func (s *Server) apiCreateLog(c *apiCall) (any, error) {
+	var body apiCreateLog
+	if err := c.decode(&body); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(body.Content) == "" {
+		return nil, errBadRequest("content is required")
+	}
+	logType, err := workstream.ParseEntryType(body.Type)
+	if err != nil {
+		return nil, errBadRequest("%s", err)
+	}
+	name := c.r.PathValue("name")
+
+	err = s.store.Update(c.project, name, store.WorkstreamUpdate{
+		LogEntry:        &body.Content,
+		LogType:         logType,
+		LogAlternatives: body.Alternatives,
+		LogRationale:    body.Rationale,
+		LogAuthor:       c.by(body.Author),
+		LogClient:       apiClient,
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.audit(c.p, c.project, name, "log", "logged "+string(logType))
+
+	ws, err := s.apiWorkstream(c)
+	if err != nil {
+		return nil, err
+	}
+	// The log is newest first
+	return newAPILogEntry(ws.Log[0]), nil
+}
+
+func (s *Server) apiListDependencies(c *apiCall) (any, error) {
+	ws, err := s.apiWorkstream(c)
+	if err != nil {
+		return nil, err
+	}
+	return newAPIDependencies(ws), nil
+}
+
+func (s *Server) apiCreateDependency(c *apiCall) (any, error) {
+	var body apiCreateDependency
+	if err := c.decode(&body); err != nil {
+		return nil, err
+	}
+	project, blocker, err := c.linkableRef(body.Blocker)
+	if err != nil {
+		return nil, err
+	}
+	name := c.r.PathValue("name")
+	ws, err := s.apiWorkstream(c)
+	if err != nil {
+		return nil, err
+	}
+	if slices.ContainsFunc(ws.BlockedBy, func(d workstream.Dependency) bool {
+		return d.BlockerProject == project && d.BlockerName == blocker
+	}) {
+		return nil, errConflict("already blocked by %s/%s", project, blocker)
+	}
+	if err := s.store.AddDependency(project, blocker, c.project, name); err != nil {
+		return nil, err
+	}
+	s.audit(c.p, c.project, name, "blocker_add", "blocked by "+project+"/"+blocker)
+	return s.apiListDependencies(c)
+}
+
+func (s *Server) apiDeleteDependency(c *apiCall) (any, error) {
+	project, blocker, err := c.linkableRef(c.r.PathValue("blocker_project") + "/" + c.r.PathValue("blocker"))
+	if err != nil {
+		return nil, err
+	}
+	name := c.r.PathValue("name")
+	ws, err := s.apiWorkstream(c)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.ContainsFunc(ws.BlockedBy, func(d workstream.Dependency) bool {
+		return d.BlockerProject == project && d.BlockerName == blocker
+	}) {
+		return nil, errNotFound("%s/%s does not block %s/%s", project, blocker, c.project, name)
+	}
+	if err := s.store.RemoveDependency(project, blocker, c.project, name); err != nil {
+		return nil, err
+	}
+	s.audit(c.p, c.project, name, "blocker_remove", "no longer blocked by "+project+"/"+blocker)
+	return nil, nil
+}
+
+func (s *Server) apiListMilestones(c *apiCall) (any, error) {
+	milestones, err := s.store.ListMilestones(c.project)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]apiMilestone, len(milestones))
+	for i := range milestones {
+		items[i] = newAPIMilestone(&milestones[i])
+	}
+	return page(c, items)
+}
+
+func (s *Server) apiCreateMilestone(c *apiCall) (any, error) {
+	var body apiCreateMilestone
+	if err := c.decode(&body); err != nil {
+		return nil, err
+	}
+	body.Name = strings.TrimSpace(body.Name)
+	if body.Name == "" {
+		return nil, errBadRequest("name is required")
+	}
+	if _, err := s.store.GetMilestone(c.project, body.Name); err == nil {
+		return nil, errConflict("milestone already exists: %s/%s", c.project, body.Name)
+	}
+	// Check every requirement first, so a bad one doesn't leave a half-made milestone
+	var refs []apiRef
+	for _, ref := range body.Requirements {
+		project, name, err := c.linkableRef(ref)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := s.store.Get(project, name); err != nil {
+			return nil, errNotFound("workstream not found: %s/%s", project, name)
+		}
+		refs = append(refs, apiRef{project, name})
+	}
+
+	if err := s.store.CreateMilestone(&workstream.Milestone{Project: c.project, Name: body.Name, Description: body.Description}); err != nil {
+		return nil, err
+	}
+	for _, ref := range refs {
+		if err := s.store.AddMilestoneRequirement(c.project, body.Name, ref.Project, ref.Name); err != nil {
+			return nil, err
+		}
+	}
+	s.audit(c.p, c.project, "", "milestone_create", "created milestone "+body.Name)
+
+	m, err := s.store.GetMilestone(c.project, body.Name)
+	if err != nil {
+		return nil, err
+	}
+	c.w.Header().Set("ETag", etagOf(m))
+	c.w.Header().Set("Location", apiPrefix+"/projects/"+url.PathEscape(c.project)+"/milestones/"+url.PathEscape(m.Name))
+	return newAPIMilestone(m), nil
+}
+
+func (s *Server) apiGetMilestone(c *apiCall) (any, error) {
+	m, err := s.apiMilestone(c)
+	if err != nil {
+		return nil, err
+	}
+	return newAPIMilestone(m), nil
+}
+
+func (s *Server) apiUpdateMilestone(c *apiCall) (any, error) {
+	var body apiUpdateMilestone
+	if err := c.decode(&body); err != nil {
+		return nil, err
+	}
+	name := c.r.PathValue("milestone")
+	if body.Description != nil {
+		if err := s.store.UpdateMilestoneDescription(c.project, name, *body.Description); err != nil {
+			return nil, err
+		}
+		s.audit(c.p, c.project, "", "milestone_update", "updated milestone "+name)
+	}
+	return s.apiGetMilestone(c)
+}
+
+func (s *Server) apiDeleteMilestone(c *apiCall) (any, error) {
+	name := c.r.PathValue("milestone")
+	if err := s.store.DeleteMilestone(c.project, name); err != nil {
+		return nil, err
+	}
+	s.audit(c.p, c.project, "", "milestone_delete", "deleted milestone "+name)
+	return nil, nil
+}
+
+func (s *Server) apiCreateRequirement(c *apiCall) (any, error) {
+	var body apiCreateRequirement
+	if err := c.decode(&body); err != nil {
+		return nil, err
+	}
+	project, ws, err := c.linkableRef(body.Workstream)
+	if err != nil {
+		return nil, err
+	}
+	name := c.r.PathValue("milestone")
+	if err := s.store.AddMilestoneRequirement(c.project, name, project, ws); err != nil {
+		return nil, err
+	}
+	s.audit(c.p, c.project, ws, "milestone_require", "required by milestone "+name)
+	return s.apiGetMilestone(c)
+}
+
+func (s *Server) apiDeleteRequirement(c *apiCall) (any, error) {
+	project, ws, err := c.linkableRef(c.r.PathValue("ws_project") + "/" + c.r.PathValue("ws"))
+	if err != nil {
+		return nil, err
+	}
+	name := c.r.PathValue("milestone")
+	if err := s.store.RemoveMilestoneRequirement(c.project, name, project, ws); err != nil {
+		return nil, err
+	}
+	s.audit(c.p, c.project, ws, "milestone_unrequire", "no longer required by milestone "+name)
+	return nil, nil
+}
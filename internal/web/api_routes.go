@@ -0,0 +1,298 @@
+package web
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/faraz/streamctl/pkg/workstream"
+)
+
+// apiTable lists the REST API's endpoints, mirroring the MCP tools
+func (s *Server) apiTable() []apiRoute {
+	ws := func(c *apiCall) (string, error) { return s.workstreamETag(c) }
+	ms := func(c *apiCall) (string, error) { return s.milestoneETag(c) }
+
+	return []apiRoute{
+		{Method: "GET", Path: "/projects", Summary: "List projects",
+			Query: pageParams, Response: apiProject{}, Paged: true, handle: s.apiListProjects},
+
+		{Method: "GET", Path: "/projects/{project}/workstreams", Summary: "List workstreams",
+			Query:    append([]apiParam{{"state", "Only workstreams in this state"}, {"owner", "Only workstreams claimed by this owner"}}, pageParams...),
+			Response: apiWorkstreamSummary{}, Paged: true, handle: s.apiListWorkstreams},
+		{Method: "POST", Path: "/projects/{project}/workstreams", Summary: "Create a workstream", Write: true,
+			Request: apiCreateWorkstream{}, Response: apiWorkstream{}, Status: http.StatusCreated, handle: s.apiCreateWorkstream},
+		{Method: "GET", Path: "/projects/{project}/workstreams/{name}", Summary: "Get a workstream",
+			Response: apiWorkstream{}, etag: ws, handle: s.apiGetWorkstream},
+		{Method: "PATCH", Path: "/projects/{project}/workstreams/{name}", Summary: "Update a workstream's state, owner, help flag or name", Write: true,
+			Request: apiUpdateWorkstream{}, Response: apiWorkstream{}, etag: ws, handle: s.apiUpdateWorkstream},
+		{Method: "DELETE", Path: "/projects/{project}/workstreams/{name}", Summary: "Delete a workstream", Write: true,
+			etag: ws, handle: s.apiDeleteWorkstream},
+
+		{Method: "GET", Path: "/projects/{project}/workstreams/{name}/tasks", Summary: "List a workstream's tasks as a tree",
+			Response: apiTaskList{}, etag: ws, handle: s.apiListTasks},
+		{Method: "POST", Path: "/projects/{project}/workstreams/{name}/tasks", Summary: "Add a task or subtask", Write: true,
+			Request: apiCreateTask{}, Response: apiTask{}, Status: http.StatusCreated, etag: ws, handle: s.apiCreateTask},
+		{Method: "PATCH", Path: "/projects/{project}/workstreams/{name}/tasks/{task}", Summary: "Update a task's text, status, notes or position", Write: true,
+			Request: apiUpdateTask{}, Response: apiTask{}, etag: ws, handle: s.apiUpdateTask},
+		{Method: "DELETE", Path: "/projects/{project}/workstreams/{name}/tasks/{task}", Summary: "Remove a task and its subtasks", Write: true,
+			etag: ws, handle: s.apiDeleteTask},
+
+		{Method: "GET", Path: "/projects/{project}/workstreams/{name}/logs", Summary: "List a workstream's log entries, newest first",
+			Query:    append([]apiParam{{"type", "Only entries of this type"}}, pageParams...),
+			Response: apiLogEntry{}, Paged: true, etag: ws, handle: s.apiListLogs},
+		{Method: "POST", Path: "/projects/{project}/workstreams/{name}/logs", Summary: "Append a log entry", Write: true,
+			Request: apiCreateLog{}, Response: apiLogEntry{}, Status: http.StatusCreated, etag: ws, handle: s.apiCreateLog},
+
+		{Method: "GET", Path: "/projects/{project}/workstreams/{name}/dependencies", Summary: "List what blocks a workstream and what it blocks",
+			Response: apiDependencies{}, etag: ws, handle: s.apiListDependencies},
+		{Method: "POST", Path: "/projects/{project}/workstreams/{name}/dependencies", Summary: "Add a blocker", Write: true,
+			Request: apiCreateDependency{}, Response: apiDependencies{}, Status: http.StatusCreated, etag: ws, handle: s.apiCreateDependency},
+		{Method: "DELETE", Path: "/projects/{project}/workstreams/{name}/dependencies/{blocker_project}/{blocker}", Summary: "Remove a blocker", Write: true,
+			etag: ws, handle: s.apiDeleteDependency},
+
+		{Method: "GET", Path: "/projects/{project}/milestones", Summary: "List milestones",
+			Query: pageParams, Response: apiMilestone{}, Paged: true, handle: s.apiListMilestones},
+		{Method: "POST", Path: "/projects/{project}/milestones", Summary: "Create a milestone", Write: true,
+			Request: apiCreateMilestone{}, Response: apiMilestone{}, Status: http.StatusCreated, handle: s.apiCreateMilestone},
+		{Method: "GET", Path: "/projects/{project}/milestones/{milestone}", Summary: "Get a milestone",
+			Response: apiMilestone{}, etag: ms, handle: s.apiGetMilestone},
+		{Method: "PATCH", Path: "/projects/{project}/milestones/{milestone}", Summary: "Update a milestone's description", Write: true,
+			Request: apiUpdateMilestone{}, Response: apiMilestone{}, etag: ms, handle: s.apiUpdateMilestone},
+		{Method: "DELETE", Path: "/projects/{project}/milestones/{milestone}", Summary: "Delete a milestone (its workstreams are kept)", Write: true,
+			etag: ms, handle: s.apiDeleteMilestone},
+		{Method: "POST", Path: "/projects/{project}/milestones/{milestone}/requirements", Summary: "Require a workstream for a milestone", Write: true,
+			Request: apiCreateRequirement{}, Response: apiMilestone{}, Status: http.StatusCreated, etag: ms, handle: s.apiCreateRequirement},
+		{Method: "DELETE", Path: "/projects/{project}/milestones/{milestone}/requirements/{ws_project}/{ws}", Summary: "Stop requiring a workstream", Write: true,
+			etag: ms, handle: s.apiDeleteRequirement},
+	}
+}
+
+// Resources the API returns
+
+type apiProject struct {
+	Name string `json:"name"`
+}
+
+type apiWorkstreamSummary struct {
+	Project    string    `json:"project"`
+	Name       string    `json:"name"`
+	State      string    `json:"state"`
+	Owner      string    `json:"owner,omitempty"`
+	NeedsHelp  bool      `json:"needs_help"`
+	Objective  string    `json:"objective"`
+	LastUpdate time.Time `json:"last_update"`
+	TasksDone  int       `json:"tasks_done"`
+	TasksTotal int       `json:"tasks_total"`
+}
+
+type apiWorkstream struct {
+	Project        string     `json:"project"`
+	Name           string     `json:"name"`
+	State          string     `json:"state"`
+	Owner          string     `json:"owner,omitempty"`
+	NeedsHelp      bool       `json:"needs_help"`
+	HelpQuestion   string     `json:"help_question,omitempty"`
+	Objective      string     `json:"objective"`
+	LastUpdate     time.Time  `json:"last_update"`
+	Summary        string     `json:"summary,omitempty"`
+	SummaryThrough *time.Time `json:"summary_through,omitempty"`
+	Tasks          []apiTask  `json:"tasks"`
+	BlockedBy      []apiRef   `json:"blocked_by"`
+	Blocks         []apiRef   `json:"blocks"`
+	LogCount       int        `json:"log_count"` // Entries are at /logs
+}
+
+type apiTask struct {
+	ID       string    `json:"id"`
+	Text     string    `json:"text"`
+	Status   string    `json:"status"`
+	Notes    string    `json:"notes,omitempty"`
+	Subtasks []apiTask `json:"subtasks,omitempty"`
+}
+
+type apiTaskList struct {
+	Items []apiTask `json:"items"`
+}
+
+type apiLogEntry struct {
+	Timestamp    time.Time `json:"timestamp"`
+	Content      string    `json:"content"`
+	Type         string    `json:"type"`
+	Alternatives []string  `json:"alternatives,omitempty"`
+	Rationale    string    `json:"rationale,omitempty"`
+	Author       string    `json:"author,omitempty"`
+	Client       string    `json:"client,omitempty"`
+	SessionID    string    `json:"session_id,omitempty"`
+	Superseded   bool      `json:"superseded"` // Covered by the workstream's summary
+}
+
+// apiRef names a workstream, possibly in another project
+type apiRef struct {
+	Project string `json:"project"`
+	Name    string `json:"name"`
+}
+
+type apiDependencies struct {
+	BlockedBy []apiRef `json:"blocked_by"`
+	Blocks    []apiRef `json:"blocks"`
+}
+
+type apiMilestone struct {
+	Project      string           `json:"project"`
+	Name         string           `json:"name"`
+	Description  string           `json:"description,omitempty"`
+	Status       string           `json:"status"`
+	CreatedAt    time.Time        `json:"created_at"`
+	Requirements []apiRequirement `json:"requirements"`
+}
+
+type apiRequirement struct {
+	Project string `json:"project"`
+	Name    string `json:"name"`
+	State   string `json:"state"`
+}
+
+// Request bodies. Optional fields are pointers or omitempty.
+
+type apiCreateWorkstream struct {
+	Name      string   `json:"name"`
+	Objective string   `json:"objective"`
+	State     string   `json:"state,omitempty"` // Defaults to pending
+	Owner     string   `json:"owner,omitempty"`
+	Tasks     []string `json:"tasks,omitempty"`
+}
+
+type apiUpdateWorkstream struct {
+	Name         *string `json:"name,omitempty"` // Renames the workstream
+	State        *string `json:"state,omitempty"`
+	Owner        *string `json:"owner,omitempty"` // "" releases the claim
+	NeedsHelp    *bool   `json:"needs_help,omitempty"`
+	HelpQuestion *string `json:"help_question,omitempty"` // Asks for help, setting needs_help
+	Author       string  `json:"author,omitempty"`        // Who asks, when sign-in isn't required
+}
+
+type apiCreateTask struct {
+	Text   string `json:"text"`
+	Parent string `json:"parent,omitempty"` // Task ID, to add a subtask
+}
+
+type apiUpdateTask struct {
+	Text     *string `json:"text,omitempty"`
+	Status   *string `json:"status,omitempty"`
+	Notes    *string `json:"notes,omitempty"`
+	Position *int    `json:"position,omitempty"` // Among its siblings, from 0
+}
+
+type apiCreateLog struct {
+	Content      string   `json:"content"`
+	Type         string   `json:"type,omitempty"` // Defaults to note
+	Alternatives []string `json:"alternatives,omitempty"`
+	Rationale    string   `json:"rationale,omitempty"`
+	Author       string   `json:"author,omitempty"` // When sign-in isn't required
+}
+
+type apiCreateDependency struct {
+	Blocker string `json:"blocker"` // NAME, or PROJECT/NAME in another project
+}
+
+type apiCreateMilestone struct {
+	Name         string   `json:"name"`
+	Description  string   `json:"description,omitempty"`
+	Requirements []string `json:"requirements,omitempty"` // NAME or PROJECT/NAME
+}
+
+type apiUpdateMilestone struct {
+	Description *string `json:"description,omitempty"`
+}
+
+type apiCreateRequirement struct {
+	Workstream string `json:"workstream"` // NAME, or PROJECT/NAME in another project
+}
+
+func newAPIWorkstreamSummary(ws workstream.Workstream) apiWorkstreamSummary {
+	done, total := ws.TaskProgress()
+	return apiWorkstreamSummary{
+		Project:    ws.Project,
+		Name:       ws.Name,
+		State:      string(ws.State),
+		Owner:      ws.Owner,
+		NeedsHelp:  ws.NeedsHelp,
+		Objective:  ws.Objective,
+		LastUpdate: ws.LastUpdate,
+		TasksDone:  done,
+		TasksTotal: total,
+	}
+}
+
+func newAPIWorkstream(ws *workstream.Workstream) apiWorkstream {
+	out := apiWorkstream{
+		Project:      ws.Project,
+		Name:         ws.Name,
+		State:        string(ws.State),
+		Owner:        ws.Owner,
+		NeedsHelp:    ws.NeedsHelp,
+		HelpQuestion: ws.HelpQuestion,
+		Objective:    ws.Objective,
+		LastUpdate:   ws.LastUpdate,
+		Summary:      ws.Summary,
+		Tasks:        newAPITasks(ws.Plan),
+		LogCount:     len(ws.Log),
+	}
+	if !ws.SummaryThrough.IsZero() {
+		out.SummaryThrough = &ws.SummaryThrough
+	}
+	deps := newAPIDependencies(ws)
+	out.BlockedBy, out.Blocks = deps.BlockedBy, deps.Blocks
+	return out
+}
+
+func newAPITasks(items []workstream.PlanItem) []apiTask {
+	tasks := make([]apiTask, len(items))
+	for i, item := range items {
+		tasks[i] = apiTask{ID: item.ID, Text: item.Text, Status: string(item.Status), Notes: item.Notes}
+		if len(item.Children) > 0 {
+			tasks[i].Subtasks = newAPITasks(item.Children)
+		}
+	}
+	return tasks
+}
+
+func newAPILogEntry(e workstream.LogEntry) apiLogEntry {
+	return apiLogEntry{
+		Timestamp:    e.Timestamp,
+		Content:      e.Content,
+		Type:         string(e.Type),
+		Alternatives: e.Alternatives,
+		Rationale:    e.Rationale,
+		Author:       e.Author,
+		Client:       e.Client,
+		SessionID:    e.SessionID,
+		Superseded:   e.Superseded,
+	}
+}
+
+func newAPIDependencies(ws *workstream.Workstream) apiDependencies {
+	deps := apiDependencies{BlockedBy: []apiRef{}, Blocks: []apiRef{}}
+	for _, d := range ws.BlockedBy {
+		deps.BlockedBy = append(deps.BlockedBy, apiRef{d.BlockerProject, d.BlockerName})
+	}
+	for _, d := range ws.Blocks {
+		deps.Blocks = append(deps.Blocks, apiRef{d.BlockedProject, d.BlockedName})
+	}
+	return deps
+}
+
+func newAPIMilestone(m *workstream.Milestone) apiMilestone {
+	out := apiMilestone{
+		Project:      m.Project,
+		Name:         m.Name,
+		Description:  m.Description,
+		Status:       string(m.Status),
+		CreatedAt:    m.CreatedAt,
+		Requirements: []apiRequirement{},
+	}
+	for _, r := range m.Requirements {
+		out.Requirements = append(out.Requirements, apiRequirement{r.WorkstreamProject, r.WorkstreamName, string(r.WorkstreamState)})
+	}
+	return out
+}
@@ -0,0 +1,378 @@
+package web
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/faraz/streamctl/pkg/workstream"
+)
+
+// apiRequest sends a JSON request as a bearer token holder (none if token
+// is empty) and decodes the response into out, if given
+func apiRequest(t *testing.T, srv http.Handler, method, path, token, body string, header map[string]string, out any) *httptest.ResponseRecorder {
+	t.Helper()
+	var r io.Reader
+	if body != "" {
+		r = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, path, r)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if out != nil && w.Body.Len() > 0 {
+		if err := json.Unmarshal(w.Body.Bytes(), out); err != nil {
+			t.Fatalf("%s %s: decoding %q: %v", method, path, w.Body.String(), err)
+		}
+	}
+	return w
+}
+
+type apiErrorBody struct {
+	Error apiError `json:"error"`
+}
+
+func TestAPI_Workstreams(t *testing.T) {
+	st, srv := setupMulti(t)
+	srv.EnableEditing("secret")
+
+	var list struct {
+		Items []apiWorkstreamSummary `json:"items"`
+	}
+	if w := apiRequest(t, srv, "GET", "/api/v1/projects/alpha/workstreams?state=in_progress", "", "", nil, &list); w.Code != http.StatusOK {
+		t.Fatalf("list status = %d: %s", w.Code, w.Body.String())
+	}
+	if len(list.Items) != 1 || list.Items[0].Name != "auth" {
+		t.Errorf("in-progress alpha workstreams = %+v, want auth", list.Items)
+	}
+
+	var created apiWorkstream
+	w := apiRequest(t, srv, "POST", "/api/v1/projects/alpha/workstreams", "secret",
+		`{"name": "billing", "objective": "Charge customers", "tasks": ["Pick a provider"]}`, nil, &created)
+	if w.Code != http.StatusCreated || w.Header().Get("Location") != "/api/v1/projects/alpha/workstreams/billing" {
+		t.Fatalf("create = %d at %q: %s", w.Code, w.Header().Get("Location"), w.Body.String())
+	}
+	if created.State != "pending" || len(created.Tasks) != 1 || created.Tasks[0].Status != "pending" {
+		t.Errorf("created = %+v", created)
+	}
+
+	var e apiErrorBody
+	if w := apiRequest(t, srv, "POST", "/api/v1/projects/alpha/workstreams", "secret", `{"name": "billing", "objective": "again"}`, nil, &e); w.Code != http.StatusConflict || e.Error.Code != "conflict" {
+		t.Errorf("duplicate create = %d %+v, want 409 conflict", w.Code, e)
+	}
+	if w := apiRequest(t, srv, "POST", "/api/v1/projects/alpha/workstreams", "secret", `{"name": "x", "objective": "y", "colour": "red"}`, nil, &e); w.Code != http.StatusBadRequest || e.Error.Code != "bad_request" {
+		t.Errorf("unknown field = %d %+v, want 400 bad_request", w.Code, e)
+	}
+
+	var updated apiWorkstream
+	w = apiRequest(t, srv, "PATCH", "/api/v1/projects/alpha/workstreams/billing", "secret",
+		`{"state": "in_progress", "owner": "agent-1", "name": "payments"}`, nil, &updated)
+	if w.Code != http.StatusOK || updated.Name != "payments" || updated.State != "in_progress" || updated.Owner != "agent-1" {
+		t.Fatalf("update = %d %+v", w.Code, updated)
+	}
+
+	if w := apiRequest(t, srv, "DELETE", "/api/v1/projects/alpha/workstreams/payments", "secret", "", nil, nil); w.Code != http.StatusNoContent {
+		t.Errorf("delete status = %d", w.Code)
+	}
+	if _, err := st.Get("alpha", "payments"); err == nil {
+		t.Errorf("workstream should be deleted")
+	}
+	if w := apiRequest(t, srv, "GET", "/api/v1/projects/alpha/workstreams/payments", "", "", nil, &e); w.Code != http.StatusNotFound || e.Error.Code != "not_found" {
+		t.Errorf("get deleted = %d %+v, want 404 not_found", w.Code, e)
+	}
+
+	audit, _ := st.AuditLog("alpha", 10)
+	if len(audit) != 3 || audit[0].Action != "delete" || audit[0].Principal != "web" {
+		t.Errorf("audit log = %+v, want create, update and delete by web", audit)
+	}
+}
+
+func TestAPI_TasksLogsAndDependencies(t *testing.T) {
+	_, srv := setupMulti(t)
+	srv.EnableEditing("secret")
+	base := "/api/v1/projects/alpha/workstreams/auth"
+
+	var task apiTask
+	if w := apiRequest(t, srv, "POST", base+"/tasks", "secret", `{"text": "Add login"}`, nil, &task); w.Code != http.StatusCreated || task.ID == "" {
+		t.Fatalf("add task = %d %+v", w.Code, task)
+	}
+	var sub apiTask
+	apiRequest(t, srv, "POST", base+"/tasks", "secret", `{"text": "Form", "parent": "`+task.ID+`"}`, nil, &sub)
+	if w := apiRequest(t, srv, "PATCH", base+"/tasks/"+sub.ID, "secret", `{"status": "done", "notes": "shipped"}`, nil, &sub); w.Code != http.StatusOK || sub.Status != "done" || sub.Notes != "shipped" {
+		t.Errorf("update subtask = %d %+v", w.Code, sub)
+	}
+	var tasks apiTaskList
+	apiRequest(t, srv, "GET", base+"/tasks", "", "", nil, &tasks)
+	if len(tasks.Items) != 1 || len(tasks.Items[0].Subtasks) != 1 || tasks.Items[0].Status != "done" {
+		t.Errorf("tasks = %+v, want the parent rolled up to done", tasks.Items)
+	}
+	if w := apiRequest(t, srv, "DELETE", base+"/tasks/"+task.ID, "secret", "", nil, nil); w.Code != http.StatusNoContent {
+		t.Errorf("remove task status = %d", w.Code)
+	}
+
+	var entry apiLogEntry
+	w := apiRequest(t, srv, "POST", base+"/logs", "secret", `{"content": "Chose JWT", "type": "decision", "rationale": "stateless", "author": "sam"}`, nil, &entry)
+	if w.Code != http.StatusCreated || entry.Type != "decision" || entry.Author != "sam" || entry.Client != "api" {
+		t.Errorf("log = %d %+v", w.Code, entry)
+	}
+	// With entries already logged, the one just written comes back
+	if apiRequest(t, srv, "POST", base+"/logs", "secret", `{"content": "Progress"}`, nil, &entry); entry.Content != "Progress" {
+		t.Errorf("second log returned %q, want Progress", entry.Content)
+	}
+	var logs struct {
+		Items      []apiLogEntry `json:"items"`
+		NextCursor string        `json:"next_cursor"`
+	}
+	apiRequest(t, srv, "GET", base+"/logs", "", "", nil, &logs)
+	if len(logs.Items) != 2 || logs.Items[0].Content != "Progress" || logs.Items[1].Content != "Chose JWT" {
+		t.Errorf("logs = %+v, want newest first", logs.Items)
+	}
+	apiRequest(t, srv, "GET", base+"/logs?type=decision", "", "", nil, &logs)
+	if len(logs.Items) != 1 || logs.Items[0].Content != "Chose JWT" {
+		t.Errorf("decision logs = %+v", logs.Items)
+	}
+
+	var deps apiDependencies
+	if w := apiRequest(t, srv, "POST", base+"/dependencies", "secret", `{"blocker": "beta/docs"}`, nil, &deps); w.Code != http.StatusCreated || len(deps.BlockedBy) != 1 || deps.BlockedBy[0] != (apiRef{"beta", "docs"}) {
+		t.Errorf("add blocker = %d %+v", w.Code, deps)
+	}
+	if w := apiRequest(t, srv, "POST", base+"/dependencies", "secret", `{"blocker": "beta/docs"}`, nil, nil); w.Code != http.StatusConflict {
+		t.Errorf("duplicate blocker status = %d, want 409", w.Code)
+	}
+	if w := apiRequest(t, srv, "DELETE", base+"/dependencies/beta/docs", "secret", "", nil, nil); w.Code != http.StatusNoContent {
+		t.Errorf("remove blocker status = %d", w.Code)
+	}
+	if w := apiRequest(t, srv, "DELETE", base+"/dependencies/beta/docs", "secret", "", nil, nil); w.Code != http.StatusNotFound {
+		t.Errorf("remove missing blocker status = %d, want 404", w.Code)
+	}
+}
+
+func TestAPI_Milestones(t *testing.T) {
+	_, srv := setupMulti(t)
+	srv.EnableEditing("secret")
+
+	var m apiMilestone
+	w := apiRequest(t, srv, "POST", "/api/v1/projects/alpha/milestones", "secret", `{"name": "v1", "requirements": ["auth", "beta/docs"]}`, nil, &m)
+	if w.Code != http.StatusCreated || len(m.Requirements) != 2 || m.Status == "" {
+		t.Fatalf("create milestone = %d %+v", w.Code, m)
+	}
+	if w := apiRequest(t, srv, "POST", "/api/v1/projects/alpha/milestones", "secret", `{"name": "v2", "requirements": ["nope"]}`, nil, nil); w.Code != http.StatusNotFound {
+		t.Errorf("milestone with a missing requirement status = %d, want 404", w.Code)
+	}
+
+	apiRequest(t, srv, "POST", "/api/v1/projects/alpha/milestones/v1/requirements", "secret", `{"workstream": "api"}`, nil, &m)
+	if len(m.Requirements) != 3 {
+		t.Errorf("requirements = %+v, want 3", m.Requirements)
+	}
+	if w := apiRequest(t, srv, "DELETE", "/api/v1/projects/alpha/milestones/v1/requirements/beta/docs", "secret", "", nil, nil); w.Code != http.StatusNoContent {
+		t.Errorf("remove requirement status = %d", w.Code)
+	}
+	apiRequest(t, srv, "PATCH", "/api/v1/projects/alpha/milestones/v1", "secret", `{"description": "First release"}`, nil, &m)
+	if m.Description != "First release" || len(m.Requirements) != 2 {
+		t.Errorf("updated milestone = %+v", m)
+	}
+
+	var list struct {
+		Items []apiMilestone `json:"items"`
+	}
+	apiRequest(t, srv, "GET", "/api/v1/projects/alpha/milestones", "", "", nil, &list)
+	if len(list.Items) != 1 || list.Items[0].Name != "v1" {
+		t.Errorf("milestones = %+v", list.Items)
+	}
+	if w := apiRequest(t, srv, "DELETE", "/api/v1/projects/alpha/milestones/v1", "secret", "", nil, nil); w.Code != http.StatusNoContent {
+		t.Errorf("delete milestone status = %d", w.Code)
+	}
+}
+
+func TestAPI_Pagination(t *testing.T) {
+	st, srv := setupMulti(t)
+	for _, name := range []string{"c", "d", "e"} {
+		st.Create(&workstream.Workstream{Project: "alpha", Name: name, State: workstream.StatePending})
+	}
+
+	var names []string
+	cursor := ""
+	for range 10 {
+		var pg struct {
+			Items      []apiWorkstreamSummary `json:"items"`
+			NextCursor string                 `json:"next_cursor"`
+		}
+		apiRequest(t, srv, "GET", "/api/v1/projects/alpha/workstreams?limit=2&cursor="+cursor, "", "", nil, &pg)
+		if len(pg.Items) > 2 {
+			t.Fatalf("page of %d items, want at most 2", len(pg.Items))
+		}
+		for _, ws := range pg.Items {
+			names = append(names, ws.Name)
+		}
+		if cursor = pg.NextCursor; cursor == "" {
+			break
+		}
+	}
+	if len(names) != 5 {
+		t.Errorf("paged through %v, want all 5 workstreams", names)
+	}
+
+	for _, q := range []string{"limit=0", "limit=1000", "cursor=abc"} {
+		if w := apiRequest(t, srv, "GET", "/api/v1/projects/alpha/workstreams?"+q, "", "", nil, nil); w.Code != http.StatusBadRequest {
+			t.Errorf("?%s status = %d, want 400", q, w.Code)
+		}
+	}
+}
+
+func TestAPI_ETags(t *testing.T) {
+	_, srv := setupMulti(t)
+	srv.EnableEditing("secret")
+	path := "/api/v1/projects/alpha/workstreams/auth"
+
+	w := apiRequest(t, srv, "GET", path, "", "", nil, nil)
+	tag := w.Header().Get("ETag")
+	if tag == "" {
+		t.Fatalf("GET should return an ETag")
+	}
+	if w := apiRequest(t, srv, "GET", path, "", "", map[string]string{"If-None-Match": tag}, nil); w.Code != http.StatusNotModified {
+		t.Errorf("conditional GET status = %d, want 304", w.Code)
+	}
+
+	w = apiRequest(t, srv, "PATCH", path, "secret", `{"state": "blocked"}`, map[string]string{"If-Match": tag}, nil)
+	if w.Code != http.StatusOK || w.Header().Get("ETag") == tag {
+		t.Fatalf("matching If-Match = %d with ETag %q", w.Code, w.Header().Get("ETag"))
+	}
+
+	// The old tag is stale now, for the workstream and its sub-resources
+	var e apiErrorBody
+	if w := apiRequest(t, srv, "PATCH", path, "secret", `{"state": "done"}`, map[string]string{"If-Match": tag}, &e); w.Code != http.StatusPreconditionFailed || e.Error.Code != "precondition_failed" {
+		t.Errorf("stale If-Match = %d %+v, want 412", w.Code, e)
+	}
+	if w := apiRequest(t, srv, "POST", path+"/logs", "secret", `{"content": "x"}`, map[string]string{"If-Match": tag}, nil); w.Code != http.StatusPreconditionFailed {
+		t.Errorf("stale If-Match on a log = %d, want 412", w.Code)
+	}
+}
+
+func TestAPI_Access(t *testing.T) {
+	st, srv := setupMulti(t)
+	srv.EnableAuth(testAuth)
+
+	// ci may read alpha only, and write nowhere
+	if w := apiRequest(t, srv, "GET", "/api/v1/projects/alpha/workstreams", "ci-token", "", nil, nil); w.Code != http.StatusOK {
+		t.Errorf("readable project status = %d", w.Code)
+	}
+	var e apiErrorBody
+	if w := apiRequest(t, srv, "GET", "/api/v1/projects/beta/workstreams", "ci-token", "", nil, &e); w.Code != http.StatusNotFound || e.Error.Code != "not_found" {
+		t.Errorf("unreadable project = %d %+v, want 404", w.Code, e)
+	}
+	var projects struct {
+		Items []apiProject `json:"items"`
+	}
+	apiRequest(t, srv, "GET", "/api/v1/projects", "ci-token", "", nil, &projects)
+	if len(projects.Items) != 1 || projects.Items[0].Name != "alpha" {
+		t.Errorf("ci's projects = %+v, want alpha", projects.Items)
+	}
+	if w := apiRequest(t, srv, "PATCH", "/api/v1/projects/alpha/workstreams/auth", "ci-token", `{"state": "done"}`, nil, &e); w.Code != http.StatusForbidden || e.Error.Code != "forbidden" {
+		t.Errorf("read-only write = %d %+v, want 403", w.Code, e)
+	}
+
+	// Basic auth is ambient, so writes need the CSRF token
+	req := httptest.NewRequest("POST", "/api/v1/projects/alpha/workstreams/auth/logs", strings.NewReader(`{"content": "x"}`))
+	req.SetBasicAuth("faraz", "pw")
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("basic auth write without CSRF status = %d, want 403", w.Code)
+	}
+	req = httptest.NewRequest("POST", "/api/v1/projects/alpha/workstreams/auth/logs", strings.NewReader(`{"content": "x", "author": "someone"}`))
+	req.SetBasicAuth("faraz", "pw")
+	req.Header.Set(csrfHeader, srv.csrfFor(&testAuth.Principals[0]))
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("basic auth write with CSRF status = %d: %s", w.Code, w.Body.String())
+	}
+	if ws, _ := st.Get("alpha", "auth"); ws.Log[0].Author != "faraz" {
+		t.Errorf("author = %q, want the principal when sign-in is required", ws.Log[0].Author)
+	}
+}
+
+func TestAPI_CrossProjectLinks(t *testing.T) {
+	st, srv := setupMulti(t)
+	st.Create(&workstream.Workstream{Project: "gamma", Name: "db", State: workstream.StatePending})
+	srv.EnableAuth(&AuthConfig{Principals: []Principal{
+		{Name: "dev", Token: "dev-token", Roles: map[string]Role{"alpha": RoleWrite, "gamma": RoleWrite, "beta": RoleRead}},
+	}})
+	post := func(path, body string) int {
+		var e apiErrorBody
+		w := apiRequest(t, srv, "POST", "/api/v1/projects/alpha"+path, "dev-token", body, nil, &e)
+		if w.Code == http.StatusForbidden && e.Error.Code != "forbidden" {
+			t.Errorf("%s error = %+v", path, e)
+		}
+		return w.Code
+	}
+	apiRequest(t, srv, "POST", "/api/v1/projects/alpha/milestones", "dev-token", `{"name": "v1"}`, nil, nil)
+
+	// Linking needs write access to the other project too
+	if code := post("/workstreams/auth/dependencies", `{"blocker": "beta/docs"}`); code != http.StatusForbidden {
+		t.Errorf("read-only blocker status = %d, want 403", code)
+	}
+	if code := post("/milestones/v1/requirements", `{"workstream": "beta/docs"}`); code != http.StatusForbidden {
+		t.Errorf("read-only requirement status = %d, want 403", code)
+	}
+	if code := post("/workstreams/auth/dependencies", `{"blocker": "gamma/db"}`); code != http.StatusCreated {
+		t.Errorf("writable blocker status = %d, want 201", code)
+	}
+
+	// A scoped server allows no links outside its projects
+	srv.LimitLinks([]string{"alpha"})
+	if code := post("/workstreams/api/dependencies", `{"blocker": "gamma/db"}`); code != http.StatusForbidden {
+		t.Errorf("out-of-scope blocker status = %d, want 403", code)
+	}
+	if code := post("/milestones/v1/requirements", `{"workstream": "gamma/db"}`); code != http.StatusForbidden {
+		t.Errorf("out-of-scope requirement status = %d, want 403", code)
+	}
+	if code := post("/milestones/v1/requirements", `{"workstream": "auth"}`); code != http.StatusCreated {
+		t.Errorf("same-project requirement status = %d, want 201", code)
+	}
+
+	ws, _ := st.Get("alpha", "auth")
+	if len(ws.BlockedBy) != 1 || ws.BlockedBy[0].BlockerProject != "gamma" {
+		t.Errorf("blocked by = %+v, want gamma/db only", ws.BlockedBy)
+	}
+}
+
+func TestAPI_AnonymousWrites(t *testing.T) {
+	_, srv := setupMulti(t)
+	if w := apiRequest(t, srv, "POST", "/api/v1/projects/alpha/workstreams/auth/logs", "", `{"content": "x"}`, nil, nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("anonymous write status = %d, want 401", w.Code)
+	}
+}
+
+func TestAPI_SingleProjectServer(t *testing.T) {
+	st, _ := setupMulti(t)
+	srv := NewServer(st, "alpha")
+
+	if w := apiRequest(t, srv, "GET", "/api/v1/projects/alpha/workstreams/auth", "", "", nil, nil); w.Code != http.StatusOK {
+		t.Errorf("own project status = %d", w.Code)
+	}
+	if w := apiRequest(t, srv, "GET", "/api/v1/projects/beta/workstreams", "", "", nil, nil); w.Code != http.StatusNotFound {
+		t.Errorf("another project status = %d, want 404", w.Code)
+	}
+}
+
+func TestAPI_UnknownRoutes(t *testing.T) {
+	_, srv := setupMulti(t)
+
+	var e apiErrorBody
+	if w := apiRequest(t, srv, "GET", "/api/v1/nope", "", "", nil, &e); w.Code != http.StatusNotFound || e.Error.Code != "not_found" {
+		t.Errorf("unknown path = %d %+v", w.Code, e)
+	}
+	w := apiRequest(t, srv, "PUT", "/api/v1/projects/alpha/workstreams/auth", "", "", nil, &e)
+	if w.Code != http.StatusMethodNotAllowed || e.Error.Code != "method_not_allowed" || !strings.Contains(w.Header().Get("Allow"), "PATCH") {
+		t.Errorf("unsupported method = %d %+v, Allow %q", w.Code, e, w.Header().Get("Allow"))
+	}
+}
@@ -0,0 +1,235 @@
+package web
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// tokenCookie holds a principal's token once a browser has signed in with ?token=
+const tokenCookie = "streamctl_token"
+
+// csrfHeader carries the CSRF token on fetch requests; forms use the csrf field
+const csrfHeader = "X-CSRF-Token"
+
+// Role is what a principal may do in a project
+type Role string
+
+const (
+	RoleRead  Role = "read"
+	RoleWrite Role = "write" // Implies read
+)
+
+// Principal is someone the web UI lets in: a person signing in with a
+// password (basic auth) or token, or a script with an API token
+type Principal struct {
+	Name     string          `json:"name"`
+	Password string          `json:"password,omitempty"` // For basic auth
+	Token    string          `json:"token,omitempty"`    // Bearer token, or ?token= to sign a browser in
+	Roles    map[string]Role `json:"roles"`              // By project; "*" covers the rest
+}
+
+// role returns p's role in project, or "" if it has none
+func (p *Principal) role(project string) Role {
+	if r, ok := p.Roles[project]; ok {
+		return r
+	}
+	return p.Roles["*"]
+}
+
+// CanRead reports whether p may view project
+func (p *Principal) CanRead(project string) bool {
+	r := p.role(project)
+	return r == RoleRead || r == RoleWrite
+}
+
+// CanWrite reports whether p may change project's workstreams
+func (p *Principal) CanWrite(project string) bool {
+	return p.role(project) == RoleWrite
+}
+
+// anonymous is who unauthenticated requests act as when auth isn't required
+var anonymous = &Principal{Name: "anonymous", Roles: map[string]Role{"*": RoleRead}}
+
+// AuthConfig lists the principals allowed into the web UI:
+//
+//	{"principals": [
+//	  {"name": "faraz", "password": "...", "roles": {"*": "write"}},
+//	  {"name": "ci", "token": "...", "roles": {"myapp": "read"}}
+//	]}
+type AuthConfig struct {
+	Principals []Principal `json:"principals"`
+}
+
+// LoadAuthConfig reads and validates an AuthConfig from a JSON file
+func LoadAuthConfig(path string) (*AuthConfig, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var cfg AuthConfig
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	seen := map[string]bool{}
+	for _, p := range cfg.Principals {
+		if p.Name == "" {
+			return nil, fmt.Errorf("%s: every principal needs a name", path)
+		}
+		if seen[p.Name] {
+			return nil, fmt.Errorf("%s: duplicate principal %q", path, p.Name)
+		}
+		seen[p.Name] = true
+		if p.Password == "" && p.Token == "" {
+			return nil, fmt.Errorf("%s: principal %q needs a password or token", path, p.Name)
+		}
+		for project, r := range p.Roles {
+			if r != RoleRead && r != RoleWrite {
+				return nil, fmt.Errorf("%s: principal %q has invalid role %q for %s (want read or write)", path, p.Name, r, project)
+			}
+		}
+	}
+	return &cfg, nil
+}
+
+// EnableAuth requires every request to come from one of cfg's principals,
+// and limits each to the projects its roles allow
+func (s *Server) EnableAuth(cfg *AuthConfig) {
+	s.principals = append(s.principals, cfg.Principals...)
+	s.authRequired = true
+	s.resetAuth()
+}
+
+// EnableEditing turns on the write endpoints, guarded by token. Browsers sign
+// in by opening any page with ?token=TOKEN; scripts send it as a bearer token.
+func (s *Server) EnableEditing(token string) {
+	s.principals = append(s.principals, Principal{Name: "web", Token: token, Roles: map[string]Role{"*": RoleWrite}})
+	s.resetAuth()
+}
+
+// LimitLinks restricts blocker edits to workstreams in projects, as a
+// scoped serve process does without --allow-cross-project. Nil lifts the limit.
+func (s *Server) LimitLinks(projects []string) {
+	s.linkProjects = projects
+	s.resetAuth()
+}
+
+// resetAuth makes a fresh CSRF key and drops project servers, which copy
+// the principals when created
+func (s *Server) resetAuth() {
+	key := make([]byte, 32)
+	rand.Read(key)
+	s.csrfKey = key
+
+	s.projectsMu.Lock()
+	clear(s.projects)
+	s.projectsMu.Unlock()
+}
+
+// NewEditToken returns a random token for EnableEditing
+func NewEditToken() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// authenticate returns who r comes from, and whether their credentials are
+// ones a browser sends by itself (a cookie or basic auth) and so need a CSRF
+// token for writes. It returns nil if r must be refused.
+func (s *Server) authenticate(r *http.Request) (p *Principal, ambient bool) {
+	if bearer, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); found {
+		if p := s.principalByToken(bearer); p != nil {
+			return p, false
+		}
+	} else if name, password, ok := r.BasicAuth(); ok {
+		for i := range s.principals {
+			p := &s.principals[i]
+			if p.Password != "" && p.Name == name && tokensEqual(password, p.Password) {
+				return p, true
+			}
+		}
+	} else if c, err := r.Cookie(tokenCookie); err == nil {
+		if p := s.principalByToken(c.Value); p != nil {
+			return p, true
+		}
+	}
+	if s.authRequired {
+		return nil, false
+	}
+	return anonymous, false
+}
+
+func (s *Server) principalByToken(token string) *Principal {
+	for i := range s.principals {
+		if p := &s.principals[i]; p.Token != "" && tokensEqual(token, p.Token) {
+			return p
+		}
+	}
+	return nil
+}
+
+// unauthorized asks the browser for credentials
+func unauthorized(w http.ResponseWriter) {
+	w.Header().Set("WWW-Authenticate", `Basic realm="streamctl", charset="UTF-8"`)
+	http.Error(w, "authentication required", http.StatusUnauthorized)
+}
+
+// csrfToken returns the CSRF token pages embed for a signed-in browser that
+// may write to this project, or "" when r cannot edit
+func (s *Server) csrfToken(r *http.Request) string {
+	p, ambient := s.authenticate(r)
+	if p == nil || !ambient || !p.CanWrite(s.project) {
+		return ""
+	}
+	return s.csrfFor(p)
+}
+
+// csrfFor returns p's CSRF token, which is the same for every project
+func (s *Server) csrfFor(p *Principal) string {
+	mac := hmac.New(sha256.New, s.csrfKey)
+	mac.Write([]byte(p.Name))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func tokensEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// signIn handles ?token= on page requests: a valid token sets the sign-in
+// cookie and redirects to the same page without it. It reports whether it
+// responded.
+func (s *Server) signIn(w http.ResponseWriter, r *http.Request) bool {
+	token := r.URL.Query().Get("token")
+	if token == "" || len(s.principals) == 0 || r.Method != http.MethodGet {
+		return false
+	}
+	if s.principalByToken(token) == nil {
+		http.Error(w, "invalid token", http.StatusForbidden)
+		return true
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     tokenCookie,
+		Value:    token,
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	})
+	u := *r.URL
+	q := u.Query()
+	q.Del("token")
+	u.RawQuery = q.Encode()
+	http.Redirect(w, r, u.RequestURI(), http.StatusSeeOther)
+	return true
+}
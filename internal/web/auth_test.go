@@ -0,0 +1,191 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/faraz/streamctl/pkg/workstream"
+)
+
+var testAuth = &AuthConfig{Principals: []Principal{
+	{Name: "faraz", Password: "pw", Roles: map[string]Role{"*": RoleWrite}},
+	{Name: "viewer", Password: "pw", Roles: map[string]Role{"*": RoleRead, "secret": ""}},
+	{Name: "ci", Token: "ci-token", Roles: map[string]Role{"alpha": RoleRead}},
+}}
+
+func TestPrincipalRoles(t *testing.T) {
+	p := Principal{Roles: map[string]Role{"*": RoleRead, "mine": RoleWrite, "hidden": ""}}
+	for project, want := range map[string][2]bool{
+		"other":  {true, false},
+		"mine":   {true, true},
+		"hidden": {false, false},
+	} {
+		if p.CanRead(project) != want[0] || p.CanWrite(project) != want[1] {
+			t.Errorf("%s: read = %v, write = %v, want %v", project, p.CanRead(project), p.CanWrite(project), want)
+		}
+	}
+}
+
+func TestLoadAuthConfig(t *testing.T) {
+	write := func(content string) string {
+		path := filepath.Join(t.TempDir(), "auth.json")
+		os.WriteFile(path, []byte(content), 0600)
+		return path
+	}
+
+	cfg, err := LoadAuthConfig(write(`{"principals": [
+		{"name": "faraz", "password": "pw", "roles": {"*": "write"}},
+		{"name": "ci", "token": "t", "roles": {"myapp": "read"}}
+	]}`))
+	if err != nil {
+		t.Fatalf("LoadAuthConfig() error = %v", err)
+	}
+	if len(cfg.Principals) != 2 || !cfg.Principals[1].CanRead("myapp") || cfg.Principals[1].CanRead("other") {
+		t.Errorf("config = %+v", cfg)
+	}
+
+	for _, bad := range []string{
+		`{"principals": [{"password": "pw"}]}`,
+		`{"principals": [{"name": "a", "roles": {"*": "read"}}]}`,
+		`{"principals": [{"name": "a", "token": "t", "roles": {"*": "admin"}}]}`,
+		`{"principals": [{"name": "a", "token": "t"}, {"name": "a", "token": "u"}]}`,
+		`{"users": []}`,
+	} {
+		if _, err := LoadAuthConfig(write(bad)); err == nil {
+			t.Errorf("LoadAuthConfig(%s) should fail", bad)
+		}
+	}
+}
+
+func TestServer_AuthRequired(t *testing.T) {
+	st, srv := setupMulti(t)
+	st.Create(&workstream.Workstream{Project: "secret", Name: "plans", State: workstream.StatePending})
+	srv.EnableAuth(testAuth)
+
+	request := func(method, path, user string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if user != "" {
+			req.SetBasicAuth(user, "pw")
+		}
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request("GET", "/", "")
+	if w.Code != http.StatusUnauthorized || !strings.HasPrefix(w.Header().Get("WWW-Authenticate"), "Basic") {
+		t.Errorf("anonymous status = %d, WWW-Authenticate = %q", w.Code, w.Header().Get("WWW-Authenticate"))
+	}
+	req := httptest.NewRequest("GET", "/", nil)
+	req.SetBasicAuth("faraz", "wrong")
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("wrong password status = %d", w.Code)
+	}
+
+	// The home page only lists projects the principal may read
+	body := request("GET", "/", "viewer").Body.String()
+	if !strings.Contains(body, `/p/alpha/`) || strings.Contains(body, `/p/secret/`) {
+		t.Errorf("viewer's home page should list alpha but not secret")
+	}
+	if w := request("GET", "/p/secret/", "viewer"); w.Code != http.StatusNotFound {
+		t.Errorf("viewer GET /p/secret/ status = %d, want 404", w.Code)
+	}
+	if w := request("GET", "/p/secret/", "faraz"); w.Code != http.StatusOK {
+		t.Errorf("faraz GET /p/secret/ status = %d", w.Code)
+	}
+
+	// Readers see no editing UI and can't edit
+	if body := request("GET", "/p/alpha/workstream/auth", "viewer").Body.String(); strings.Contains(body, `id="editor"`) {
+		t.Errorf("reader's page should not include the editor")
+	}
+	if w := request("POST", "/p/alpha/edit/auth", "viewer"); w.Code != http.StatusForbidden {
+		t.Errorf("reader edit status = %d, want 403", w.Code)
+	}
+}
+
+func TestServer_AuthWritesAreAttributedAndAudited(t *testing.T) {
+	st, srv := setupMulti(t)
+	srv.EnableAuth(testAuth)
+
+	// Basic auth is sent by the browser on its own, so writes need CSRF
+	req := httptest.NewRequest("GET", "/p/alpha/workstream/auth", nil)
+	req.SetBasicAuth("faraz", "pw")
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	m := regexp.MustCompile(`const csrfToken = "([0-9a-f]+)"`).FindStringSubmatch(w.Body.String())
+	if m == nil {
+		t.Fatalf("writer's page should embed a CSRF token")
+	}
+
+	post := func(csrf string) *httptest.ResponseRecorder {
+		form := url.Values{"action": {"log"}, "content": {"Looks good"}, "by": {"someone-else"}}
+		req := httptest.NewRequest("POST", "/p/alpha/edit/auth", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetBasicAuth("faraz", "pw")
+		if csrf != "" {
+			req.Header.Set(csrfHeader, csrf)
+		}
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+	if w := post(""); w.Code != http.StatusForbidden {
+		t.Errorf("basic auth without CSRF status = %d, want 403", w.Code)
+	}
+	if w := post(m[1]); w.Code != http.StatusOK {
+		t.Fatalf("edit status = %d: %s", w.Code, w.Body.String())
+	}
+
+	ws, _ := st.Get("alpha", "auth")
+	if last := ws.Log[len(ws.Log)-1]; last.Author != "faraz" {
+		t.Errorf("entry author = %q, want the signed-in principal", last.Author)
+	}
+	audit, _ := st.AuditLog("alpha", 10)
+	if len(audit) != 1 || audit[0].Principal != "faraz" || audit[0].Workstream != "auth" || audit[0].Action != "log" {
+		t.Errorf("audit log = %+v", audit)
+	}
+}
+
+func TestServer_APIToken(t *testing.T) {
+	_, srv := setupMulti(t)
+	srv.EnableAuth(testAuth)
+
+	request := func(method, path string) int {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer ci-token")
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w.Code
+	}
+	if code := request("GET", "/p/alpha/api/activity"); code != http.StatusOK {
+		t.Errorf("token read status = %d", code)
+	}
+	if code := request("GET", "/p/beta/api/activity"); code != http.StatusNotFound {
+		t.Errorf("token read of another project status = %d, want 404", code)
+	}
+	if code := request("POST", "/p/alpha/edit/auth"); code != http.StatusForbidden {
+		t.Errorf("read-only token edit status = %d, want 403", code)
+	}
+}
+
+func TestServer_SingleProjectAuth(t *testing.T) {
+	st, _ := setupMulti(t)
+	srv := NewServer(st, "beta")
+	srv.EnableAuth(testAuth)
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "Bearer ci-token")
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("token without a role in beta status = %d, want 403", w.Code)
+	}
+}
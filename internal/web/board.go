@@ -0,0 +1,117 @@
+package web
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/faraz/streamctl/internal/store"
+	"github.com/faraz/streamctl/pkg/workstream"
+)
+
+// boardStates are the board's columns, in order
+var boardStates = []workstream.State{
+	workstream.StatePending,
+	workstream.StateInProgress,
+	workstream.StateBlocked,
+	workstream.StateDone,
+}
+
+// boardCard is a workstream on the board with its task progress
+type boardCard struct {
+	workstream.Workstream
+	TasksDone  int
+	TasksTotal int
+}
+
+type boardColumn struct {
+	State workstream.State
+	Cards []boardCard
+}
+
+// handleBoard shows workstreams as a kanban board with a column per state,
+// optionally filtered by ?milestone= and ?owner=
+func (s *Server) handleBoard(w http.ResponseWriter, r *http.Request) {
+	milestone := r.URL.Query().Get("milestone")
+	owner := r.URL.Query().Get("owner")
+
+	all, err := s.store.List(store.Filter{Project: s.project})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	milestones, err := s.store.ListMilestones(s.project)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Only workstreams the milestone requires, when filtering by one
+	var required map[string]bool
+	if milestone != "" {
+		m, err := s.store.GetMilestone(s.project, milestone)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		required = map[string]bool{}
+		for _, req := range m.Requirements {
+			if req.WorkstreamProject == s.project {
+				required[req.WorkstreamName] = true
+			}
+		}
+	}
+
+	owners := map[string]bool{}
+	columns := make([]boardColumn, len(boardStates))
+	for i, state := range boardStates {
+		columns[i].State = state
+	}
+	for _, ws := range all {
+		if ws.Owner != "" {
+			owners[ws.Owner] = true
+		}
+		if (required != nil && !required[ws.Name]) || (owner != "" && ws.Owner != owner) {
+			continue
+		}
+		done, total := ws.TaskProgress()
+		card := boardCard{Workstream: ws, TasksDone: done, TasksTotal: total}
+		for i := range columns {
+			if columns[i].State == ws.State {
+				columns[i].Cards = append(columns[i].Cards, card)
+			}
+		}
+	}
+
+	ownerNames := make([]string, 0, len(owners))
+	for o := range owners {
+		ownerNames = append(ownerNames, o)
+	}
+	sort.Strings(ownerNames)
+
+	data := struct {
+		Project    string
+		Base       string
+		Columns    []boardColumn
+		Milestones []workstream.Milestone
+		Owners     []string
+		Milestone  string
+		Owner      string
+		Cursor     string
+		CSRF       string // Set when this browser may move cards
+	}{
+		Project:    s.project,
+		Base:       s.base,
+		Columns:    columns,
+		Milestones: milestones,
+		Owners:     ownerNames,
+		Milestone:  milestone,
+		Owner:      owner,
+		Cursor:     s.currentCursor(),
+		CSRF:       s.csrfToken(r),
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := templates.ExecuteTemplate(w, "board.html", data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,100 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/faraz/streamctl/internal/store"
+	"github.com/faraz/streamctl/pkg/workstream"
+)
+
+// boardColumns returns the card names in each column of a rendered board
+func boardColumns(body string) map[string][]string {
+	columns := map[string][]string{}
+	sections := regexp.MustCompile(`data-state="(\w+)"`).FindAllStringSubmatchIndex(body, -1)
+	for i, sec := range sections {
+		end := len(body)
+		if i+1 < len(sections) {
+			end = sections[i+1][0]
+		}
+		state := body[sec[2]:sec[3]]
+		columns[state] = []string{}
+		for _, m := range regexp.MustCompile(`class="card" data-name="([^"]+)"`).FindAllStringSubmatch(body[sec[1]:end], -1) {
+			columns[state] = append(columns[state], m[1])
+		}
+	}
+	return columns
+}
+
+func TestServer_Board(t *testing.T) {
+	st := setupTestStore(t)
+	st.Create(&workstream.Workstream{Project: "myproject", Name: "auth", State: workstream.StateInProgress, Owner: "agent-1"})
+	st.Create(&workstream.Workstream{Project: "myproject", Name: "db", State: workstream.StateDone})
+	st.Create(&workstream.Workstream{Project: "myproject", Name: "ui", State: workstream.StateBlocked, Owner: "agent-2"})
+	st.Create(&workstream.Workstream{Project: "other", Name: "api", State: workstream.StatePending})
+	needsHelp := true
+	st.Update("myproject", "ui", store.WorkstreamUpdate{NeedsHelp: &needsHelp})
+	st.AddTask("myproject", "auth", "Login")
+	id, _ := st.AddTask("myproject", "auth", "Logout")
+	st.SetTaskStatusByID("myproject", "auth", id, workstream.TaskDone)
+	st.CreateMilestone(&workstream.Milestone{Project: "myproject", Name: "beta"})
+	st.AddMilestoneRequirement("myproject", "beta", "myproject", "auth")
+	st.AddMilestoneRequirement("myproject", "beta", "myproject", "ui")
+	srv := NewServer(st, "myproject")
+
+	get := func(path string) string {
+		t.Helper()
+		req := httptest.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s: status = %d", path, w.Code)
+		}
+		return w.Body.String()
+	}
+
+	body := get("/board")
+	columns := boardColumns(body)
+	want := map[string]string{"pending": "", "in_progress": "auth", "blocked": "ui", "done": "db"}
+	for state, name := range want {
+		if got := strings.Join(columns[state], ","); got != name {
+			t.Errorf("column %s = %q, want %q", state, got, name)
+		}
+	}
+	if !strings.Contains(body, "1/2 tasks") || !strings.Contains(body, "@agent-1") || !strings.Contains(body, "needs help") {
+		t.Errorf("cards should show task progress, owner and needs help")
+	}
+	if strings.Contains(body, ` draggable="true">`) {
+		t.Errorf("cards should not be draggable without editing")
+	}
+
+	columns = boardColumns(get("/board?milestone=beta"))
+	if len(columns["done"]) != 0 || len(columns["in_progress"]) != 1 || len(columns["blocked"]) != 1 {
+		t.Errorf("milestone filter: %v", columns)
+	}
+	columns = boardColumns(get("/board?owner=agent-2"))
+	if len(columns["blocked"]) != 1 || len(columns["in_progress"]) != 0 {
+		t.Errorf("owner filter: %v", columns)
+	}
+
+	req := httptest.NewRequest("GET", "/board?milestone=missing", nil)
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("unknown milestone status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+
+	// Signed-in browsers can drag cards between columns
+	srv.EnableEditing("s3cret")
+	cookie, _ := editClient(t, srv, "s3cret", "auth")
+	req = httptest.NewRequest("GET", "/board", nil)
+	req.AddCookie(cookie)
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if !strings.Contains(w.Body.String(), ` draggable="true">`) {
+		t.Errorf("cards should be draggable when editing")
+	}
+}
@@ -0,0 +1,249 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/faraz/streamctl/internal/store"
+	"github.com/faraz/streamctl/pkg/workstream"
+)
+
+// handleEdit applies one change to a workstream: POST /edit/NAME with an
+// action field and its parameters. Responds with {"ok":true,"message":...}.
+//
+// Actions: state (state), log (content, log_type), task_add (text, parent),
+// task_status (task, status), task_text (task, text), task_notes (task,
+// notes), task_move (task, to), blocker_add / blocker_remove (blocker as
+// NAME or PROJECT/NAME), claim (owner), release, needs_help (value).
+// The optional by field attributes log entries and claims when auth isn't
+// configured; otherwise they are attributed to the principal. Every edit is
+// recorded in the audit log.
+func (s *Server) handleEdit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	p := s.writer(w, r)
+	if p == nil {
+		return
+	}
+
+	name := strings.TrimPrefix(r.URL.Path, "/edit/")
+	if _, err := s.store.Get(s.project, name); err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	message, err := s.applyEdit(p, name, r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.audit(p, s.project, name, r.FormValue("action"), message)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"ok": true, "message": message})
+}
+
+// checkLink returns why p may not link this project's workstreams to one in
+// project, or nil if it may
+func (s *Server) checkLink(p *Principal, project string) error {
+	if project == s.project {
+		return nil
+	}
+	if !p.CanWrite(project) {
+		return fmt.Errorf("%s may not link to workstreams in %s", p.Name, project)
+	}
+	if s.linkProjects != nil && !slices.Contains(s.linkProjects, project) {
+		return fmt.Errorf("project '%s' is outside this server's scope; links across projects are not allowed", project)
+	}
+	return nil
+}
+
+// writer returns who r comes from if they may change this project, having
+// checked the CSRF token of browser credentials. Otherwise it responds with
+// the reason and returns nil.
+func (s *Server) writer(w http.ResponseWriter, r *http.Request) *Principal {
+	p, ambient := s.authenticate(r)
+	if p == nil || p == anonymous {
+		http.Error(w, "editing is not enabled for this session", http.StatusUnauthorized)
+		return nil
+	}
+	if !p.CanWrite(s.project) {
+		http.Error(w, p.Name+" may not edit "+s.project, http.StatusForbidden)
+		return nil
+	}
+	if ambient {
+		sent := r.Header.Get(csrfHeader)
+		if sent == "" {
+			sent = r.FormValue("csrf")
+		}
+		if !sameOrigin(r) || !tokensEqual(sent, s.csrfToken(r)) {
+			http.Error(w, "missing or invalid CSRF token", http.StatusForbidden)
+			return nil
+		}
+	}
+	return p
+}
+
+// applyEdit performs the edit p asked for in r's form on workstream name
+func (s *Server) applyEdit(p *Principal, name string, r *http.Request) (string, error) {
+	// Signed-in people are attributed by name; a shared edit token trusts the
+	// optional by field, falling back to the principal's name
+	by := strings.TrimSpace(r.FormValue("by"))
+	if s.authRequired || by == "" {
+		by = p.Name
+	}
+	field := func(key string) (string, error) {
+		v := strings.TrimSpace(r.FormValue(key))
+		if v == "" {
+			return "", fmt.Errorf("%s is required", key)
+		}
+		return v, nil
+	}
+
+	switch action := r.FormValue("action"); action {
+	case "state":
+		state := workstream.State(r.FormValue("state"))
+		switch state {
+		case workstream.StatePending, workstream.StateInProgress, workstream.StateBlocked, workstream.StateDone:
+		default:
+			return "", fmt.Errorf("invalid state: %q", state)
+		}
+		return "state set to " + string(state), s.store.Update(s.project, name, store.WorkstreamUpdate{State: &state})
+
+	case "log":
+		content, err := field("content")
+		if err != nil {
+			return "", err
+		}
+		logType, err := workstream.ParseEntryType(r.FormValue("log_type"))
+		if err != nil {
+			return "", err
+		}
+		return "logged", s.store.Update(s.project, name, store.WorkstreamUpdate{
+			LogEntry:  &content,
+			LogType:   logType,
+			LogAuthor: by,
+			LogClient: "web",
+		})
+
+	case "task_add":
+		text, err := field("text")
+		if err != nil {
+			return "", err
+		}
+		var id string
+		if parent := r.FormValue("parent"); parent != "" {
+			id, err = s.store.AddSubtask(s.project, name, parent, text)
+		} else {
+			id, err = s.store.AddTask(s.project, name, text)
+		}
+		return "added task " + id, err
+
+	case "task_status":
+		task, err := field("task")
+		if err != nil {
+			return "", err
+		}
+		status := workstream.TaskStatus(r.FormValue("status"))
+		switch status {
+		case workstream.TaskPending, workstream.TaskInProgress, workstream.TaskDone, workstream.TaskSkipped:
+		default:
+			return "", fmt.Errorf("invalid task status: %q", status)
+		}
+		return task + " marked " + string(status), s.store.SetTaskStatusByID(s.project, name, task, status)
+
+	case "task_text":
+		task, err := field("task")
+		if err != nil {
+			return "", err
+		}
+		text, err := field("text")
+		if err != nil {
+			return "", err
+		}
+		return task + " updated", s.store.SetTaskText(s.project, name, task, text)
+
+	case "task_notes":
+		task, err := field("task")
+		if err != nil {
+			return "", err
+		}
+		return task + " notes updated", s.store.SetTaskNotesByID(s.project, name, task, r.FormValue("notes"))
+
+	case "task_move":
+		task, err := field("task")
+		if err != nil {
+			return "", err
+		}
+		to, err := strconv.Atoi(r.FormValue("to"))
+		if err != nil {
+			return "", fmt.Errorf("to must be a position")
+		}
+		return task + " moved", s.store.MoveTask(s.project, name, task, to)
+
+	case "blocker_add", "blocker_remove":
+		ref, err := field("blocker")
+		if err != nil {
+			return "", err
+		}
+		project, blocker := s.project, ref
+		if p, n, found := strings.Cut(ref, "/"); found {
+			project, blocker = p, n
+		}
+		if err := s.checkLink(p, project); err != nil {
+			return "", err
+		}
+		if action == "blocker_add" {
+			return "blocked by " + project + "/" + blocker, s.store.AddDependency(project, blocker, s.project, name)
+		}
+		return "no longer blocked by " + project + "/" + blocker, s.store.RemoveDependency(project, blocker, s.project, name)
+
+	case "claim":
+		owner := strings.TrimSpace(r.FormValue("owner"))
+		if owner == "" {
+			owner = by
+		}
+		return "claimed for " + owner, s.store.Update(s.project, name, store.WorkstreamUpdate{Owner: &owner})
+
+	case "release":
+		owner := ""
+		return "released", s.store.Update(s.project, name, store.WorkstreamUpdate{Owner: &owner})
+
+	case "needs_help":
+		value, err := strconv.ParseBool(r.FormValue("value"))
+		if err != nil {
+			return "", fmt.Errorf("value must be true or false")
+		}
+		message := "needs help cleared"
+		if value {
+			message = "flagged as needing help"
+		}
+		return message, s.store.Update(s.project, name, store.WorkstreamUpdate{NeedsHelp: &value})
+
+	default:
+		return "", fmt.Errorf("unknown action: %q", action)
+	}
+}
+
+// audit records a write by p, logging rather than failing the request if
+// the audit log can't be written
+func (s *Server) audit(p *Principal, project, name, action, detail string) {
+	err := s.store.RecordAudit(&store.AuditEntry{
+		Principal:  p.Name,
+		Project:    project,
+		Workstream: name,
+		Action:     action,
+		Detail:     detail,
+	})
+	if err != nil {
+		log.Printf("audit: %v", err)
+	}
+}
@@ -0,0 +1,217 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/faraz/streamctl/pkg/workstream"
+)
+
+// editClient signs in to srv with token and returns the cookie and the CSRF
+// token embedded in the workstream page
+func editClient(t *testing.T, srv *Server, token, name string) (*http.Cookie, string) {
+	t.Helper()
+	req := httptest.NewRequest("GET", "/workstream/"+name+"?token="+token, nil)
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/workstream/"+name {
+		t.Fatalf("sign-in status = %d, location = %q", w.Code, w.Header().Get("Location"))
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != tokenCookie {
+		t.Fatalf("sign-in should set the token cookie, got %v", cookies)
+	}
+
+	req = httptest.NewRequest("GET", "/workstream/"+name, nil)
+	req.AddCookie(cookies[0])
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	m := regexp.MustCompile(`const csrfToken = "([0-9a-f]+)"`).FindStringSubmatch(w.Body.String())
+	if m == nil {
+		t.Fatalf("signed-in page should embed a CSRF token")
+	}
+	return cookies[0], m[1]
+}
+
+func postEdit(srv *Server, name string, form url.Values, cookie *http.Cookie, csrf string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", "/edit/"+name, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	if csrf != "" {
+		req.Header.Set(csrfHeader, csrf)
+	}
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	return w
+}
+
+func TestServer_Edit_RequiresAuthAndCSRF(t *testing.T) {
+	st := setupTestStore(t)
+	st.Create(&workstream.Workstream{Project: "myproject", Name: "auth", State: workstream.StatePending})
+	srv := NewServer(st, "myproject")
+	form := url.Values{"action": {"state"}, "state": {"done"}}
+
+	// Read-only by default, and pages carry no editing UI
+	if w := postEdit(srv, "auth", form, nil, ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("read-only status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+	req := httptest.NewRequest("GET", "/workstream/auth", nil)
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if strings.Contains(w.Body.String(), `id="editor"`) {
+		t.Errorf("read-only page should not include the editor")
+	}
+
+	srv.EnableEditing("s3cret")
+
+	req = httptest.NewRequest("GET", "/?token=wrong", nil)
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("wrong token status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+
+	cookie, csrf := editClient(t, srv, "s3cret", "auth")
+
+	if w := postEdit(srv, "auth", form, cookie, ""); w.Code != http.StatusForbidden {
+		t.Errorf("missing CSRF status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+	if w := postEdit(srv, "auth", form, cookie, "bad"); w.Code != http.StatusForbidden {
+		t.Errorf("bad CSRF status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+	if w := postEdit(srv, "auth", form, cookie, csrf); w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	if ws, _ := st.Get("myproject", "auth"); ws.State != workstream.StateDone {
+		t.Errorf("state = %s, want done", ws.State)
+	}
+
+	// Scripts use the token as a bearer token and need no CSRF token
+	req = httptest.NewRequest("POST", "/edit/auth", strings.NewReader("action=state&state=pending"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "Bearer s3cret")
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("bearer status = %d: %s", w.Code, w.Body.String())
+	}
+
+	if w := postEdit(srv, "missing", form, cookie, csrf); w.Code != http.StatusNotFound {
+		t.Errorf("unknown workstream status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestServer_Edit_Actions(t *testing.T) {
+	st := setupTestStore(t)
+	st.Create(&workstream.Workstream{Project: "myproject", Name: "auth", State: workstream.StatePending})
+	st.Create(&workstream.Workstream{Project: "myproject", Name: "db", State: workstream.StatePending})
+	srv := NewServer(st, "myproject")
+	srv.EnableEditing("s3cret")
+	cookie, csrf := editClient(t, srv, "s3cret", "auth")
+
+	edit := func(values ...string) {
+		t.Helper()
+		form := url.Values{"by": {"faraz"}}
+		for i := 0; i+1 < len(values); i += 2 {
+			form.Set(values[i], values[i+1])
+		}
+		if w := postEdit(srv, "auth", form, cookie, csrf); w.Code != http.StatusOK {
+			t.Fatalf("%v: status = %d: %s", values, w.Code, w.Body.String())
+		}
+	}
+
+	edit("action", "log", "content", "Chose JWT", "log_type", "decision")
+	edit("action", "task_add", "text", "Write tests")
+	edit("action", "task_add", "text", "Ship it")
+	ws, _ := st.Get("myproject", "auth")
+	first, second := ws.Plan[0].ID, ws.Plan[1].ID
+	edit("action", "task_add", "text", "Unit", "parent", first)
+	edit("action", "task_status", "task", second, "status", "in_progress")
+	edit("action", "task_text", "task", second, "text", "Ship it!")
+	edit("action", "task_notes", "task", second, "notes", "after review")
+	edit("action", "task_move", "task", second, "to", "0")
+	edit("action", "blocker_add", "blocker", "db")
+	edit("action", "claim")
+	edit("action", "needs_help", "value", "true")
+
+	ws, _ = st.Get("myproject", "auth")
+	if ws.Log[0].Content != "Chose JWT" || ws.Log[0].Type != workstream.EntryDecision || ws.Log[0].Author != "faraz" {
+		t.Errorf("log entry = %+v", ws.Log[0])
+	}
+	if len(ws.Plan) != 2 || ws.Plan[0].ID != second || ws.Plan[0].Text != "Ship it!" || ws.Plan[0].Notes != "after review" || ws.Plan[0].Status != workstream.TaskInProgress {
+		t.Errorf("plan = %+v", ws.Plan)
+	}
+	if len(ws.Plan[1].Children) != 1 || ws.Plan[1].Children[0].Text != "Unit" {
+		t.Errorf("subtasks = %+v", ws.Plan[1].Children)
+	}
+	if len(ws.BlockedBy) != 1 || ws.BlockedBy[0].BlockerName != "db" {
+		t.Errorf("blocked by = %+v", ws.BlockedBy)
+	}
+	if ws.Owner != "faraz" || !ws.NeedsHelp {
+		t.Errorf("owner = %q, needs help = %v", ws.Owner, ws.NeedsHelp)
+	}
+
+	edit("action", "blocker_remove", "blocker", "myproject/db")
+	edit("action", "release")
+	edit("action", "needs_help", "value", "false")
+	ws, _ = st.Get("myproject", "auth")
+	if len(ws.BlockedBy) != 0 || ws.Owner != "" || ws.NeedsHelp {
+		t.Errorf("after undo: blocked by = %+v, owner = %q, needs help = %v", ws.BlockedBy, ws.Owner, ws.NeedsHelp)
+	}
+
+	for _, form := range []url.Values{
+		{"action": {"state"}, "state": {"finished"}},
+		{"action": {"task_status"}, "task": {first}, "status": {"nope"}},
+		{"action": {"log"}},
+		{"action": {"explode"}},
+	} {
+		if w := postEdit(srv, "auth", form, cookie, csrf); w.Code != http.StatusBadRequest {
+			t.Errorf("%v: status = %d, want %d", form, w.Code, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestServer_Edit_CrossProjectBlockers(t *testing.T) {
+	st := setupTestStore(t)
+	st.Create(&workstream.Workstream{Project: "myproject", Name: "auth", State: workstream.StatePending})
+	st.Create(&workstream.Workstream{Project: "other", Name: "api", State: workstream.StatePending})
+	st.Create(&workstream.Workstream{Project: "shared", Name: "db", State: workstream.StatePending})
+
+	srv := NewServer(st, "myproject")
+	srv.EnableAuth(&AuthConfig{Principals: []Principal{
+		{Name: "dev", Token: "dev-token", Roles: map[string]Role{"myproject": RoleWrite, "shared": RoleWrite, "other": RoleRead}},
+	}})
+	addBlocker := func(ref string) int {
+		req := httptest.NewRequest("POST", "/edit/auth", strings.NewReader(url.Values{"action": {"blocker_add"}, "blocker": {ref}}.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Authorization", "Bearer dev-token")
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Linking needs write access to the other project too
+	if code := addBlocker("other/api"); code != http.StatusBadRequest {
+		t.Errorf("read-only project status = %d, want %d", code, http.StatusBadRequest)
+	}
+	if code := addBlocker("shared/db"); code != http.StatusOK {
+		t.Errorf("writable project status = %d, want %d", code, http.StatusOK)
+	}
+
+	// A scoped server allows no links outside its projects
+	srv.LimitLinks([]string{"myproject"})
+	if code := addBlocker("shared/db"); code != http.StatusBadRequest {
+		t.Errorf("out-of-scope status = %d, want %d", code, http.StatusBadRequest)
+	}
+
+	ws, _ := st.Get("myproject", "auth")
+	if len(ws.BlockedBy) != 1 || ws.BlockedBy[0].BlockerProject != "shared" {
+		t.Errorf("blocked by = %+v, want shared/db only", ws.BlockedBy)
+	}
+}
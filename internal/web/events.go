@@ -0,0 +1,284 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/faraz/streamctl/internal/store"
+	"github.com/faraz/streamctl/internal/webhook"
+	"github.com/faraz/streamctl/pkg/workstream"
+)
+
+// eventsBatch is how many entries or events are read per query
+const eventsBatch = 100
+
+// heartbeatEvery is how many quiet polls pass between keep-alive comments
+const heartbeatEvery = 15
+
+// eventsCursor is how far a client has seen. It is sent as the SSE event ID
+// ("ENTRY.EVENT.UPDATED") so a reconnecting browser resumes where it left
+// off via Last-Event-ID.
+type eventsCursor struct {
+	Entry   int64     // Last log entry ID sent
+	Event   int64     // Last event ID sent
+	Updated time.Time // Newest workstream last_update sent
+}
+
+func (c eventsCursor) String() string {
+	return fmt.Sprintf("%d.%d.%d", c.Entry, c.Event, c.Updated.UnixNano())
+}
+
+func parseEventsCursor(s string) (eventsCursor, bool) {
+	parts := strings.Split(s, ".")
+	if len(parts) != 3 {
+		return eventsCursor{}, false
+	}
+	entry, err1 := strconv.ParseInt(parts[0], 10, 64)
+	event, err2 := strconv.ParseInt(parts[1], 10, 64)
+	updated, err3 := strconv.ParseInt(parts[2], 10, 64)
+	if err1 != nil || err2 != nil || err3 != nil {
+		return eventsCursor{}, false
+	}
+	return eventsCursor{Entry: entry, Event: event, Updated: time.Unix(0, updated).UTC()}, true
+}
+
+// snapshotJSON is a workstream's headline status as sent to the browser
+type snapshotJSON struct {
+	Name      string `json:"name"`
+	State     string `json:"state"`
+	Owner     string `json:"owner,omitempty"`
+	NeedsHelp bool   `json:"needsHelp"`
+	Blocked   bool   `json:"blocked"`
+}
+
+type sessionJSON struct {
+	ID       string `json:"id"`
+	Agent    string `json:"agent"`
+	Client   string `json:"client,omitempty"`
+	Duration string `json:"duration"`
+}
+
+type statsJSON struct {
+	NeedsHelp int `json:"needsHelp"`
+	Blocked   int `json:"blocked"`
+	Stale     int `json:"stale"`
+	Active    int `json:"active"`
+	Total     int `json:"total"`
+}
+
+// changeMessage is the data of a "change" event: what is new since the
+// client's cursor, plus the dashboard counters
+type changeMessage struct {
+	Entries  []activityJSON    `json:"entries"`
+	Events   []webhook.Payload `json:"events"`
+	Changed  []snapshotJSON    `json:"changed"`
+	Stats    statsJSON         `json:"stats"`
+	Sessions []sessionJSON     `json:"sessions"`
+}
+
+// currentCursor returns a cursor for the present, for pages to pass to
+// /api/events so nothing between rendering and connecting is missed
+func (s *Server) currentCursor() string {
+	event, entry, err := s.store.LatestIDs()
+	if err != nil {
+		return ""
+	}
+	return eventsCursor{Entry: entry, Event: event, Updated: time.Now().UTC()}.String()
+}
+
+// handleEvents streams changes to the project as Server-Sent Events. It
+// polls the store's cheap revision token and, when it moves, sends one
+// "change" event with the new activity, events and changed workstreams.
+// Clients resume from Last-Event-ID or the cursor parameter. The author
+// and session query parameters filter activity as on the dashboard.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	filter := store.ActivityFilter{
+		Project: s.project,
+		Author:  r.URL.Query().Get("author"),
+		Session: r.URL.Query().Get("session"),
+	}
+
+	// Take the revision before the cursor, so a change landing in between
+	// is still noticed
+	rev, err := s.store.Revision(s.project)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Resume from the browser's last event or the page's cursor, or start from now
+	cursor, resumed := parseEventsCursor(r.Header.Get("Last-Event-ID"))
+	if !resumed {
+		cursor, resumed = parseEventsCursor(r.URL.Query().Get("cursor"))
+	}
+	if !resumed {
+		event, entry, err := s.store.LatestIDs()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		cursor = eventsCursor{Entry: entry, Event: event, Updated: time.Now().UTC()}
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	fmt.Fprintf(w, "retry: 3000\nid: %s\n\n", cursor)
+	flusher.Flush()
+
+	if resumed {
+		rev = "" // Catch up on anything missed while disconnected
+	}
+
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+	quiet := 0
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+		}
+
+		current, err := s.store.Revision(s.project)
+		if err != nil || current == rev {
+			if quiet++; quiet >= heartbeatEvery {
+				quiet = 0
+				fmt.Fprint(w, ": ping\n\n")
+				flusher.Flush()
+			}
+			continue
+		}
+		rev, quiet = current, 0
+
+		msg, next, err := s.changesSince(cursor, filter)
+		if err != nil {
+			continue
+		}
+		cursor = next
+		data, err := json.Marshal(msg)
+		if err != nil {
+			continue
+		}
+		fmt.Fprintf(w, "id: %s\nevent: change\ndata: %s\n\n", cursor, data)
+		flusher.Flush()
+	}
+}
+
+// changesSince collects what changed after cursor, returning the message
+// and the cursor to continue from
+func (s *Server) changesSince(cursor eventsCursor, filter store.ActivityFilter) (*changeMessage, eventsCursor, error) {
+	msg := &changeMessage{
+		Entries:  []activityJSON{},
+		Events:   []webhook.Payload{},
+		Changed:  []snapshotJSON{},
+		Sessions: []sessionJSON{},
+	}
+
+	// Read entries and events in ID order until a batch comes back short, so
+	// a burst between polls is sent whole
+	for {
+		entries, err := s.store.ActivityAfter(filter, cursor.Entry, eventsBatch)
+		if err != nil {
+			return nil, cursor, err
+		}
+		for _, e := range entries {
+			msg.Entries = append(msg.Entries, newActivityJSON(e))
+			cursor.Entry = e.ID
+		}
+		if len(entries) < eventsBatch {
+			break
+		}
+	}
+	// Pages expect entries newest first, like the activity API
+	slices.Reverse(msg.Entries)
+
+	for {
+		events, err := s.store.Events(s.project, cursor.Event, eventsBatch)
+		if err != nil {
+			return nil, cursor, err
+		}
+		for _, ev := range events {
+			msg.Events = append(msg.Events, webhook.NewPayload(ev))
+			cursor.Event = ev.ID
+		}
+		if len(events) < eventsBatch {
+			break
+		}
+	}
+
+	changed, err := s.store.Snapshots(s.project, cursor.Updated)
+	if err != nil {
+		return nil, cursor, err
+	}
+	for _, snap := range changed {
+		msg.Changed = append(msg.Changed, newSnapshotJSON(snap))
+		if snap.LastUpdate.After(cursor.Updated) {
+			cursor.Updated = snap.LastUpdate
+		}
+	}
+
+	all, err := s.store.Snapshots(s.project, time.Time{})
+	if err != nil {
+		return nil, cursor, err
+	}
+	now := time.Now()
+	for _, snap := range all {
+		msg.Stats.Total++
+		if s.stale.Stale(snap.State, snap.LastUpdate, now) {
+			msg.Stats.Stale++
+		}
+		if snap.NeedsHelp {
+			msg.Stats.NeedsHelp++
+		}
+		if snap.Blocked {
+			msg.Stats.Blocked++
+		}
+		if snap.State == workstream.StateInProgress {
+			msg.Stats.Active++
+		}
+	}
+	// Dependency changes don't touch last_update; when nothing else explains
+	// the new revision, send every workstream's status
+	if len(msg.Changed) == 0 && len(msg.Entries) == 0 && len(msg.Events) == 0 {
+		for _, snap := range all {
+			msg.Changed = append(msg.Changed, newSnapshotJSON(snap))
+		}
+	}
+
+	sessions, err := s.store.ActiveSessions(s.project)
+	if err != nil {
+		return nil, cursor, err
+	}
+	for _, sess := range sessions {
+		msg.Sessions = append(msg.Sessions, sessionJSON{
+			ID:       sess.ID,
+			Agent:    sess.Agent,
+			Client:   sess.Client,
+			Duration: workstream.FormatDuration(sess.Duration()),
+		})
+	}
+
+	return msg, cursor, nil
+}
+
+func newSnapshotJSON(snap workstream.Snapshot) snapshotJSON {
+	return snapshotJSON{
+		Name:      snap.Name,
+		State:     string(snap.State),
+		Owner:     snap.Owner,
+		NeedsHelp: snap.NeedsHelp,
+		Blocked:   snap.Blocked,
+	}
+}
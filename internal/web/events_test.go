@@ -0,0 +1,219 @@
+package web
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/faraz/streamctl/internal/store"
+	"github.com/faraz/streamctl/pkg/workstream"
+)
+
+// readSSE reads one server-sent event, skipping comments, and returns its
+// fields
+func readSSE(t *testing.T, r *bufio.Reader) map[string]string {
+	t.Helper()
+	fields := map[string]string{}
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("reading event stream: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			if len(fields) > 0 {
+				return fields
+			}
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+		key, value, _ := strings.Cut(line, ": ")
+		fields[key] = value
+	}
+}
+
+func openEvents(t *testing.T, url, lastEventID string) *bufio.Reader {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	req, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET %s: %v", url, err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+	return bufio.NewReader(resp.Body)
+}
+
+func TestServer_Events_StreamsChanges(t *testing.T) {
+	st := setupTestStore(t)
+	st.Create(&workstream.Workstream{Project: "myproject", Name: "auth", State: workstream.StatePending})
+
+	srv := NewServer(st, "myproject")
+	srv.pollInterval = 10 * time.Millisecond
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+
+	stream := openEvents(t, ts.URL+"/api/events", "")
+	hello := readSSE(t, stream)
+	if hello["id"] == "" {
+		t.Fatalf("first event should carry the cursor, got %v", hello)
+	}
+
+	state := workstream.StateInProgress
+	entry := "Started on login"
+	st.Update("myproject", "auth", store.WorkstreamUpdate{State: &state, LogEntry: &entry, LogAuthor: "agent-1"})
+
+	change := readSSE(t, stream)
+	if change["event"] != "change" {
+		t.Fatalf("event = %v", change)
+	}
+	var msg changeMessage
+	if err := json.Unmarshal([]byte(change["data"]), &msg); err != nil {
+		t.Fatalf("data is not JSON: %v", err)
+	}
+	if len(msg.Entries) != 1 || msg.Entries[0].Content != "Started on login" || msg.Entries[0].Author != "agent-1" {
+		t.Errorf("entries = %+v", msg.Entries)
+	}
+	if len(msg.Events) != 1 || msg.Events[0].Type != "state_changed" {
+		t.Errorf("events = %+v", msg.Events)
+	}
+	if len(msg.Changed) != 1 || msg.Changed[0].Name != "auth" || msg.Changed[0].State != "in_progress" {
+		t.Errorf("changed = %+v", msg.Changed)
+	}
+	if msg.Stats.Active != 1 || msg.Stats.Total != 1 {
+		t.Errorf("stats = %+v", msg.Stats)
+	}
+
+	// A reconnecting client resumes from its last event ID
+	lastID := change["id"]
+	entry = "Missed while offline"
+	st.Update("myproject", "auth", store.WorkstreamUpdate{LogEntry: &entry})
+
+	resumed := openEvents(t, ts.URL+"/api/events", lastID)
+	readSSE(t, resumed)
+	change = readSSE(t, resumed)
+	msg = changeMessage{}
+	json.Unmarshal([]byte(change["data"]), &msg)
+	if len(msg.Entries) != 1 || msg.Entries[0].Content != "Missed while offline" {
+		t.Errorf("resumed entries = %+v", msg.Entries)
+	}
+}
+
+func TestServer_Events_FiltersByAuthor(t *testing.T) {
+	st := setupTestStore(t)
+	st.Create(&workstream.Workstream{Project: "myproject", Name: "auth", State: workstream.StatePending})
+
+	srv := NewServer(st, "myproject")
+	srv.pollInterval = 10 * time.Millisecond
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+
+	stream := openEvents(t, ts.URL+"/api/events?author=agent-2", "")
+	readSSE(t, stream)
+
+	first, second := "From agent 1", "From agent 2"
+	st.Update("myproject", "auth", store.WorkstreamUpdate{LogEntry: &first, LogAuthor: "agent-1"})
+	st.Update("myproject", "auth", store.WorkstreamUpdate{LogEntry: &second, LogAuthor: "agent-2"})
+
+	// Both updates may arrive in one message or two; collect until agent-2's shows up
+	var contents []string
+	for len(contents) == 0 || contents[len(contents)-1] != second {
+		var msg changeMessage
+		json.Unmarshal([]byte(readSSE(t, stream)["data"]), &msg)
+		for _, e := range msg.Entries {
+			contents = append(contents, e.Content)
+		}
+	}
+	if len(contents) != 1 {
+		t.Errorf("filtered entries = %v, want only agent-2's", contents)
+	}
+}
+
+func TestServer_ChangesSince_SendsBurstsWhole(t *testing.T) {
+	st := setupTestStore(t)
+	srv := NewServer(st, "myproject")
+	// Starting from the page cursor, only the burst below is new
+	start, ok := parseEventsCursor(srv.currentCursor())
+	if !ok {
+		t.Fatalf("currentCursor() = %q", srv.currentCursor())
+	}
+
+	ws := &workstream.Workstream{Project: "myproject", Name: "busy", State: workstream.StateInProgress}
+	for i := 0; i < eventsBatch*2+3; i++ {
+		ws.Log = append(ws.Log, workstream.LogEntry{Timestamp: time.Now().UTC(), Content: fmt.Sprintf("entry %d", i)})
+	}
+	st.Create(ws)
+
+	msg, cursor, err := srv.changesSince(start, store.ActivityFilter{Project: "myproject"})
+	if err != nil {
+		t.Fatalf("changesSince() error = %v", err)
+	}
+	if len(msg.Entries) != len(ws.Log) {
+		t.Fatalf("entries = %d, want %d", len(msg.Entries), len(ws.Log))
+	}
+	if first, last := msg.Entries[0].Content, msg.Entries[len(msg.Entries)-1].Content; first != fmt.Sprintf("entry %d", len(ws.Log)-1) || last != "entry 0" {
+		t.Errorf("entries run %q to %q, want newest first", first, last)
+	}
+	if next, _, _ := srv.changesSince(cursor, store.ActivityFilter{Project: "myproject"}); len(next.Entries) != 0 {
+		t.Errorf("cursor should be past the burst, got %d more entries", len(next.Entries))
+	}
+}
+
+func TestEventsCursorRoundTrip(t *testing.T) {
+	c := eventsCursor{Entry: 12, Event: 7, Updated: time.Unix(1760000000, 123456789).UTC()}
+	parsed, ok := parseEventsCursor(c.String())
+	if !ok || parsed != c {
+		t.Errorf("parseEventsCursor(%q) = %+v, %v", c.String(), parsed, ok)
+	}
+	if _, ok := parseEventsCursor("garbage"); ok {
+		t.Errorf("parseEventsCursor should reject garbage")
+	}
+}
+
+func TestServer_Workstream_LogContentsAreJSON(t *testing.T) {
+	st := setupTestStore(t)
+	st.Create(&workstream.Workstream{Project: "myproject", Name: "auth", State: workstream.StatePending})
+	entry := "Line one\nline </script> two"
+	st.Update("myproject", "auth", store.WorkstreamUpdate{LogEntry: &entry})
+	srv := NewServer(st, "myproject")
+
+	req := httptest.NewRequest("GET", "/workstream/auth", nil)
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+
+	// Live updates re-read log contents from the refetched page
+	body := w.Body.String()
+	start := strings.Index(body, `<script type="application/json" id="log-contents">`)
+	if start < 0 {
+		t.Fatalf("page should embed log contents as JSON")
+	}
+	raw := body[start+len(`<script type="application/json" id="log-contents">`):]
+	raw = raw[:strings.Index(raw, "</script>")]
+	var contents map[string]string
+	if err := json.Unmarshal([]byte(raw), &contents); err != nil {
+		t.Fatalf("log contents are not valid JSON: %v\n%s", err, raw)
+	}
+	for _, content := range contents {
+		if content != entry {
+			t.Errorf("content = %q, want %q", content, entry)
+		}
+	}
+	if len(contents) != 1 {
+		t.Errorf("got %d log contents, want 1", len(contents))
+	}
+}
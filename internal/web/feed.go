@@ -0,0 +1,245 @@
+package web
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/faraz/streamctl/internal/store"
+	"github.com/faraz/streamctl/pkg/workstream"
+)
+
+// feedLimit is the most entries a feed carries
+const feedLimit = 50
+
+// feedIDPrefix starts every feed and entry ID. IDs are tag URIs rather than
+// page URLs so they stay the same when the server's address changes.
+const feedIDPrefix = "tag:streamctl,2026:"
+
+// feedEventTypes are the events a feed reports alongside log entries
+var feedEventTypes = []workstream.EventType{
+	workstream.EventStateChanged,
+	workstream.EventNeedsHelpRaised,
+	workstream.EventNeedsHelpCleared,
+}
+
+// atomFeed is an Atom (RFC 4287) feed document
+type atomFeed struct {
+	XMLName   xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID        string      `xml:"id"`
+	Title     string      `xml:"title"`
+	Updated   string      `xml:"updated"`
+	Author    atomPerson  `xml:"author"`
+	Generator string      `xml:"generator"`
+	Links     []atomLink  `xml:"link"`
+	Entries   []atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	ID       string        `xml:"id"`
+	Title    string        `xml:"title"`
+	Updated  string        `xml:"updated"`
+	Author   *atomPerson   `xml:"author,omitempty"`
+	Link     atomLink      `xml:"link"`
+	Category *atomCategory `xml:"category,omitempty"`
+	Content  atomText      `xml:"content"`
+
+	at time.Time // For sorting
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+// feedTime formats t as an Atom date
+func feedTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// feedID returns the ID of a feed or entry under the project
+func (s *Server) feedID(parts ...string) string {
+	escaped := []string{url.PathEscape(s.project)}
+	for _, p := range parts {
+		escaped = append(escaped, url.PathEscape(p))
+	}
+	return feedIDPrefix + strings.Join(escaped, "/")
+}
+
+// absoluteURL returns the address of path on this server as r reached it.
+// Feed readers need absolute links.
+func (s *Server) absoluteURL(r *http.Request, path string) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + s.base + path
+}
+
+// activityEntry turns a log entry into a feed entry
+func (s *Server) activityEntry(r *http.Request, a workstream.ActivityEntry) atomEntry {
+	title, _, _ := strings.Cut(strings.TrimSpace(a.Content), "\n")
+	e := atomEntry{
+		ID:       s.feedID("log", fmt.Sprint(a.ID)),
+		Title:    a.WorkstreamName + ": " + truncate(title, 80),
+		Updated:  feedTime(a.Timestamp),
+		Link:     atomLink{Href: s.absoluteURL(r, fmt.Sprintf("/workstream/%s#log-%d", url.PathEscape(a.WorkstreamName), a.Timestamp.Unix()))},
+		Category: &atomCategory{Term: string(a.Type)},
+		Content:  atomText{Type: "text", Body: a.Content},
+		at:       a.Timestamp,
+	}
+	if author := a.Author; author != "" || a.Client != "" {
+		if author == "" {
+			author = a.Client
+		}
+		e.Author = &atomPerson{Name: author}
+	}
+	return e
+}
+
+// eventEntry turns a state change or needs_help event into a feed entry
+func (s *Server) eventEntry(r *http.Request, ev workstream.Event) atomEntry {
+	e := atomEntry{
+		ID:       s.feedID("event", fmt.Sprint(ev.ID)),
+		Updated:  feedTime(ev.CreatedAt),
+		Link:     atomLink{Href: s.absoluteURL(r, "/workstream/"+url.PathEscape(ev.Workstream))},
+		Category: &atomCategory{Term: string(ev.Type)},
+		at:       ev.CreatedAt,
+	}
+	switch ev.Type {
+	case workstream.EventStateChanged:
+		e.Title = fmt.Sprintf("%s: %s → %s", ev.Workstream, ev.Data["from"], ev.Data["to"])
+		e.Content.Body = fmt.Sprintf("%s moved from %s to %s.", ev.Workstream, ev.Data["from"], ev.Data["to"])
+	case workstream.EventNeedsHelpRaised:
+		e.Title = ev.Workstream + " needs help"
+		e.Content.Body = ev.Workstream + " is flagged as needing help."
+		if q := ev.Data["question"]; q != "" {
+			e.Content.Body = "Question: " + q
+		}
+		if by := ev.Data["asked_by"]; by != "" {
+			e.Author = &atomPerson{Name: by}
+		}
+	case workstream.EventNeedsHelpCleared:
+		e.Title = ev.Workstream + " no longer needs help"
+		e.Content.Body = ev.Workstream + " is no longer flagged as needing help."
+		if a := ev.Data["answer"]; a != "" {
+			e.Content.Body = "Question: " + ev.Data["question"] + "\n\nAnswer: " + a
+		}
+		if by := ev.Data["answered_by"]; by != "" {
+			e.Author = &atomPerson{Name: by}
+		}
+	}
+	e.Content.Type = "text"
+	return e
+}
+
+// serveFeed writes the log entries and events matching the filters as an
+// Atom feed, newest first
+func (s *Server) serveFeed(w http.ResponseWriter, r *http.Request, feed atomFeed, activity store.ActivityFilter, events store.EventFilter) {
+	entries, err := s.store.Activity(activity, feedLimit, 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	evs, err := s.store.RecentEvents(events, feedLimit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	for _, a := range entries {
+		feed.Entries = append(feed.Entries, s.activityEntry(r, a))
+	}
+	for _, ev := range evs {
+		feed.Entries = append(feed.Entries, s.eventEntry(r, ev))
+	}
+	sort.SliceStable(feed.Entries, func(i, j int) bool { return feed.Entries[i].at.After(feed.Entries[j].at) })
+	feed.Entries = feed.Entries[:min(len(feed.Entries), feedLimit)]
+
+	// The feed changes when its newest entry does
+	updated := time.Now()
+	if len(feed.Entries) > 0 {
+		updated = feed.Entries[0].at
+	}
+	feed.Updated = feedTime(updated)
+	feed.Author = atomPerson{Name: "streamctl"}
+	feed.Generator = "streamctl"
+	feed.Links = append(feed.Links, atomLink{Rel: "self", Type: "application/atom+xml", Href: s.absoluteURL(r, r.URL.Path)})
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	if err := enc.Encode(feed); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+	http.ServeContent(w, r, "", updated, bytes.NewReader(buf.Bytes()))
+}
+
+// handleFeed serves the project's activity as an Atom feed
+func (s *Server) handleFeed(w http.ResponseWriter, r *http.Request) {
+	feed := atomFeed{
+		ID:    s.feedID(),
+		Title: s.project + " activity",
+		Links: []atomLink{{Rel: "alternate", Type: "text/html", Href: s.absoluteURL(r, "/")}},
+	}
+	s.serveFeed(w, r, feed,
+		store.ActivityFilter{Project: s.project},
+		store.EventFilter{Project: s.project, Types: feedEventTypes})
+}
+
+// handleWorkstreamFeed serves one workstream's activity as an Atom feed
+func (s *Server) handleWorkstreamFeed(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if _, err := s.store.Get(s.project, name); err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	feed := atomFeed{
+		ID:    s.feedID("workstream", name),
+		Title: s.project + "/" + name + " activity",
+		Links: []atomLink{{Rel: "alternate", Type: "text/html", Href: s.absoluteURL(r, "/workstream/"+url.PathEscape(name))}},
+	}
+	s.serveFeed(w, r, feed,
+		store.ActivityFilter{Project: s.project, Workstream: name},
+		store.EventFilter{Project: s.project, Workstream: name, Types: feedEventTypes})
+}
+
+// handleMilestoneFeed serves the activity of the workstreams a milestone
+// requires as an Atom feed
+func (s *Server) handleMilestoneFeed(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if _, err := s.store.GetMilestone(s.project, name); err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	feed := atomFeed{
+		ID:    s.feedID("milestone", name),
+		Title: s.project + " milestone " + name + " activity",
+		Links: []atomLink{{Rel: "alternate", Type: "text/html", Href: s.absoluteURL(r, "/milestone/"+url.PathEscape(name))}},
+	}
+	s.serveFeed(w, r, feed,
+		store.ActivityFilter{Project: s.project, Milestone: name},
+		store.EventFilter{Project: s.project, Milestone: name, Types: feedEventTypes})
+}
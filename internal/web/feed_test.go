@@ -0,0 +1,143 @@
+package web
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/faraz/streamctl/internal/store"
+	"github.com/faraz/streamctl/pkg/workstream"
+)
+
+func parseFeed(t *testing.T, w *httptest.ResponseRecorder) atomFeed {
+	t.Helper()
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/atom+xml") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	var feed atomFeed
+	if err := xml.Unmarshal(w.Body.Bytes(), &feed); err != nil {
+		t.Fatalf("feed is not XML: %v\n%s", err, w.Body.String())
+	}
+	return feed
+}
+
+func TestServer_Feed(t *testing.T) {
+	st := setupTestStore(t)
+	st.Create(&workstream.Workstream{Project: "myproject", Name: "auth", State: workstream.StatePending})
+	st.Create(&workstream.Workstream{Project: "myproject", Name: "api", State: workstream.StatePending})
+	st.CreateMilestone(&workstream.Milestone{Project: "myproject", Name: "beta"})
+	st.AddMilestoneRequirement("myproject", "beta", "myproject", "api")
+
+	inProgress := workstream.StateInProgress
+	entry := "Started on login\n\nDetails follow"
+	st.Update("myproject", "auth", store.WorkstreamUpdate{State: &inProgress, LogEntry: &entry, LogAuthor: "agent-1"})
+	time.Sleep(10 * time.Millisecond) // Order the entries
+	st.RequestHelp("myproject", "api", "Which DB?", "agent-2")
+	srv := NewServer(st, "myproject")
+
+	feed := parseFeed(t, get(srv, "/feed.atom"))
+	if feed.ID != "tag:streamctl,2026:myproject" || feed.Title != "myproject activity" {
+		t.Errorf("feed id = %q, title = %q", feed.ID, feed.Title)
+	}
+	if len(feed.Entries) != 4 {
+		t.Fatalf("entries = %+v, want two log entries, the state change and the help request", feed.Entries)
+	}
+	var help atomEntry
+	for _, e := range feed.Entries[:2] {
+		if e.Category.Term == "needs_help_raised" {
+			help = e
+		}
+	}
+	if help.Title != "api needs help" || help.Content.Body != "Question: Which DB?" || help.Author.Name != "agent-2" {
+		t.Errorf("newest entries = %+v, want api's help request", feed.Entries[:2])
+	}
+	if feed.Updated != help.Updated {
+		t.Errorf("feed updated = %s, want its newest entry's %s", feed.Updated, help.Updated)
+	}
+	ids := map[string]bool{}
+	for _, e := range feed.Entries {
+		ids[e.ID] = true
+		if _, err := time.Parse(time.RFC3339, e.Updated); err != nil {
+			t.Errorf("entry %s updated = %q: %v", e.ID, e.Updated, err)
+		}
+		if !strings.HasPrefix(e.Link.Href, "http://example.com/workstream/") {
+			t.Errorf("entry link = %q, want an absolute URL", e.Link.Href)
+		}
+	}
+	if len(ids) != 4 {
+		t.Errorf("entry IDs should be unique: %v", ids)
+	}
+	body := get(srv, "/feed.atom").Body.String()
+	for _, want := range []string{"<title>auth: Started on login</title>", "<title>auth: pending → in_progress</title>", `<category term="state_changed">`, `<link rel="self" type="application/atom+xml" href="http://example.com/feed.atom">`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("feed should contain %q:\n%s", want, body)
+		}
+	}
+
+	// Readers that already have the latest entries get 304
+	req := httptest.NewRequest("GET", "/feed.atom", nil)
+	req.Header.Set("If-Modified-Since", get(srv, "/feed.atom").Header().Get("Last-Modified"))
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusNotModified {
+		t.Errorf("conditional GET status = %d, want 304", w.Code)
+	}
+}
+
+func TestServer_WorkstreamAndMilestoneFeeds(t *testing.T) {
+	st := setupTestStore(t)
+	st.Create(&workstream.Workstream{Project: "myproject", Name: "auth", State: workstream.StatePending})
+	st.Create(&workstream.Workstream{Project: "myproject", Name: "api", State: workstream.StatePending})
+	st.CreateMilestone(&workstream.Milestone{Project: "myproject", Name: "beta"})
+	st.AddMilestoneRequirement("myproject", "beta", "myproject", "api")
+	for _, name := range []string{"auth", "api"} {
+		entry := "work on " + name
+		st.Update("myproject", name, store.WorkstreamUpdate{LogEntry: &entry})
+	}
+	done := workstream.StateDone
+	st.Update("myproject", "api", store.WorkstreamUpdate{State: &done})
+	srv := NewServer(st, "myproject")
+
+	feed := parseFeed(t, get(srv, "/workstream/auth/feed.atom"))
+	if feed.ID != "tag:streamctl,2026:myproject/workstream/auth" || len(feed.Entries) != 1 || feed.Entries[0].Title != "auth: work on auth" {
+		t.Errorf("auth feed = %s with %+v", feed.ID, feed.Entries)
+	}
+
+	feed = parseFeed(t, get(srv, "/milestone/beta/feed.atom"))
+	if feed.ID != "tag:streamctl,2026:myproject/milestone/beta" || len(feed.Entries) != 2 {
+		t.Errorf("beta feed = %s with %+v, want api's entry and state change", feed.ID, feed.Entries)
+	}
+	for _, e := range feed.Entries {
+		if strings.Contains(e.Title, "auth") {
+			t.Errorf("beta does not require auth, but its entry %q is in the feed", e.Title)
+		}
+	}
+
+	if w := get(srv, "/workstream/missing/feed.atom"); w.Code != http.StatusNotFound {
+		t.Errorf("missing workstream status = %d, want 404", w.Code)
+	}
+	if w := get(srv, "/milestone/missing/feed.atom"); w.Code != http.StatusNotFound {
+		t.Errorf("missing milestone status = %d, want 404", w.Code)
+	}
+	if body := get(srv, "/workstream/auth").Body.String(); !strings.Contains(body, `href="/workstream/auth/feed.atom"`) {
+		t.Errorf("workstream page should link its feed")
+	}
+}
+
+func TestMultiServer_Feed(t *testing.T) {
+	_, srv := setupMulti(t)
+
+	feed := parseFeed(t, get(srv, "/p/beta/feed.atom"))
+	if feed.ID != "tag:streamctl,2026:beta" || len(feed.Entries) != 1 || feed.Entries[0].Title != "docs needs help" {
+		t.Errorf("beta feed = %s with %+v", feed.ID, feed.Entries)
+	}
+	if feed.Links[0].Href != "http://example.com/p/beta/" {
+		t.Errorf("feed should link the project dashboard, got %+v", feed.Links)
+	}
+}
@@ -0,0 +1,326 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/faraz/streamctl/internal/store"
+	"github.com/faraz/streamctl/pkg/workstream"
+)
+
+// Dependency graph geometry, in SVG user units
+const (
+	graphNodeWidth   = 170
+	graphNodeHeight  = 36
+	graphLayerGap    = 70 // Horizontal space between layers
+	graphRowGap      = 14 // Vertical space between nodes in a layer
+	graphClusterPad  = 10
+	graphClusterHead = 20 // Room for a milestone's label
+	graphMargin      = 16
+	graphLabelChars  = 20 // Longer names are truncated
+)
+
+// graphNode is a workstream placed on the dependency graph. Nodes flow left to
+// right: a node's layer is the length of the longest chain of blockers above it.
+type graphNode struct {
+	ID         string // PROJECT/NAME
+	Name       string
+	Label      string // Name, truncated to fit
+	Project    string
+	State      workstream.State
+	NeedsHelp  bool
+	External   bool     // In another project
+	Milestones []string // Milestones requiring it; the first is its cluster
+	Layer      int
+	X, Y       int
+}
+
+type graphEdge struct {
+	From, To string // Node IDs
+	Path     string // SVG path data
+}
+
+// graphCluster is the box drawn around a milestone's workstreams
+type graphCluster struct {
+	Name           string
+	Status         workstream.State
+	X, Y, W, H     int
+	LabelX, LabelY int
+}
+
+type graphLayout struct {
+	Nodes         []graphNode
+	Edges         []graphEdge
+	Clusters      []graphCluster
+	Width, Height int
+}
+
+// layoutGraph places workstreams and the dependencies between them. Each
+// milestone gets a horizontal band holding the workstreams it requires
+// (workstreams in several milestones sit in the first); the rest share a
+// final band. Cycles are tolerated by ignoring the edge that closes them.
+func layoutGraph(project string, workstreams []workstream.Workstream, deps []workstream.Dependency, milestones []workstream.Milestone) graphLayout {
+	nodes := map[string]*graphNode{}
+	var order []string
+	addNode := func(n graphNode) *graphNode {
+		if existing, ok := nodes[n.ID]; ok {
+			return existing
+		}
+		n.Label = n.Name
+		if len([]rune(n.Name)) > graphLabelChars {
+			n.Label = string([]rune(n.Name)[:graphLabelChars-1]) + "…"
+		}
+		nodes[n.ID] = &n
+		order = append(order, n.ID)
+		return nodes[n.ID]
+	}
+	for _, ws := range workstreams {
+		addNode(graphNode{ID: ws.Project + "/" + ws.Name, Name: ws.Name, Project: ws.Project, State: ws.State, NeedsHelp: ws.NeedsHelp})
+	}
+
+	preds := map[string][]string{}
+	var edges []graphEdge
+	for _, d := range deps {
+		from := addNode(graphNode{ID: d.BlockerProject + "/" + d.BlockerName, Name: d.BlockerName, Project: d.BlockerProject, External: d.BlockerProject != project})
+		to := addNode(graphNode{ID: d.BlockedProject + "/" + d.BlockedName, Name: d.BlockedName, Project: d.BlockedProject, External: d.BlockedProject != project})
+		preds[to.ID] = append(preds[to.ID], from.ID)
+		edges = append(edges, graphEdge{From: from.ID, To: to.ID})
+	}
+
+	// Layer by longest path from a source
+	const visiting = -1
+	layers := map[string]int{}
+	var layerOf func(id string) int
+	layerOf = func(id string) int {
+		if l, ok := layers[id]; ok {
+			if l == visiting {
+				return -1 // Edge closes a cycle
+			}
+			return l
+		}
+		layers[id] = visiting
+		layer := 0
+		for _, p := range preds[id] {
+			if l := layerOf(p) + 1; l > layer {
+				layer = l
+			}
+		}
+		layers[id] = layer
+		return layer
+	}
+	maxLayer := 0
+	for _, id := range order {
+		nodes[id].Layer = layerOf(id)
+		if nodes[id].Layer > maxLayer {
+			maxLayer = nodes[id].Layer
+		}
+	}
+
+	// Assign bands: one per milestone with workstreams, then the rest
+	bandOf := map[string]int{}
+	var bandNames []string
+	var bandStatus []workstream.State
+	for _, m := range milestones {
+		band := -1
+		for _, req := range m.Requirements {
+			n, ok := nodes[req.WorkstreamProject+"/"+req.WorkstreamName]
+			if !ok {
+				continue
+			}
+			n.Milestones = append(n.Milestones, m.Name)
+			if _, placed := bandOf[n.ID]; placed {
+				continue
+			}
+			if band < 0 {
+				band = len(bandNames)
+				bandNames = append(bandNames, m.Name)
+				bandStatus = append(bandStatus, m.Status)
+			}
+			bandOf[n.ID] = band
+		}
+	}
+	rest := len(bandNames)
+	bandNames = append(bandNames, "")
+	bandStatus = append(bandStatus, "")
+
+	// Group nodes by band and layer, in name order to start with
+	type cell struct{ band, layer int }
+	groups := map[cell][]string{}
+	sort.SliceStable(order, func(i, j int) bool { return nodes[order[i]].Name < nodes[order[j]].Name })
+	for _, id := range order {
+		band, ok := bandOf[id]
+		if !ok {
+			band = rest
+		}
+		c := cell{band, nodes[id].Layer}
+		groups[c] = append(groups[c], id)
+	}
+
+	// Band heights fit their tallest layer
+	bandTop := make([]int, len(bandNames)+1)
+	bandTop[0] = graphMargin
+	for b := range bandNames {
+		rows := 0
+		for l := 0; l <= maxLayer; l++ {
+			if n := len(groups[cell{b, l}]); n > rows {
+				rows = n
+			}
+		}
+		height := 0
+		if rows > 0 {
+			height = rows*(graphNodeHeight+graphRowGap) - graphRowGap + 2*graphClusterPad + graphRowGap
+			if bandNames[b] != "" {
+				height += graphClusterHead
+			}
+		}
+		bandTop[b+1] = bandTop[b] + height
+	}
+
+	// Place layer by layer, ordering each cell by where its blockers sit so
+	// edges cross less
+	for l := 0; l <= maxLayer; l++ {
+		for b := range bandNames {
+			ids := groups[cell{b, l}]
+			key := func(id string) float64 {
+				sum, n := 0, 0
+				for _, p := range preds[id] {
+					if nodes[p].Layer < l {
+						sum += nodes[p].Y
+						n++
+					}
+				}
+				if n == 0 {
+					return 1e9
+				}
+				return float64(sum) / float64(n)
+			}
+			sort.SliceStable(ids, func(i, j int) bool { return key(ids[i]) < key(ids[j]) })
+			top := bandTop[b] + graphClusterPad
+			if bandNames[b] != "" {
+				top += graphClusterHead
+			}
+			for row, id := range ids {
+				n := nodes[id]
+				n.X = graphMargin + graphClusterPad + l*(graphNodeWidth+graphLayerGap)
+				n.Y = top + row*(graphNodeHeight+graphRowGap)
+			}
+		}
+	}
+
+	layout := graphLayout{
+		Width:  2*graphMargin + 2*graphClusterPad + (maxLayer+1)*(graphNodeWidth+graphLayerGap) - graphLayerGap,
+		Height: bandTop[len(bandNames)] + graphMargin,
+	}
+	if len(order) == 0 {
+		layout.Width, layout.Height = 0, 0
+	}
+
+	for b, name := range bandNames {
+		if name == "" {
+			continue
+		}
+		minX, maxX := -1, 0
+		for id, band := range bandOf {
+			if band != b {
+				continue
+			}
+			if x := nodes[id].X; minX < 0 || x < minX {
+				minX = x
+			}
+			if x := nodes[id].X + graphNodeWidth; x > maxX {
+				maxX = x
+			}
+		}
+		layout.Clusters = append(layout.Clusters, graphCluster{
+			Name:   name,
+			Status: bandStatus[b],
+			X:      minX - graphClusterPad,
+			Y:      bandTop[b],
+			W:      maxX - minX + 2*graphClusterPad,
+			H:      bandTop[b+1] - bandTop[b] - graphRowGap,
+			LabelX: minX - graphClusterPad + 8,
+			LabelY: bandTop[b] + 15,
+		})
+	}
+
+	// Nodes in layer then top-to-bottom order, for keyboard navigation
+	for _, id := range order {
+		layout.Nodes = append(layout.Nodes, *nodes[id])
+	}
+	sort.SliceStable(layout.Nodes, func(i, j int) bool {
+		a, b := layout.Nodes[i], layout.Nodes[j]
+		if a.Layer != b.Layer {
+			return a.Layer < b.Layer
+		}
+		return a.Y < b.Y
+	})
+
+	for _, e := range edges {
+		from, to := nodes[e.From], nodes[e.To]
+		x1, y1 := from.X+graphNodeWidth, from.Y+graphNodeHeight/2
+		x2, y2 := to.X, to.Y+graphNodeHeight/2
+		bend := (x2 - x1) / 2
+		if bend < graphLayerGap/2 {
+			bend = graphLayerGap / 2 // Cycle edges run backwards; keep them visible
+		}
+		e.Path = fmt.Sprintf("M %d %d C %d %d, %d %d, %d %d", x1, y1, x1+bend, y1, x2-bend, y2, x2, y2)
+		layout.Edges = append(layout.Edges, e)
+	}
+	return layout
+}
+
+// handleGraph renders the project's dependency graph as SVG
+func (s *Server) handleGraph(w http.ResponseWriter, r *http.Request) {
+	workstreams, err := s.store.List(store.Filter{Project: s.project})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	deps, err := s.store.Dependencies(s.project)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	milestones, err := s.store.ListMilestones(s.project)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	layout := layoutGraph(s.project, workstreams, deps, milestones)
+
+	// Colour workstreams from other projects by their own state
+	for i, n := range layout.Nodes {
+		if !n.External {
+			continue
+		}
+		if ws, err := s.store.Get(n.Project, n.Name); err == nil {
+			layout.Nodes[i].State = ws.State
+			layout.Nodes[i].NeedsHelp = ws.NeedsHelp
+		}
+	}
+
+	data := struct {
+		Project    string
+		Base       string
+		Graph      graphLayout
+		NodeWidth  int
+		NodeHeight int
+		Selected   string
+		Cursor     string
+	}{
+		Project:    s.project,
+		Base:       s.base,
+		Graph:      layout,
+		NodeWidth:  graphNodeWidth,
+		NodeHeight: graphNodeHeight,
+		Selected:   r.URL.Query().Get("ws"),
+		Cursor:     s.currentCursor(),
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := templates.ExecuteTemplate(w, "graph.html", data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
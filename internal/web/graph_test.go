@@ -0,0 +1,105 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/faraz/streamctl/pkg/workstream"
+)
+
+func TestLayoutGraph(t *testing.T) {
+	workstreams := []workstream.Workstream{
+		{Project: "p", Name: "schema", State: workstream.StateDone},
+		{Project: "p", Name: "api", State: workstream.StateInProgress},
+		{Project: "p", Name: "ui", State: workstream.StatePending},
+		{Project: "p", Name: "docs", State: workstream.StatePending},
+	}
+	deps := []workstream.Dependency{
+		{BlockerProject: "p", BlockerName: "schema", BlockedProject: "p", BlockedName: "api"},
+		{BlockerProject: "p", BlockerName: "api", BlockedProject: "p", BlockedName: "ui"},
+		{BlockerProject: "p", BlockerName: "schema", BlockedProject: "p", BlockedName: "ui"},
+		{BlockerProject: "other", BlockerName: "sdk", BlockedProject: "p", BlockedName: "docs"},
+	}
+	milestones := []workstream.Milestone{{
+		Name: "beta",
+		Requirements: []workstream.MilestoneRequirement{
+			{WorkstreamProject: "p", WorkstreamName: "api"},
+			{WorkstreamProject: "p", WorkstreamName: "ui"},
+		},
+	}}
+
+	g := layoutGraph("p", workstreams, deps, milestones)
+	nodes := map[string]graphNode{}
+	for _, n := range g.Nodes {
+		nodes[n.ID] = n
+	}
+
+	// Layers follow the longest chain of blockers
+	for id, layer := range map[string]int{"p/schema": 0, "p/api": 1, "p/ui": 2, "other/sdk": 0, "p/docs": 1} {
+		if nodes[id].Layer != layer {
+			t.Errorf("%s layer = %d, want %d", id, nodes[id].Layer, layer)
+		}
+	}
+	if !nodes["other/sdk"].External || nodes["p/api"].External {
+		t.Errorf("only workstreams from other projects should be external")
+	}
+	if len(g.Edges) != 4 {
+		t.Errorf("got %d edges, want 4", len(g.Edges))
+	}
+
+	// Milestone members share a cluster that contains them
+	if len(g.Clusters) != 1 || g.Clusters[0].Name != "beta" {
+		t.Fatalf("clusters = %+v", g.Clusters)
+	}
+	c := g.Clusters[0]
+	for _, id := range []string{"p/api", "p/ui"} {
+		n := nodes[id]
+		if n.X < c.X || n.X+graphNodeWidth > c.X+c.W || n.Y < c.Y || n.Y+graphNodeHeight > c.Y+c.H {
+			t.Errorf("%s at (%d,%d) outside cluster %+v", id, n.X, n.Y, c)
+		}
+	}
+	if n := nodes["p/schema"]; n.Y >= c.Y && n.Y < c.Y+c.H {
+		t.Errorf("schema should not be inside the beta cluster")
+	}
+	if g.Width <= 0 || g.Height <= 0 {
+		t.Errorf("size = %dx%d", g.Width, g.Height)
+	}
+}
+
+func TestLayoutGraph_Cycle(t *testing.T) {
+	workstreams := []workstream.Workstream{{Project: "p", Name: "a"}, {Project: "p", Name: "b"}}
+	deps := []workstream.Dependency{
+		{BlockerProject: "p", BlockerName: "a", BlockedProject: "p", BlockedName: "b"},
+		{BlockerProject: "p", BlockerName: "b", BlockedProject: "p", BlockedName: "a"},
+	}
+	g := layoutGraph("p", workstreams, deps, nil)
+	if len(g.Nodes) != 2 || len(g.Edges) != 2 {
+		t.Errorf("cyclic graph should still lay out, got %+v", g)
+	}
+}
+
+func TestServer_Graph(t *testing.T) {
+	st := setupTestStore(t)
+	st.Create(&workstream.Workstream{Project: "myproject", Name: "auth", State: workstream.StateDone})
+	st.Create(&workstream.Workstream{Project: "myproject", Name: "api", State: workstream.StateInProgress})
+	st.AddDependency("myproject", "auth", "myproject", "api")
+	srv := NewServer(st, "myproject")
+
+	req := httptest.NewRequest("GET", "/graph", nil)
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d", w.Code)
+	}
+	body := w.Body.String()
+	for _, want := range []string{"<svg", `class="node node-done"`, `class="node node-in_progress"`, `data-from="myproject/auth" data-to="myproject/api"`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("graph page should contain %q", want)
+		}
+	}
+	if strings.Contains(body, "cdn.") {
+		t.Errorf("graph page should not load from a CDN")
+	}
+}
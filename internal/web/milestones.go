@@ -0,0 +1,98 @@
+package web
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/faraz/streamctl/internal/store"
+	"github.com/faraz/streamctl/pkg/workstream"
+)
+
+// milestoneActivityLimit caps the activity shown on a milestone page
+const milestoneActivityLimit = 50
+
+// milestoneRequirement is a required workstream with the details the
+// milestone page shows; Workstream is nil if it could not be loaded
+type milestoneRequirement struct {
+	workstream.MilestoneRequirement
+	Workstream *workstream.Workstream
+	External   bool // In another project, so not linked
+	TasksDone  int
+	TasksTotal int
+}
+
+// milestoneRequirements loads the details of m's required workstreams
+func (s *Server) milestoneRequirements(m *workstream.Milestone) []milestoneRequirement {
+	requirements := make([]milestoneRequirement, 0, len(m.Requirements))
+	for _, req := range m.Requirements {
+		mr := milestoneRequirement{MilestoneRequirement: req, External: req.WorkstreamProject != s.project}
+		if ws, err := s.store.Get(req.WorkstreamProject, req.WorkstreamName); err == nil {
+			mr.Workstream = ws
+			mr.TasksDone, mr.TasksTotal = ws.TaskProgress()
+		}
+		requirements = append(requirements, mr)
+	}
+	return requirements
+}
+
+// handleMilestones lists the project's milestones with their progress
+func (s *Server) handleMilestones(w http.ResponseWriter, r *http.Request) {
+	milestones, err := s.store.ListMilestones(s.project)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data := struct {
+		Project    string
+		Base       string
+		Milestones []workstream.Milestone
+	}{
+		Project:    s.project,
+		Base:       s.base,
+		Milestones: milestones,
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := templates.ExecuteTemplate(w, "milestones.html", data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// handleMilestone shows one milestone: its requirements and their recent activity
+func (s *Server) handleMilestone(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/milestone/")
+	m, err := s.store.GetMilestone(s.project, name)
+	if name == "" || err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	requirements := s.milestoneRequirements(m)
+	activity, err := s.store.Activity(store.ActivityFilter{Project: s.project, Milestone: m.Name}, milestoneActivityLimit, 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data := struct {
+		Project      string
+		Base         string
+		Milestone    *workstream.Milestone
+		Requirements []milestoneRequirement
+		Activity     []workstream.ActivityEntry
+		Cursor       string
+	}{
+		Project:      s.project,
+		Base:         s.base,
+		Milestone:    m,
+		Requirements: requirements,
+		Activity:     activity,
+		Cursor:       s.currentCursor(),
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := templates.ExecuteTemplate(w, "milestone.html", data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
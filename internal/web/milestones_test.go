@@ -0,0 +1,91 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/faraz/streamctl/internal/store"
+	"github.com/faraz/streamctl/pkg/workstream"
+)
+
+func setupMilestone(t *testing.T) *Server {
+	t.Helper()
+	st := setupTestStore(t)
+	st.Create(&workstream.Workstream{Project: "myproject", Name: "auth", State: workstream.StateDone})
+	st.Create(&workstream.Workstream{Project: "myproject", Name: "api", State: workstream.StateInProgress, Owner: "alice"})
+	st.Create(&workstream.Workstream{Project: "myproject", Name: "docs", State: workstream.StatePending})
+	st.Create(&workstream.Workstream{Project: "other", Name: "sdk", State: workstream.StatePending})
+	st.AddDependency("myproject", "auth", "myproject", "api")
+	st.AddTask("myproject", "api", "write handlers")
+	for _, name := range []string{"api", "docs"} {
+		entry := "progress on " + name
+		st.Update("myproject", name, store.WorkstreamUpdate{LogEntry: &entry})
+	}
+	st.CreateMilestone(&workstream.Milestone{Project: "myproject", Name: "beta", Description: "First public release"})
+	st.AddMilestoneRequirement("myproject", "beta", "myproject", "auth")
+	st.AddMilestoneRequirement("myproject", "beta", "myproject", "api")
+	st.AddMilestoneRequirement("myproject", "beta", "other", "sdk")
+	return NewServer(st, "myproject")
+}
+
+func TestServer_Milestones(t *testing.T) {
+	srv := setupMilestone(t)
+
+	req := httptest.NewRequest("GET", "/milestones", nil)
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d", w.Code)
+	}
+	body := w.Body.String()
+	for _, want := range []string{`data-href="/milestone/beta"`, "First public release", "1/3 workstreams done", "width: 33%"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("milestones page should contain %q", want)
+		}
+	}
+}
+
+func TestServer_Milestone(t *testing.T) {
+	srv := setupMilestone(t)
+
+	req := httptest.NewRequest("GET", "/milestone/beta", nil)
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d", w.Code)
+	}
+	body := w.Body.String()
+	for _, want := range []string{
+		`data-href="/workstream/api"`,
+		"@alice",
+		"Blocked by myproject/auth",
+		"0/1 tasks",
+		"other/sdk",
+		"progress on api",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("milestone page should contain %q", want)
+		}
+	}
+	if strings.Contains(body, `data-href="/workstream/sdk"`) {
+		t.Errorf("workstreams in other projects should not be linked")
+	}
+	if strings.Contains(body, "progress on docs") {
+		t.Errorf("activity should be limited to required workstreams")
+	}
+}
+
+func TestServer_MilestoneNotFound(t *testing.T) {
+	srv := setupMilestone(t)
+
+	for _, path := range []string{"/milestone/missing", "/milestone/"} {
+		req := httptest.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("GET %s status = %d, want 404", path, w.Code)
+		}
+	}
+}
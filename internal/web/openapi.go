@@ -0,0 +1,209 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// handleOpenAPI serves the OpenAPI 3 document for the REST API, generated
+// from the route table
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(openAPIDocument(s.apiTable()))
+}
+
+var pathParam = regexp.MustCompile(`\{([a-z_]+)\}`)
+
+// openAPIDocument describes routes as an OpenAPI 3.0 document
+func openAPIDocument(routes []apiRoute) map[string]any {
+	g := &schemaGen{components: map[string]any{}}
+	errorRef := g.schema(reflect.TypeFor[apiError]())
+	errorResponse := func(description string) map[string]any {
+		return map[string]any{
+			"description": description,
+			"content": map[string]any{"application/json": map[string]any{
+				"schema": map[string]any{
+					"type":       "object",
+					"properties": map[string]any{"error": errorRef},
+					"required":   []string{"error"},
+				},
+			}},
+		}
+	}
+
+	paths := map[string]map[string]any{}
+	for _, route := range routes {
+		var params []any
+		for _, m := range pathParam.FindAllStringSubmatch(route.Path, -1) {
+			params = append(params, map[string]any{"name": m[1], "in": "path", "required": true, "schema": map[string]any{"type": "string"}})
+		}
+		for _, q := range route.Query {
+			params = append(params, map[string]any{"name": q.Name, "in": "query", "description": q.Description, "schema": map[string]any{"type": "string"}})
+		}
+
+		status := route.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		responses := map[string]any{
+			"400": errorResponse("Invalid request"),
+			"404": errorResponse("Not found, or not readable by the caller"),
+		}
+		if route.Response == nil {
+			responses["204"] = map[string]any{"description": "Done"}
+		} else {
+			schema := g.schema(reflect.TypeOf(route.Response))
+			if route.Paged {
+				schema = map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"items":       map[string]any{"type": "array", "items": schema},
+						"next_cursor": map[string]any{"type": "string", "description": "Pass as cursor for the next page; absent on the last page"},
+					},
+					"required": []string{"items"},
+				}
+			}
+			responses[strconv.Itoa(status)] = map[string]any{
+				"description": http.StatusText(status),
+				"content":     map[string]any{"application/json": map[string]any{"schema": schema}},
+			}
+		}
+
+		op := map[string]any{
+			"summary":     route.Summary,
+			"operationId": operationID(route),
+			"responses":   responses,
+		}
+		if len(params) > 0 {
+			op["parameters"] = params
+		}
+		if route.Request != nil {
+			op["requestBody"] = map[string]any{
+				"required": true,
+				"content":  map[string]any{"application/json": map[string]any{"schema": g.schema(reflect.TypeOf(route.Request))}},
+			}
+		}
+		if route.Write {
+			responses["401"] = errorResponse("Writes need a token or sign-in")
+			responses["403"] = errorResponse("The caller may not change this project")
+		}
+		if route.etag != nil {
+			if route.Write {
+				responses["412"] = errorResponse("If-Match does not match the current ETag")
+			} else {
+				responses["304"] = map[string]any{"description": "Not modified since the If-None-Match ETag"}
+			}
+		}
+		if route.Method == http.MethodPost && route.Request != nil {
+			responses["409"] = errorResponse("Already exists")
+		}
+
+		path := apiPrefix + route.Path
+		if paths[path] == nil {
+			paths[path] = map[string]any{}
+		}
+		paths[path][strings.ToLower(route.Method)] = op
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   "streamctl",
+			"version": "1",
+			"description": "Workstreams and milestones by project. Responses for a workstream or milestone, " +
+				"and its tasks, logs, dependencies or requirements, carry the ETag of that workstream or " +
+				"milestone; send it as If-Match on writes to fail with 412 if it changed meanwhile.",
+		},
+		"servers": []any{map[string]any{"url": "/"}},
+		"paths":   paths,
+		"components": map[string]any{
+			"schemas": g.components,
+			"securitySchemes": map[string]any{
+				"bearer": map[string]any{"type": "http", "scheme": "bearer"},
+				"basic":  map[string]any{"type": "http", "scheme": "basic"},
+			},
+		},
+		"security": []any{map[string]any{"bearer": []string{}}, map[string]any{"basic": []string{}}, map[string]any{}},
+	}
+}
+
+// operationID names a route from its method and path, e.g.
+// patch_projects_workstreams_tasks
+func operationID(route apiRoute) string {
+	parts := []string{strings.ToLower(route.Method)}
+	for _, seg := range strings.Split(strings.Trim(route.Path, "/"), "/") {
+		if !strings.HasPrefix(seg, "{") {
+			parts = append(parts, seg)
+		}
+	}
+	if strings.HasSuffix(route.Path, "}") && route.Method == http.MethodGet {
+		parts = append(parts, "item")
+	}
+	return strings.Join(parts, "_")
+}
+
+// schemaGen derives JSON schemas from Go types, collecting named structs
+// as components
+type schemaGen struct {
+	components map[string]any
+}
+
+var timeType = reflect.TypeFor[time.Time]()
+
+func (g *schemaGen) schema(t reflect.Type) map[string]any {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.String:
+		return map[string]any{"type": "string"}
+	case t.Kind() == reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		return map[string]any{"type": "number"}
+	case t.Kind() == reflect.Slice:
+		return map[string]any{"type": "array", "items": g.schema(t.Elem())}
+	case t.Kind() == reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case t.Kind() == reflect.Struct:
+		name := strings.TrimPrefix(t.Name(), "api")
+		ref := map[string]any{"$ref": "#/components/schemas/" + name}
+		if _, ok := g.components[name]; ok {
+			return ref
+		}
+		g.components[name] = nil // Placeholder, for recursive types
+		props := map[string]any{}
+		var required []string
+		for i := range t.NumField() {
+			f := t.Field(i)
+			tag := f.Tag.Get("json")
+			if tag == "-" || !f.IsExported() {
+				continue
+			}
+			key, opts, _ := strings.Cut(tag, ",")
+			if key == "" {
+				key = f.Name
+			}
+			props[key] = g.schema(f.Type)
+			if !strings.Contains(opts, "omitempty") && f.Type.Kind() != reflect.Pointer {
+				required = append(required, key)
+			}
+		}
+		schema := map[string]any{"type": "object", "properties": props}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		g.components[name] = schema
+		return ref
+	}
+	return map[string]any{}
+}
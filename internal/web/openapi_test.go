@@ -0,0 +1,61 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestOpenAPI(t *testing.T) {
+	_, srv := setupMulti(t)
+
+	w := get(srv, "/api/v1/openapi.json")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d", w.Code)
+	}
+	var doc struct {
+		OpenAPI    string                               `json:"openapi"`
+		Paths      map[string]map[string]map[string]any `json:"paths"`
+		Components struct {
+			Schemas map[string]map[string]any `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("decoding: %v", err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		t.Errorf("openapi = %q", doc.OpenAPI)
+	}
+
+	// Every route is documented, with a unique operation ID
+	ids := map[string]bool{}
+	for _, route := range srv.apiTable() {
+		op, ok := doc.Paths[apiPrefix+route.Path][strings.ToLower(route.Method)]
+		if !ok {
+			t.Errorf("%s %s is not documented", route.Method, route.Path)
+			continue
+		}
+		id := op["operationId"].(string)
+		if ids[id] {
+			t.Errorf("duplicate operationId %s", id)
+		}
+		ids[id] = true
+	}
+
+	ws := doc.Components.Schemas["Workstream"]
+	if ws == nil {
+		t.Fatalf("Workstream schema missing; have %v", doc.Components.Schemas)
+	}
+	props := ws["properties"].(map[string]any)
+	if props["tasks"].(map[string]any)["items"].(map[string]any)["$ref"] != "#/components/schemas/Task" {
+		t.Errorf("tasks = %v, want an array of Task", props["tasks"])
+	}
+	if props["last_update"].(map[string]any)["format"] != "date-time" {
+		t.Errorf("last_update = %v, want a date-time", props["last_update"])
+	}
+	update := doc.Components.Schemas["UpdateWorkstream"]
+	if _, ok := update["required"]; ok {
+		t.Errorf("UpdateWorkstream's fields should all be optional: %v", update["required"])
+	}
+}
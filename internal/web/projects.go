@@ -0,0 +1,170 @@
+package web
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/faraz/streamctl/internal/store"
+	"github.com/faraz/streamctl/pkg/workstream"
+)
+
+// NewMultiServer creates a web server for every project in the store. The
+// home page summarises each project; a project's dashboard lives under
+// /p/PROJECT/, with the same pages as NewServer serves at /. The REST API
+// serves every project at /api/v1.
+func NewMultiServer(st *store.Store) *Server {
+	s := &Server{
+		store:        st,
+		mux:          http.NewServeMux(),
+		pollInterval: time.Second,
+		stale:        staleRules(),
+		projects:     map[string]*Server{},
+	}
+	s.mux.HandleFunc("/", s.handleProjects)
+	s.apiRoutes()
+	return s
+}
+
+// serveMulti routes /p/PROJECT/... to that project's server, for projects p
+// may read
+func (s *Server) serveMulti(w http.ResponseWriter, r *http.Request, p *Principal) {
+	rest, ok := strings.CutPrefix(r.URL.Path, "/p/")
+	if !ok {
+		s.mux.ServeHTTP(w, r)
+		return
+	}
+	project, path, found := strings.Cut(rest, "/")
+	if project == "" || !p.CanRead(project) || !s.hasProject(project) {
+		http.NotFound(w, r)
+		return
+	}
+	if !found {
+		http.Redirect(w, r, "/p/"+project+"/", http.StatusMovedPermanently)
+		return
+	}
+
+	r2 := r.Clone(r.Context())
+	r2.URL.Path = "/" + path
+	r2.URL.RawPath = ""
+	s.projectServer(project).mux.ServeHTTP(w, r2)
+}
+
+func (s *Server) hasProject(project string) bool {
+	projects, err := s.store.ListProjects()
+	if err != nil {
+		return false
+	}
+	for _, p := range projects {
+		if p == project {
+			return true
+		}
+	}
+	return false
+}
+
+// projectServer returns the server for one project's pages, sharing this
+// server's store and principals
+func (s *Server) projectServer(project string) *Server {
+	s.projectsMu.Lock()
+	defer s.projectsMu.Unlock()
+	if ps, ok := s.projects[project]; ok {
+		return ps
+	}
+	ps := &Server{
+		store:        s.store,
+		project:      project,
+		base:         "/p/" + project,
+		mux:          http.NewServeMux(),
+		pollInterval: s.pollInterval,
+		stale:        s.stale,
+		principals:   s.principals,
+		authRequired: s.authRequired,
+		csrfKey:      s.csrfKey,
+		linkProjects: s.linkProjects,
+	}
+	ps.routes()
+	s.projects[project] = ps
+	return ps
+}
+
+// projectSummary is a project's headline counts on the home page
+type projectSummary struct {
+	Name       string
+	Total      int
+	NeedsHelp  int
+	Blocked    int
+	Stale      int
+	InProgress int
+	Done       int
+	LastUpdate time.Time
+	Idle       time.Duration // Since LastUpdate
+}
+
+// handleProjects is the multi-project home page: one row per project
+func (s *Server) handleProjects(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+
+	all, err := s.store.ListProjects()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var projects []string
+	if p, _ := s.authenticate(r); p != nil {
+		for _, project := range all {
+			if p.CanRead(project) {
+				projects = append(projects, project)
+			}
+		}
+	}
+
+	summaries := make([]projectSummary, 0, len(projects))
+	for _, p := range projects {
+		snaps, err := s.store.Snapshots(p, time.Time{})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		sum := projectSummary{Name: p, Total: len(snaps)}
+		now := time.Now()
+		for _, snap := range snaps {
+			if snap.NeedsHelp {
+				sum.NeedsHelp++
+			}
+			if snap.Blocked {
+				sum.Blocked++
+			}
+			if s.stale.Stale(snap.State, snap.LastUpdate, now) {
+				sum.Stale++
+			}
+			switch snap.State {
+			case workstream.StateInProgress:
+				sum.InProgress++
+			case workstream.StateDone:
+				sum.Done++
+			}
+			if snap.LastUpdate.After(sum.LastUpdate) {
+				sum.LastUpdate = snap.LastUpdate
+			}
+		}
+		if !sum.LastUpdate.IsZero() {
+			sum.Idle = time.Since(sum.LastUpdate)
+		}
+		summaries = append(summaries, sum)
+	}
+
+	data := struct {
+		Projects []projectSummary
+	}{
+		Projects: summaries,
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := templates.ExecuteTemplate(w, "projects.html", data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,146 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/faraz/streamctl/internal/store"
+	"github.com/faraz/streamctl/pkg/workstream"
+)
+
+func setupMulti(t *testing.T) (*store.Store, *Server) {
+	t.Helper()
+	st := setupTestStore(t)
+	st.Create(&workstream.Workstream{Project: "alpha", Name: "auth", State: workstream.StateInProgress})
+	st.Create(&workstream.Workstream{Project: "alpha", Name: "api", State: workstream.StateDone})
+	st.Create(&workstream.Workstream{Project: "beta", Name: "docs", State: workstream.StatePending})
+	st.Create(&workstream.Workstream{Project: "beta", Name: "site", State: workstream.StatePending})
+	needsHelp := true
+	st.Update("beta", "docs", store.WorkstreamUpdate{NeedsHelp: &needsHelp})
+	st.AddDependency("beta", "docs", "beta", "site")
+	return st, NewMultiServer(st)
+}
+
+func get(srv http.Handler, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+	return w
+}
+
+func TestMultiServer_Home(t *testing.T) {
+	_, srv := setupMulti(t)
+
+	w := get(srv, "/")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d", w.Code)
+	}
+	body := w.Body.String()
+	for _, want := range []string{
+		`data-href="/p/alpha/"`,
+		`data-href="/p/beta/"`,
+		"1 in progress",
+		"1/2 done",
+		"1 needs help",
+		"1 blocked",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("home page should contain %q", want)
+		}
+	}
+}
+
+func TestMultiServer_ProjectPages(t *testing.T) {
+	_, srv := setupMulti(t)
+
+	w := get(srv, "/p/alpha/")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d", w.Code)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, `const base = "/p/alpha"`) {
+		t.Errorf("project pages should link under /p/alpha")
+	}
+	if !strings.Contains(body, `href="/" class="header-projects"`) {
+		t.Errorf("project dashboard should link back to the project list")
+	}
+	if strings.Contains(body, "docs") {
+		t.Errorf("alpha's dashboard should not show beta's workstreams")
+	}
+
+	for path, code := range map[string]int{
+		"/p/alpha/workstream/auth": http.StatusOK,
+		"/p/alpha/workstream/docs": http.StatusNotFound,
+		"/p/beta/board":            http.StatusOK,
+		"/p/missing/":              http.StatusNotFound,
+		"/p/":                      http.StatusNotFound,
+		"/workstream/auth":         http.StatusNotFound,
+	} {
+		if w := get(srv, path); w.Code != code {
+			t.Errorf("GET %s status = %d, want %d", path, w.Code, code)
+		}
+	}
+
+	w = get(srv, "/p/alpha")
+	if w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != "/p/alpha/" {
+		t.Errorf("GET /p/alpha = %d to %q, want redirect to /p/alpha/", w.Code, w.Header().Get("Location"))
+	}
+
+	w = get(srv, "/p/alpha/workstream/auth")
+	if !strings.Contains(w.Body.String(), `href="/p/alpha/"`) {
+		t.Errorf("workstream breadcrumb should link to the project dashboard")
+	}
+}
+
+func TestMultiServer_SingleProjectUnprefixed(t *testing.T) {
+	st, _ := setupMulti(t)
+	srv := NewServer(st, "alpha")
+
+	body := get(srv, "/").Body.String()
+	if !strings.Contains(body, `const base = ""`) || strings.Contains(body, `class="header-projects"`) {
+		t.Errorf("a single-project server should keep links at the root")
+	}
+}
+
+func TestMultiServer_Edit(t *testing.T) {
+	st, srv := setupMulti(t)
+	srv.EnableEditing("secret")
+
+	// Signing in anywhere covers every project
+	w := get(srv, "/?token=secret")
+	cookies := w.Result().Cookies()
+	if w.Code != http.StatusSeeOther || len(cookies) != 1 {
+		t.Fatalf("sign-in status = %d, cookies = %v", w.Code, cookies)
+	}
+
+	form := url.Values{"action": {"state"}, "state": {"done"}}
+	req := httptest.NewRequest("POST", "/p/beta/edit/docs", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "Bearer secret")
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("edit status = %d: %s", w.Code, w.Body.String())
+	}
+	if ws, _ := st.Get("beta", "docs"); ws.State != workstream.StateDone {
+		t.Errorf("state = %s, want done", ws.State)
+	}
+}
+
+func TestMultiServer_AnswerRedirectKeepsPrefix(t *testing.T) {
+	st, srv := setupMulti(t)
+	st.RequestHelp("beta", "docs", "which style guide?", "agent-1")
+	srv.EnableEditing("secret")
+
+	form := url.Values{"workstream": {"docs"}, "answer": {"the house one"}}
+	req := httptest.NewRequest("POST", "/p/beta/answer", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "Bearer secret")
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/p/beta/workstream/docs" {
+		t.Errorf("answer = %d to %q, want redirect to /p/beta/workstream/docs", w.Code, w.Header().Get("Location"))
+	}
+}
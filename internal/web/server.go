@@ -5,8 +5,11 @@ import (
 	"encoding/json"
 	"html/template"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/faraz/streamctl/internal/store"
 	"github.com/faraz/streamctl/pkg/workstream"
@@ -16,7 +19,15 @@ import (
 var templateFS embed.FS
 
 var funcMap = template.FuncMap{
-	"add": func(a, b int) int { return a + b },
+	"add":      func(a, b int) int { return a + b },
+	"duration": workstream.FormatDuration,
+	"percent": func(n, total int) int {
+		if total == 0 {
+			return 0
+		}
+		return n * 100 / total
+	},
+	"siteHref": siteHref,
 	"json": func(v any) template.JS {
 		b, _ := json.Marshal(v)
 		return template.JS(b)
@@ -28,26 +39,88 @@ var templates = template.Must(template.New("").Funcs(funcMap).ParseFS(templateFS
 // Server serves the web UI for workstreams.
 type Server struct {
 	store   *store.Store
-	project string
+	project string // "" for a multi-project server (see NewMultiServer)
+	base    string // URL prefix of this project's pages: "" or /p/PROJECT
 	mux     *http.ServeMux
+
+	// How often /api/events checks the store for changes
+	pollInterval time.Duration
+
+	// When workstreams count as stale, from STREAMCTL_STALE
+	stale workstream.StaleRules
+
+	// Who may read and write (see EnableAuth and EnableEditing). Without
+	// auth, anyone may read and only principals may write.
+	principals   []Principal
+	authRequired bool
+	csrfKey      []byte
+
+	// Projects whose workstreams edits may link to as blockers, besides
+	// this one (see LimitLinks); nil for any the principal may write
+	linkProjects []string
+
+	// Per-project servers behind a multi-project server, by project
+	projectsMu sync.Mutex
+	projects   map[string]*Server
+
+	// REST API routes (see apiRoutes), and a lock held by its writes
+	api   *http.ServeMux
+	apiMu sync.Mutex
 }
 
 // NewServer creates a new web server for the given project.
 func NewServer(st *store.Store, project string) *Server {
 	s := &Server{
-		store:   st,
-		project: project,
-		mux:     http.NewServeMux(),
+		store:        st,
+		project:      project,
+		mux:          http.NewServeMux(),
+		pollInterval: time.Second,
+		stale:        staleRules(),
 	}
+	s.routes()
+	s.apiRoutes()
+	return s
+}
+
+// routes registers a project's pages on s.mux
+func (s *Server) routes() {
 	s.mux.HandleFunc("/", s.handleIndex)
 	s.mux.HandleFunc("/workstream/", s.handleWorkstream)
 	s.mux.HandleFunc("/search", s.handleSearch)
+	s.mux.HandleFunc("/decisions", s.handleDecisions)
+	s.mux.HandleFunc("/board", s.handleBoard)
+	s.mux.HandleFunc("/graph", s.handleGraph)
+	s.mux.HandleFunc("/timeline", s.handleTimeline)
+	s.mux.HandleFunc("/stats", s.handleStats)
+	s.mux.HandleFunc("/milestones", s.handleMilestones)
+	s.mux.HandleFunc("/milestone/", s.handleMilestone)
+	s.mux.HandleFunc("/feed.atom", s.handleFeed)
+	s.mux.HandleFunc("/workstream/{name}/feed.atom", s.handleWorkstreamFeed)
+	s.mux.HandleFunc("/milestone/{name}/feed.atom", s.handleMilestoneFeed)
+	s.mux.HandleFunc("/answer", s.handleAnswer)
+	s.mux.HandleFunc("/edit/", s.handleEdit)
 	s.mux.HandleFunc("/api/activity", s.handleActivityAPI)
 	s.mux.HandleFunc("/api/search", s.handleSearchAPI)
-	return s
+	s.mux.HandleFunc("/api/events", s.handleEvents)
 }
 
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.signIn(w, r) {
+		return
+	}
+	p, _ := s.authenticate(r)
+	if p == nil {
+		unauthorized(w)
+		return
+	}
+	if s.project == "" {
+		s.serveMulti(w, r, p)
+		return
+	}
+	if !p.CanRead(s.project) {
+		http.Error(w, p.Name+" may not view "+s.project, http.StatusForbidden)
+		return
+	}
 	s.mux.ServeHTTP(w, r)
 }
 
@@ -65,7 +138,12 @@ func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
 
 	// Get recent activity (fetch one extra to check if there's more)
 	const pageSize = 20
-	activity, err := s.store.RecentActivity(s.project, pageSize+1, 0)
+	filter := store.ActivityFilter{
+		Project: s.project,
+		Author:  r.URL.Query().Get("author"),
+		Session: r.URL.Query().Get("session"),
+	}
+	activity, err := s.store.Activity(filter, pageSize+1, 0)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -76,9 +154,19 @@ func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
 		activity = activity[:pageSize]
 	}
 
+	sessions, err := s.store.ActiveSessions(s.project)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
 	// Compute insights
-	var blocked, needsHelp, inProgress []workstream.Workstream
+	var blocked, needsHelp, inProgress, stale []workstream.Workstream
+	now := time.Now()
 	for _, ws := range workstreams {
+		if s.stale.Stale(ws.State, ws.LastUpdate, now) {
+			stale = append(stale, ws)
+		}
 		if ws.State == workstream.StateBlocked || len(ws.BlockedBy) > 0 {
 			blocked = append(blocked, ws)
 		}
@@ -92,20 +180,30 @@ func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
 
 	data := struct {
 		Project     string
+		Base        string
 		Workstreams []workstream.Workstream
 		Activity    []workstream.ActivityEntry
 		Blocked     []workstream.Workstream
 		NeedsHelp   []workstream.Workstream
 		InProgress  []workstream.Workstream
+		Stale       []workstream.Workstream
 		HasMore     bool
+		Filter      store.ActivityFilter
+		Sessions    []workstream.Session
+		Cursor      string
 	}{
 		Project:     s.project,
+		Base:        s.base,
 		Workstreams: workstreams,
 		Activity:    activity,
 		Blocked:     blocked,
 		NeedsHelp:   needsHelp,
 		InProgress:  inProgress,
+		Stale:       stale,
 		HasMore:     hasMore,
+		Filter:      filter,
+		Sessions:    sessions,
+		Cursor:      s.currentCursor(),
 	}
 
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
@@ -136,12 +234,18 @@ func (s *Server) handleWorkstream(w http.ResponseWriter, r *http.Request) {
 
 	data := struct {
 		Project        string
+		Base           string
 		Workstream     *workstream.Workstream
 		AllWorkstreams []workstream.Workstream
+		Cursor         string
+		CSRF           string // Set when this browser may edit
 	}{
 		Project:        s.project,
+		Base:           s.base,
 		Workstream:     ws,
 		AllWorkstreams: allWorkstreams,
+		Cursor:         s.currentCursor(),
+		CSRF:           s.csrfToken(r),
 	}
 
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
@@ -150,6 +254,53 @@ func (s *Server) handleWorkstream(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// handleAnswer records a human's answer to a workstream's help request
+func (s *Server) handleAnswer(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !sameOrigin(r) {
+		http.Error(w, "cross-origin request rejected", http.StatusForbidden)
+		return
+	}
+
+	// Answering is a write, so it needs the same access as an edit
+	p := s.writer(w, r)
+	if p == nil {
+		return
+	}
+
+	name := r.FormValue("workstream")
+	by := strings.TrimSpace(r.FormValue("by"))
+	if s.authRequired || by == "" {
+		by = p.Name
+	}
+	if _, err := s.store.AnswerHelp(s.project, name, r.FormValue("answer"), by); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.audit(p, s.project, name, "answer", "answered help request")
+
+	http.Redirect(w, r, s.base+"/workstream/"+url.PathEscape(name), http.StatusSeeOther)
+}
+
+// sameOrigin reports whether a browser request came from a page on this
+// server, judged by its Origin (or, failing that, Referer) header
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		origin = r.Header.Get("Referer")
+	}
+	if origin == "" {
+		// Non-browser clients (curl, scripts) don't send either
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && u.Host == r.Host
+}
+
 func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
 	workstreams, err := s.store.List(store.Filter{Project: s.project})
 	if err != nil {
@@ -159,10 +310,14 @@ func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
 
 	data := struct {
 		Project     string
+		Base        string
 		Workstreams []workstream.Workstream
+		Cursor      string
 	}{
 		Project:     s.project,
+		Base:        s.base,
 		Workstreams: workstreams,
+		Cursor:      s.currentCursor(),
 	}
 
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
@@ -171,6 +326,31 @@ func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (s *Server) handleDecisions(w http.ResponseWriter, r *http.Request) {
+	// The register is the whole project's history, so no paging
+	const maxDecisions = 1000
+	decisions, err := s.store.Activity(store.ActivityFilter{Project: s.project, Type: workstream.EntryDecision}, maxDecisions, 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data := struct {
+		Project   string
+		Base      string
+		Decisions []workstream.ActivityEntry
+	}{
+		Project:   s.project,
+		Base:      s.base,
+		Decisions: decisions,
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := templates.ExecuteTemplate(w, "decisions.html", data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
 func (s *Server) handleActivityAPI(w http.ResponseWriter, r *http.Request) {
 	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
 	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
@@ -178,7 +358,22 @@ func (s *Server) handleActivityAPI(w http.ResponseWriter, r *http.Request) {
 		limit = 20
 	}
 
-	activity, err := s.store.RecentActivity(s.project, limit+1, offset)
+	var entryType workstream.EntryType
+	if t := r.URL.Query().Get("type"); t != "" {
+		var err error
+		if entryType, err = workstream.ParseEntryType(t); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	filter := store.ActivityFilter{
+		Project: s.project,
+		Type:    entryType,
+		Author:  r.URL.Query().Get("author"),
+		Session: r.URL.Query().Get("session"),
+	}
+	activity, err := s.store.Activity(filter, limit+1, offset)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -189,26 +384,9 @@ func (s *Server) handleActivityAPI(w http.ResponseWriter, r *http.Request) {
 		activity = activity[:limit]
 	}
 
-	// Convert to JSON-friendly format
-	type jsonEntry struct {
-		WorkstreamName string `json:"workstreamName"`
-		Timestamp      int64  `json:"timestamp"`
-		Content        string `json:"content"`
-		NeedsHelp      bool   `json:"needsHelp"`
-		BlockedBy      string `json:"blockedBy,omitempty"`
-		RelativeTime   string `json:"relativeTime"`
-	}
-
-	entries := make([]jsonEntry, len(activity))
+	entries := make([]activityJSON, len(activity))
 	for i, e := range activity {
-		entries[i] = jsonEntry{
-			WorkstreamName: e.WorkstreamName,
-			Timestamp:      e.Timestamp.Unix(),
-			Content:        e.Content,
-			NeedsHelp:      e.NeedsHelp,
-			BlockedBy:      e.BlockedBy,
-			RelativeTime:   e.RelativeTime,
-		}
+		entries[i] = newActivityJSON(e)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -218,6 +396,39 @@ func (s *Server) handleActivityAPI(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// activityJSON is an activity entry as sent to the browser
+type activityJSON struct {
+	WorkstreamName string   `json:"workstreamName"`
+	Timestamp      int64    `json:"timestamp"`
+	Content        string   `json:"content"`
+	Type           string   `json:"type"`
+	Alternatives   []string `json:"alternatives,omitempty"`
+	Rationale      string   `json:"rationale,omitempty"`
+	Author         string   `json:"author,omitempty"`
+	Client         string   `json:"client,omitempty"`
+	SessionID      string   `json:"sessionId,omitempty"`
+	NeedsHelp      bool     `json:"needsHelp"`
+	BlockedBy      string   `json:"blockedBy,omitempty"`
+	RelativeTime   string   `json:"relativeTime"`
+}
+
+func newActivityJSON(e workstream.ActivityEntry) activityJSON {
+	return activityJSON{
+		WorkstreamName: e.WorkstreamName,
+		Timestamp:      e.Timestamp.Unix(),
+		Content:        e.Content,
+		Type:           string(e.Type),
+		Alternatives:   e.Alternatives,
+		Rationale:      e.Rationale,
+		Author:         e.Author,
+		Client:         e.Client,
+		SessionID:      e.SessionID,
+		NeedsHelp:      e.NeedsHelp,
+		BlockedBy:      e.BlockedBy,
+		RelativeTime:   e.RelativeTime,
+	}
+}
+
 func (s *Server) handleSearchAPI(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query().Get("q")
 	wsFilter := r.URL.Query().Get("ws")
@@ -231,3 +442,10 @@ func (s *Server) handleSearchAPI(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(results)
 }
+
+// staleRules are the rules from STREAMCTL_STALE, or the defaults if it is
+// unset or invalid
+func staleRules() workstream.StaleRules {
+	rules, _ := workstream.StaleRulesFromEnv()
+	return rules
+}
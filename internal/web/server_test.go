@@ -1,11 +1,14 @@
 package web
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/faraz/streamctl/internal/store"
 	"github.com/faraz/streamctl/pkg/workstream"
@@ -254,6 +257,72 @@ func TestServer_Workstream_ShowsDetails(t *testing.T) {
 	}
 }
 
+func TestServer_Workstream_ShowsTaskIDs(t *testing.T) {
+	st := setupTestStore(t)
+
+	st.Create(&workstream.Workstream{
+		Project: "myproject",
+		Name:    "auth",
+		State:   workstream.StateInProgress,
+		Plan:    []workstream.PlanItem{{Text: "Design schema"}},
+	})
+	ws, _ := st.Get("myproject", "auth")
+
+	srv := NewServer(st, "myproject")
+
+	req := httptest.NewRequest("GET", "/workstream/auth", nil)
+	w := httptest.NewRecorder()
+
+	srv.ServeHTTP(w, req)
+
+	body := w.Body.String()
+	if !strings.Contains(body, `id="task-`+ws.Plan[0].ID+`"`) {
+		t.Errorf("body should anchor task by its stable ID %s", ws.Plan[0].ID)
+	}
+	if !strings.Contains(body, `<span class="task-id">`+ws.Plan[0].ID+`</span>`) {
+		t.Errorf("body should display task ID %s", ws.Plan[0].ID)
+	}
+}
+
+func TestServer_Workstream_RendersSubtaskTree(t *testing.T) {
+	st := setupTestStore(t)
+
+	st.Create(&workstream.Workstream{
+		Project: "myproject",
+		Name:    "auth",
+		State:   workstream.StateInProgress,
+		Plan: []workstream.PlanItem{
+			{Text: "Login flow", Children: []workstream.PlanItem{
+				{Text: "Form", Status: workstream.TaskDone},
+				{Text: "Session cookie", Children: []workstream.PlanItem{
+					{Text: "Set SameSite"},
+				}},
+			}},
+		},
+	})
+
+	srv := NewServer(st, "myproject")
+
+	req := httptest.NewRequest("GET", "/workstream/auth", nil)
+	w := httptest.NewRecorder()
+
+	srv.ServeHTTP(w, req)
+
+	body := w.Body.String()
+	if !strings.Contains(body, `<details class="subtask-tree"`) {
+		t.Error("body should render subtasks as a collapsible tree")
+	}
+	if !strings.Contains(body, "1/2 subtasks done") {
+		t.Error("body should summarise subtask progress")
+	}
+	if !strings.Contains(body, "Set SameSite") {
+		t.Error("body should render nested subtasks")
+	}
+	if !strings.Contains(body, "expandSubtasks") {
+		t.Error("body should support keyboard expand of subtask trees")
+	}
+}
+
 func TestServer_Workstream_LongObjective_IsFeedItem(t *testing.T) {
 	st := setupTestStore(t)
 
@@ -337,3 +406,258 @@ func TestServer_Workstream_ShortObjective_NotInFeed(t *testing.T) {
 		}
 	}
 }
+
+func createDecisionFixture(t *testing.T, st *store.Store) {
+	t.Helper()
+	ws := &workstream.Workstream{
+		Project: "myproject",
+		Name:    "auth",
+		State:   workstream.StateInProgress,
+		Log: []workstream.LogEntry{
+			{Timestamp: time.Now().Add(-time.Hour), Content: "Started on login form"},
+			{
+				Timestamp: time.Now(), Content: "Use JWT for sessions", Type: workstream.EntryDecision,
+				Alternatives: []string{"cookie sessions"}, Rationale: "stateless servers",
+			},
+		},
+	}
+	if err := st.Create(ws); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+}
+
+func TestServer_Decisions_ListsDecisions(t *testing.T) {
+	st := setupTestStore(t)
+	createDecisionFixture(t, st)
+	srv := NewServer(st, "myproject")
+
+	req := httptest.NewRequest("GET", "/decisions", nil)
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	body := w.Body.String()
+	for _, want := range []string{"Use JWT for sessions", "Rationale: stateless servers", "Rejected: cookie sessions"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("body should contain %q", want)
+		}
+	}
+	if strings.Contains(body, "Started on login form") {
+		t.Errorf("decisions page should not list plain notes")
+	}
+}
+
+func TestServer_ActivityAPI_FilterByType(t *testing.T) {
+	st := setupTestStore(t)
+	createDecisionFixture(t, st)
+	srv := NewServer(st, "myproject")
+
+	req := httptest.NewRequest("GET", "/api/activity?type=decision", nil)
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+
+	var resp struct {
+		Entries []struct {
+			Content string `json:"content"`
+			Type    string `json:"type"`
+		} `json:"entries"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Entries) != 1 || resp.Entries[0].Type != "decision" {
+		t.Errorf("entries = %+v, want single decision", resp.Entries)
+	}
+
+	req = httptest.NewRequest("GET", "/api/activity?type=bogus", nil)
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d for invalid type", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestServer_Index_FilterByAuthor(t *testing.T) {
+	st := setupTestStore(t)
+	if err := st.Create(&workstream.Workstream{Project: "myproject", Name: "auth", State: workstream.StateInProgress}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	for _, author := range []string{"alice", "bob"} {
+		entry := "work by " + author
+		st.Update("myproject", "auth", store.WorkstreamUpdate{LogEntry: &entry, LogAuthor: author, LogSession: "s-" + author})
+	}
+	srv := NewServer(st, "myproject")
+
+	req := httptest.NewRequest("GET", "/?author=alice", nil)
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+
+	body := w.Body.String()
+	if !strings.Contains(body, "work by alice") || !strings.Contains(body, "@alice") {
+		t.Errorf("filtered feed should show alice's entry with author badge")
+	}
+	if strings.Contains(body, "work by bob") {
+		t.Errorf("filtered feed should not show bob's entry")
+	}
+
+	req = httptest.NewRequest("GET", "/api/activity?session=s-bob", nil)
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+
+	var resp struct {
+		Entries []struct {
+			Content   string `json:"content"`
+			Author    string `json:"author"`
+			SessionID string `json:"sessionId"`
+		} `json:"entries"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Entries) != 1 || resp.Entries[0].Author != "bob" || resp.Entries[0].SessionID != "s-bob" {
+		t.Errorf("entries = %+v, want bob's entry only", resp.Entries)
+	}
+}
+
+func TestServer_Workstream_ShowsSummary(t *testing.T) {
+	st := setupTestStore(t)
+	ws := &workstream.Workstream{
+		Project: "myproject",
+		Name:    "compacted",
+		State:   workstream.StateInProgress,
+		Log:     []workstream.LogEntry{{Timestamp: time.Now().Add(-time.Hour), Content: "old detail"}},
+	}
+	if err := st.Create(ws); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := st.Compact("myproject", "compacted", "Condensed state of play", time.Time{}); err != nil {
+		t.Fatalf("Compact: %v", err)
+	}
+	srv := NewServer(st, "myproject")
+
+	req := httptest.NewRequest("GET", "/workstream/compacted", nil)
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+
+	body := w.Body.String()
+	if !strings.Contains(body, "Condensed state of play") {
+		t.Errorf("body should contain the summary")
+	}
+	if !strings.Contains(body, "summarised") {
+		t.Errorf("superseded log entries should be marked")
+	}
+}
+
+func TestServer_Index_ShowsActiveSessions(t *testing.T) {
+	st := setupTestStore(t)
+	if _, err := st.StartSession("s1", "myproject", "agent-blue", ""); err != nil {
+		t.Fatalf("StartSession: %v", err)
+	}
+	st.StartSession("s2", "myproject", "agent-gone", "")
+	st.EndSession("s2", "done")
+	srv := NewServer(st, "myproject")
+
+	req := httptest.NewRequest("GET", "/", nil)
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+
+	body := w.Body.String()
+	if !strings.Contains(body, "Active sessions:") || !strings.Contains(body, "agent-blue") {
+		t.Errorf("body should list active sessions")
+	}
+	if strings.Contains(body, "agent-gone") {
+		t.Errorf("ended sessions should not be listed")
+	}
+}
+
+func TestServer_Index_ShowsStale(t *testing.T) {
+	st := setupTestStore(t)
+	old := time.Now().Add(-10 * 24 * time.Hour)
+	st.Create(&workstream.Workstream{Project: "myproject", Name: "auth", State: workstream.StateInProgress, LastUpdate: old})
+	st.Create(&workstream.Workstream{Project: "myproject", Name: "api", State: workstream.StateInProgress, LastUpdate: time.Now()})
+	st.Create(&workstream.Workstream{Project: "myproject", Name: "docs", State: workstream.StatePending, LastUpdate: old})
+	srv := NewServer(st, "myproject")
+
+	body := get(srv, "/").Body.String()
+	if !strings.Contains(body, `title="No recent updates: auth">1 stale</span>`) {
+		t.Errorf("header should count only auth as stale")
+	}
+
+	srv.stale = workstream.StaleRules{workstream.StatePending: 24 * time.Hour}
+	body = get(srv, "/").Body.String()
+	if !strings.Contains(body, `title="No recent updates: docs">1 stale</span>`) {
+		t.Errorf("header should follow the configured rules")
+	}
+}
+
+func TestServer_Answer(t *testing.T) {
+	st := setupTestStore(t)
+	if err := st.Create(&workstream.Workstream{Project: "myproject", Name: "auth", State: workstream.StateInProgress}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := st.RequestHelp("myproject", "auth", "Which OAuth provider?", "agent-1"); err != nil {
+		t.Fatalf("RequestHelp: %v", err)
+	}
+	srv := NewServer(st, "myproject")
+	answer := func(cookie *http.Cookie, origin, csrf string) *httptest.ResponseRecorder {
+		form := url.Values{"workstream": {"auth"}, "answer": {"Google"}, "by": {"faraz"}, "csrf": {csrf}}
+		req := httptest.NewRequest("POST", "/answer", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Origin", origin)
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+
+	// Without editing, the page shows the question but no one may answer
+	w := get(srv, "/workstream/auth")
+	if body := w.Body.String(); !strings.Contains(body, "Which OAuth provider?") || strings.Contains(body, `action="/answer"`) {
+		t.Errorf("a read-only page should show the help question without an answer form")
+	}
+	if w := answer(nil, "http://example.com", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("anonymous status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+
+	// With editing, the signed-in page has the form
+	srv.EnableEditing("s3cret")
+	cookie, csrf := editClient(t, srv, "s3cret", "auth")
+	req := httptest.NewRequest("GET", "/workstream/auth", nil)
+	req.AddCookie(cookie)
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if !strings.Contains(w.Body.String(), `action="/answer"`) {
+		t.Errorf("a signed-in page should show the answer form")
+	}
+
+	// Cross-origin posts and posts without the CSRF token are rejected
+	if w := answer(cookie, "http://evil.example", csrf); w.Code != http.StatusForbidden {
+		t.Errorf("cross-origin status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+	if w := answer(cookie, "http://example.com", ""); w.Code != http.StatusForbidden {
+		t.Errorf("missing CSRF status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+	if ws, _ := st.Get("myproject", "auth"); !ws.NeedsHelp {
+		t.Fatalf("rejected answers should leave needs_help set")
+	}
+
+	if w := answer(cookie, "http://example.com", csrf); w.Code != http.StatusSeeOther {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusSeeOther, w.Body.String())
+	}
+
+	ws, _ := st.Get("myproject", "auth")
+	if ws.NeedsHelp {
+		t.Errorf("needs_help should be cleared after answering")
+	}
+	if ws.Log[0].Content != "Answer: Google" || ws.Log[0].Author != "faraz" {
+		t.Errorf("answer should be logged, got %+v", ws.Log[0])
+	}
+
+	if w := get(srv, "/answer"); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET status = %d, want %d", w.Code, http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,196 @@
+package web
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/faraz/streamctl/internal/store"
+	"github.com/faraz/streamctl/pkg/workstream"
+)
+
+// siteStates orders the index's workstream sections
+var siteStates = []workstream.State{
+	workstream.StateInProgress,
+	workstream.StateBlocked,
+	workstream.StatePending,
+	workstream.StateDone,
+}
+
+// siteWorkstream is a workstream on the exported index
+type siteWorkstream struct {
+	workstream.Workstream
+	TasksDone, TasksTotal int
+	Brief                 string // First line of the objective
+}
+
+type siteSection struct {
+	State       workstream.State
+	Workstreams []siteWorkstream
+}
+
+// siteEntry is a search index entry. Href is relative to the index.
+type siteEntry struct {
+	Kind  string `json:"kind"` // workstream, milestone, task or log
+	Title string `json:"title"`
+	Text  string `json:"text"`
+	Href  string `json:"href"`
+}
+
+// siteFile returns the file name of a workstream or milestone page
+func siteFile(name string) string {
+	return url.PathEscape(name) + ".html"
+}
+
+// siteHref returns a relative link to the page siteFile names
+func siteHref(name string) string {
+	return url.PathEscape(siteFile(name))
+}
+
+// ExportSite writes a read-only snapshot of the project to dir as a static
+// site: index.html with an embedded search index, and a page per workstream
+// and milestone under workstream/ and milestone/. Pages link to each other
+// relatively and load nothing from elsewhere, so the site works opened from
+// disk or served from any path.
+func (s *Server) ExportSite(dir string) error {
+	exported := time.Now()
+	list, err := s.store.List(store.Filter{Project: s.project})
+	if err != nil {
+		return fmt.Errorf("failed to list workstreams: %w", err)
+	}
+	milestones, err := s.store.ListMilestones(s.project)
+	if err != nil {
+		return fmt.Errorf("failed to list milestones: %w", err)
+	}
+	for _, sub := range []string{"workstream", "milestone"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0755); err != nil {
+			return fmt.Errorf("failed to create directory: %w", err)
+		}
+	}
+
+	var index []siteEntry
+	sections := map[workstream.State][]siteWorkstream{}
+	var needsHelp, blocked, active int
+	for _, summary := range list {
+		ws, err := s.store.Get(s.project, summary.Name)
+		if err != nil {
+			return err
+		}
+		done, total := ws.TaskProgress()
+		brief, _, _ := strings.Cut(strings.TrimSpace(ws.Objective), "\n")
+		sections[ws.State] = append(sections[ws.State], siteWorkstream{Workstream: *ws, TasksDone: done, TasksTotal: total, Brief: truncate(brief, 160)})
+		if ws.NeedsHelp {
+			needsHelp++
+		}
+		if ws.State == workstream.StateBlocked || len(ws.BlockedBy) > 0 {
+			blocked++
+		}
+		if ws.State == workstream.StateInProgress {
+			active++
+		}
+
+		data := struct {
+			Project    string
+			Exported   time.Time
+			Workstream *workstream.Workstream
+			TasksDone  int
+			TasksTotal int
+		}{s.project, exported, ws, done, total}
+		if err := writeSitePage(filepath.Join(dir, "workstream", siteFile(ws.Name)), "site_workstream.html", data); err != nil {
+			return err
+		}
+		index = append(index, workstreamEntries(ws)...)
+	}
+
+	for i := range milestones {
+		m := &milestones[i]
+		activity, err := s.store.Activity(store.ActivityFilter{Project: s.project, Milestone: m.Name}, milestoneActivityLimit, 0)
+		if err != nil {
+			return err
+		}
+		data := struct {
+			Project      string
+			Exported     time.Time
+			Milestone    *workstream.Milestone
+			Requirements []milestoneRequirement
+			Activity     []workstream.ActivityEntry
+		}{s.project, exported, m, s.milestoneRequirements(m), activity}
+		if err := writeSitePage(filepath.Join(dir, "milestone", siteFile(m.Name)), "site_milestone.html", data); err != nil {
+			return err
+		}
+		index = append(index, siteEntry{Kind: "milestone", Title: m.Name, Text: m.Description, Href: "milestone/" + siteHref(m.Name)})
+	}
+
+	var ordered []siteSection
+	for _, state := range siteStates {
+		if len(sections[state]) > 0 {
+			ordered = append(ordered, siteSection{State: state, Workstreams: sections[state]})
+		}
+	}
+	data := struct {
+		Project    string
+		Exported   time.Time
+		Sections   []siteSection
+		Milestones []workstream.Milestone
+		NeedsHelp  int
+		Blocked    int
+		Active     int
+		Total      int
+		Index      []siteEntry
+	}{
+		Project:    s.project,
+		Exported:   exported,
+		Sections:   ordered,
+		Milestones: milestones,
+		NeedsHelp:  needsHelp,
+		Blocked:    blocked,
+		Active:     active,
+		Total:      len(list),
+		Index:      index,
+	}
+	return writeSitePage(filepath.Join(dir, "index.html"), "site_index.html", data)
+}
+
+// workstreamEntries indexes a workstream, its tasks and its log for search
+func workstreamEntries(ws *workstream.Workstream) []siteEntry {
+	href := "workstream/" + siteHref(ws.Name)
+	entries := []siteEntry{{Kind: "workstream", Title: ws.Name, Text: strings.TrimSpace(ws.Objective + "\n" + ws.Summary), Href: href}}
+
+	var addTasks func(items []workstream.PlanItem)
+	addTasks = func(items []workstream.PlanItem) {
+		for _, item := range items {
+			entries = append(entries, siteEntry{Kind: "task", Title: ws.Name + ": " + item.Text, Text: item.Notes, Href: href + "#task-" + item.ID})
+			addTasks(item.Children)
+		}
+	}
+	addTasks(ws.Plan)
+
+	for _, e := range ws.Log {
+		title := ws.Name + " · " + e.Timestamp.Format("Jan 2 15:04")
+		if who := e.Attribution(); who != "" {
+			title += " · @" + who
+		}
+		text := e.Content
+		if e.Rationale != "" {
+			text += "\n" + e.Rationale
+		}
+		entries = append(entries, siteEntry{Kind: "log", Title: title, Text: text, Href: fmt.Sprintf("%s#log-%d", href, e.Timestamp.Unix())})
+	}
+	return entries
+}
+
+// writeSitePage renders the named template to path
+func writeSitePage(path, name string, data any) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := templates.ExecuteTemplate(f, name, data); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to render %s: %w", path, err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,100 @@
+package web
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/faraz/streamctl/internal/store"
+	"github.com/faraz/streamctl/pkg/workstream"
+)
+
+func readSiteFile(t *testing.T, dir, name string) string {
+	t.Helper()
+	b, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatalf("site should contain %s: %v", name, err)
+	}
+	return string(b)
+}
+
+func TestServer_ExportSite(t *testing.T) {
+	st := setupTestStore(t)
+	st.Create(&workstream.Workstream{Project: "myproject", Name: "auth", State: workstream.StateInProgress, Owner: "agent-1",
+		Objective: "Add login\nwith sessions", Plan: []workstream.PlanItem{{Text: "Write schema"}, {Text: "Add handlers"}}})
+	st.Create(&workstream.Workstream{Project: "myproject", Name: "api", State: workstream.StatePending})
+	st.Create(&workstream.Workstream{Project: "other", Name: "sdk", State: workstream.StatePending})
+	st.SetTaskStatus("myproject", "auth", 0, workstream.TaskDone)
+	entry := "Chose </script> bcrypt over argon2"
+	st.Update("myproject", "auth", store.WorkstreamUpdate{LogEntry: &entry, LogAuthor: "agent-1", LogType: workstream.EntryDecision})
+	st.AddDependency("myproject", "auth", "myproject", "api")
+	st.CreateMilestone(&workstream.Milestone{Project: "myproject", Name: "beta", Description: "First release"})
+	st.AddMilestoneRequirement("myproject", "beta", "myproject", "auth")
+	st.AddMilestoneRequirement("myproject", "beta", "other", "sdk")
+
+	dir := filepath.Join(t.TempDir(), "site")
+	if err := NewServer(st, "myproject").ExportSite(dir); err != nil {
+		t.Fatalf("ExportSite() error = %v", err)
+	}
+
+	index := readSiteFile(t, dir, "index.html")
+	for _, want := range []string{`href="workstream/auth.html"`, `href="workstream/api.html"`, `href="milestone/beta.html"`, "1/2 tasks", "Add login", "1 blocked"} {
+		if !strings.Contains(index, want) {
+			t.Errorf("index should contain %q", want)
+		}
+	}
+	overview := index[:strings.Index(index, `id="search-index"`)]
+	if strings.Contains(index, "sdk") || strings.Contains(overview, "with sessions") {
+		t.Errorf("index should show only this project and the objective's first line")
+	}
+	for _, page := range []string{"index.html", "workstream/auth.html", "milestone/beta.html"} {
+		body := readSiteFile(t, dir, page)
+		if strings.Contains(body, "http://") || strings.Contains(body, "https://") || strings.Contains(body, "/api/") {
+			t.Errorf("%s should not load anything from a server", page)
+		}
+	}
+
+	// The search index is embedded as JSON, safely inside its script tag
+	start := strings.Index(index, `<script type="application/json" id="search-index">`)
+	raw := index[start+len(`<script type="application/json" id="search-index">`):]
+	raw = raw[:strings.Index(raw, "</script>")]
+	var entries []siteEntry
+	if err := json.Unmarshal([]byte(raw), &entries); err != nil {
+		t.Fatalf("search index is not JSON: %v\n%s", err, raw)
+	}
+	kinds := map[string]int{}
+	for _, e := range entries {
+		kinds[e.Kind]++
+		if e.Kind == "log" && (!strings.Contains(e.Text, "</script>") || !strings.HasPrefix(e.Href, "workstream/auth.html#log-")) {
+			t.Errorf("log entry = %+v", e)
+		}
+	}
+	if kinds["workstream"] != 2 || kinds["task"] != 2 || kinds["log"] != 1 || kinds["milestone"] != 1 {
+		t.Errorf("index kinds = %v", kinds)
+	}
+
+	ws := readSiteFile(t, dir, "workstream/auth.html")
+	for _, want := range []string{`href="../index.html"`, "Write schema", "✓", "@agent-1", "badge-decision", `Blocks <a href="api.html">api</a>`, `id="log-`} {
+		if !strings.Contains(ws, want) {
+			t.Errorf("workstream page should contain %q", want)
+		}
+	}
+
+	m := readSiteFile(t, dir, "milestone/beta.html")
+	for _, want := range []string{"First release", `href="../workstream/auth.html"`, "other/sdk", "1/2 tasks"} {
+		if !strings.Contains(m, want) {
+			t.Errorf("milestone page should contain %q", want)
+		}
+	}
+}
+
+func TestSiteHref(t *testing.T) {
+	if got := siteFile("auth"); got != "auth.html" {
+		t.Errorf("siteFile(auth) = %q", got)
+	}
+	if got, want := siteHref("a/b c"), "a%252Fb%2520c.html"; got != want {
+		t.Errorf("siteHref() = %q, want %q so the link finds a%%2Fb%%20c.html", got, want)
+	}
+}
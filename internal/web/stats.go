@@ -0,0 +1,266 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/faraz/streamctl/internal/metrics"
+	"github.com/faraz/streamctl/pkg/workstream"
+)
+
+// Chart geometry, in SVG user units
+const (
+	statsMargin      = 16
+	statsPlotHeight  = 120 // Of column and burndown charts
+	statsColumnWidth = 28  // Per day, including the gap
+	statsBarLabel    = 180 // Workstream names, left of horizontal bars
+	statsBarWidth    = 360 // Longest horizontal bar
+	statsRowHeight   = 22
+	statsMaxRows     = 15 // Horizontal bar charts show the top rows only
+	statsLineWidth   = 520
+)
+
+// statsDays are the throughput windows offered on the page
+var statsDays = []int{7, 14, 30, 90}
+
+// statsChart is a bar chart: columns for throughput, or horizontal bars
+// per workstream
+type statsChart struct {
+	Width, Height int
+	Baseline      int // Of columns
+	Bars          []statsBar
+}
+
+type statsBar struct {
+	X, Y, W, H     int
+	Label          string
+	LabelX, LabelY int
+	Value          string
+	ValueX, ValueY int
+	Title          string
+	Href           string // Workstream page, for horizontal bars
+	Highlight      bool   // Still blocked
+}
+
+// statsBurndown is a milestone's burndown as a line chart, remaining
+// workstreams over a dashed line of its scope
+type statsBurndown struct {
+	Milestone        string
+	Total, Remaining int
+	Width, Height    int
+	Left, Bottom     int // Plot origin
+	Right, Top       int
+	RemainingLine    string // SVG polyline points
+	ScopeLine        string
+	Points           []statsPoint
+	Labels           []statsGridline
+	YMax             int
+}
+
+type statsPoint struct {
+	X, Y  int
+	Title string
+}
+
+type statsGridline struct {
+	X     int
+	Label string
+}
+
+// columnChart draws a column per day
+func columnChart(days []metrics.Day) statsChart {
+	most := 1
+	for _, d := range days {
+		most = max(most, d.Count)
+	}
+	top := statsMargin + 12 // Room for values above the columns
+	c := statsChart{
+		Width:    2*statsMargin + len(days)*statsColumnWidth,
+		Height:   top + statsPlotHeight + 20 + statsMargin,
+		Baseline: top + statsPlotHeight,
+	}
+	// Label fewer days as the window grows, always including the latest
+	step := 1
+	if len(days) > 7 {
+		step = (len(days) + 13) / 14 * 2
+	}
+	for i, d := range days {
+		h := d.Count * statsPlotHeight / most
+		x := statsMargin + i*statsColumnWidth
+		bar := statsBar{
+			X: x + 4, Y: top + statsPlotHeight - h, W: statsColumnWidth - 8, H: h,
+			ValueX: x + statsColumnWidth/2, ValueY: top + statsPlotHeight - h - 4,
+			LabelX: x + statsColumnWidth/2, LabelY: top + statsPlotHeight + 14,
+			Title: fmt.Sprintf("%s: %d task%s done", d.Date.Format("Mon Jan 2"), d.Count, plural(d.Count)),
+		}
+		if d.Count > 0 {
+			bar.Value = strconv.Itoa(d.Count)
+		}
+		if (len(days)-1-i)%step == 0 {
+			bar.Label = d.Date.Format("Jan 2")
+		}
+		c.Bars = append(c.Bars, bar)
+	}
+	return c
+}
+
+// statsRow is one workstream's bar in a horizontal bar chart
+type statsRow struct {
+	Name      string
+	Value     float64
+	Text      string // Value, formatted
+	Highlight bool
+}
+
+// barChart draws a horizontal bar per row, the first statsMaxRows only
+func barChart(base string, rows []statsRow) statsChart {
+	rows = rows[:min(len(rows), statsMaxRows)]
+	most := 0.0
+	for _, r := range rows {
+		most = max(most, r.Value)
+	}
+	c := statsChart{
+		Width:  2*statsMargin + statsBarLabel + statsBarWidth + 80,
+		Height: 2*statsMargin + len(rows)*statsRowHeight,
+	}
+	for i, r := range rows {
+		y := statsMargin + i*statsRowHeight
+		w := 1
+		if most > 0 {
+			w = max(int(r.Value*statsBarWidth/most), 1)
+		}
+		x := statsMargin + statsBarLabel
+		c.Bars = append(c.Bars, statsBar{
+			X: x, Y: y + 4, W: w, H: statsRowHeight - 8,
+			Label: truncate(r.Name, timelineLabelChars), LabelX: statsMargin, LabelY: y + 15,
+			Value: r.Text, ValueX: x + w + 6, ValueY: y + 15,
+			Title:     r.Name + ": " + r.Text,
+			Href:      base + "/workstream/" + r.Name,
+			Highlight: r.Highlight,
+		})
+	}
+	return c
+}
+
+// burndownChart plots remaining workstreams and scope by day
+func burndownChart(b metrics.Burndown) statsBurndown {
+	c := statsBurndown{
+		Milestone: b.Milestone,
+		Total:     b.Total,
+		Remaining: b.Remaining,
+		Left:      statsMargin + 28, // Room for the y-axis labels
+		Top:       statsMargin,
+		YMax:      1,
+	}
+	c.Right = c.Left + statsLineWidth
+	c.Bottom = c.Top + statsPlotHeight
+	c.Width = c.Right + statsMargin
+	c.Height = c.Bottom + 20 + statsMargin
+	for _, p := range b.Points {
+		c.YMax = max(c.YMax, p.Scope)
+	}
+
+	n := len(b.Points)
+	x := func(i int) int {
+		if n == 1 {
+			return c.Right
+		}
+		return c.Left + i*statsLineWidth/(n-1)
+	}
+	y := func(v int) int { return c.Bottom - v*statsPlotHeight/c.YMax }
+	for i, p := range b.Points {
+		c.RemainingLine += fmt.Sprintf("%d,%d ", x(i), y(p.Remaining))
+		c.ScopeLine += fmt.Sprintf("%d,%d ", x(i), y(p.Scope))
+		c.Points = append(c.Points, statsPoint{
+			X: x(i), Y: y(p.Remaining),
+			Title: fmt.Sprintf("%s: %d of %d remaining", p.Date.Format("Mon Jan 2"), p.Remaining, p.Scope),
+		})
+	}
+	if n > 0 {
+		c.Labels = append(c.Labels, statsGridline{X: x(0), Label: b.Points[0].Date.Format("Jan 2")})
+	}
+	if n > 1 {
+		c.Labels = append(c.Labels, statsGridline{X: x(n - 1), Label: b.Points[n-1].Date.Format("Jan 2")})
+	}
+	return c
+}
+
+func plural(n int) string {
+	if n == 1 {
+		return ""
+	}
+	return "s"
+}
+
+// handleStats charts the project's metrics. ?days=N sets the throughput window.
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	days := metrics.DefaultDays
+	if v := r.URL.Query().Get("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > 366 {
+			http.Error(w, "days must be between 1 and 366", http.StatusBadRequest)
+			return
+		}
+		days = n
+	}
+
+	stats, err := metrics.Load(s.store, s.project, days, time.Now().UTC())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var cycle, blocked, help []statsRow
+	for _, ct := range stats.CycleTimes {
+		cycle = append(cycle, statsRow{Name: ct.Workstream, Value: ct.Duration.Hours(), Text: workstream.FormatDuration(ct.Duration)})
+	}
+	for _, b := range stats.Blocked {
+		text := workstream.FormatDuration(b.Duration)
+		if b.Current {
+			text += " (now)"
+		}
+		blocked = append(blocked, statsRow{Name: b.Workstream, Value: b.Duration.Hours(), Text: text, Highlight: b.Current})
+	}
+	for _, h := range stats.Help {
+		help = append(help, statsRow{Name: h.Workstream, Value: float64(h.Count), Text: strconv.Itoa(h.Count)})
+	}
+	var burndowns []statsBurndown
+	for _, b := range stats.Burndown {
+		burndowns = append(burndowns, burndownChart(b))
+	}
+
+	data := struct {
+		Project    string
+		Base       string
+		Stats      *metrics.Stats
+		Days       int
+		DayOptions []int
+		Throughput statsChart
+		CycleTime  statsChart
+		Blocked    statsChart
+		Help       statsChart
+		Burndown   []statsBurndown
+		PerDay     string
+		Cursor     string
+	}{
+		Project:    s.project,
+		Base:       s.base,
+		Stats:      stats,
+		Days:       days,
+		DayOptions: statsDays,
+		Throughput: columnChart(stats.Throughput),
+		CycleTime:  barChart(s.base, cycle),
+		Blocked:    barChart(s.base, blocked),
+		Help:       barChart(s.base, help),
+		Burndown:   burndowns,
+		PerDay:     strconv.FormatFloat(float64(stats.TasksDone)/float64(days), 'f', 1, 64),
+		Cursor:     s.currentCursor(),
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := templates.ExecuteTemplate(w, "stats.html", data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
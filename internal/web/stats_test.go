@@ -0,0 +1,95 @@
+package web
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/faraz/streamctl/internal/metrics"
+	"github.com/faraz/streamctl/internal/store"
+	"github.com/faraz/streamctl/pkg/workstream"
+)
+
+func TestColumnChart(t *testing.T) {
+	since := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	var days []metrics.Day
+	for i, n := range []int{0, 2, 4, 1, 0, 0, 3, 1, 0, 2} {
+		days = append(days, metrics.Day{Date: since.AddDate(0, 0, i), Count: n})
+	}
+
+	c := columnChart(days)
+	if len(c.Bars) != 10 || c.Width != 2*statsMargin+10*statsColumnWidth {
+		t.Fatalf("bars = %d, width = %d", len(c.Bars), c.Width)
+	}
+	if c.Bars[2].H != statsPlotHeight || c.Bars[1].H != statsPlotHeight/2 || c.Bars[0].H != 0 {
+		t.Errorf("heights = %d, %d, %d; want scaled to the busiest day", c.Bars[0].H, c.Bars[1].H, c.Bars[2].H)
+	}
+	if c.Bars[2].Y+c.Bars[2].H != c.Baseline {
+		t.Errorf("columns should stand on the baseline")
+	}
+	// Every other day is labelled, ending with the latest
+	if c.Bars[9].Label != "Mar 10" || c.Bars[8].Label != "" || c.Bars[7].Label != "Mar 8" {
+		t.Errorf("labels = %q, %q, %q", c.Bars[7].Label, c.Bars[8].Label, c.Bars[9].Label)
+	}
+}
+
+func TestBurndownChart(t *testing.T) {
+	since := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	c := burndownChart(metrics.Burndown{Milestone: "beta", Total: 4, Remaining: 1, Points: []metrics.BurndownPoint{
+		{Date: since, Scope: 2, Remaining: 2},
+		{Date: since.AddDate(0, 0, 1), Scope: 4, Remaining: 3},
+		{Date: since.AddDate(0, 0, 2), Scope: 4, Remaining: 1},
+	}})
+	if c.YMax != 4 {
+		t.Errorf("YMax = %d, want the largest scope", c.YMax)
+	}
+	if c.Points[0].X != c.Left || c.Points[2].X != c.Right {
+		t.Errorf("points should span the plot: %+v", c.Points)
+	}
+	if c.Points[1].Y != c.Bottom-3*statsPlotHeight/4 {
+		t.Errorf("point y = %d", c.Points[1].Y)
+	}
+	if len(c.Labels) != 2 || c.Labels[1].Label != "Mar 3" {
+		t.Errorf("labels = %+v", c.Labels)
+	}
+}
+
+func TestServer_Stats(t *testing.T) {
+	st := setupTestStore(t)
+	st.Create(&workstream.Workstream{Project: "myproject", Name: "auth", State: workstream.StatePending, Plan: []workstream.PlanItem{{Text: "schema"}}})
+	st.Create(&workstream.Workstream{Project: "myproject", Name: "docs", State: workstream.StatePending})
+	st.SetTaskStatus("myproject", "auth", 0, workstream.TaskDone)
+	done, blocked := workstream.StateDone, workstream.StateBlocked
+	st.Update("myproject", "auth", store.WorkstreamUpdate{State: &done})
+	st.Update("myproject", "docs", store.WorkstreamUpdate{State: &blocked})
+	st.RequestHelp("myproject", "docs", "Which format?", "agent-1")
+	st.CreateMilestone(&workstream.Milestone{Project: "myproject", Name: "beta"})
+	st.AddMilestoneRequirement("myproject", "beta", "myproject", "auth")
+	srv := NewServer(st, "myproject")
+
+	w := get(srv, "/stats?days=7")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d", w.Code)
+	}
+	body := w.Body.String()
+	for _, want := range []string{
+		"1 task completed in the last 7 days",
+		`<option value="7" selected>`,
+		`href="/workstream/auth"`,
+		`class="bar bar-highlight"`, // docs is still blocked
+		"Raised 1 time",
+		`href="/milestone/beta"`,
+		`class="line-remaining"`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("stats page should contain %q", want)
+		}
+	}
+
+	for _, days := range []string{"0", "x", "1000"} {
+		if w := get(srv, "/stats?days="+days); w.Code != http.StatusBadRequest {
+			t.Errorf("days=%s status = %d, want 400", days, w.Code)
+		}
+	}
+}
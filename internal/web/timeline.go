@@ -0,0 +1,322 @@
+package web
+
+import (
+	"hash/fnv"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/faraz/streamctl/internal/store"
+	"github.com/faraz/streamctl/pkg/workstream"
+)
+
+// Timeline geometry, in SVG user units
+const (
+	timelineLabelWidth = 180 // Workstream names, left of the chart
+	timelineLaneHeight = 28
+	timelineSpanHeight = 14
+	timelineAxisHeight = 28
+	timelineMargin     = 16
+	timelineLabelChars = 22 // Longer names are truncated
+	timelineTitleChars = 120
+)
+
+// timelineZoom is a scale the timeline can be drawn at
+type timelineZoom struct {
+	Name      string
+	DayWidth  int           // Width of a day
+	GridEvery time.Duration // Between axis gridlines
+}
+
+var timelineZooms = []timelineZoom{
+	{Name: "day", DayWidth: 48, GridEvery: 24 * time.Hour},
+	{Name: "week", DayWidth: 12, GridEvery: 7 * 24 * time.Hour},
+}
+
+// timelineLane is one workstream's row: the states it was in over time and
+// a tick for each log entry
+type timelineLane struct {
+	Name  string
+	Label string // Name, truncated to fit
+	State workstream.State
+	Y     int
+	Spans []timelineSpan
+	Ticks []timelineTick
+	Idle  time.Duration // Since its last activity
+	Stale bool          // Not updated within its state's stale threshold
+}
+
+type timelineSpan struct {
+	State    workstream.State
+	From, To time.Time
+	X, W     int
+}
+
+type timelineTick struct {
+	Time   time.Time
+	Type   workstream.EntryType
+	Author string // Attribution
+	Colour string // By author, so overlapping agents stand out
+	Title  string // Content, truncated
+	X      int
+}
+
+type timelineGridline struct {
+	X     int
+	Label string
+}
+
+// timelineAuthor is an author in the legend
+type timelineAuthor struct {
+	Name    string
+	Colour  string
+	Entries int
+}
+
+type timelineLayout struct {
+	Lanes         []timelineLane
+	Grid          []timelineGridline
+	Authors       []timelineAuthor
+	Width, Height int
+	NowX          int
+}
+
+// timelineColours are assigned to authors by hashing their name
+var timelineColours = []string{"#2563eb", "#db2777", "#059669", "#d97706", "#7c3aed", "#0891b2", "#dc2626", "#4d7c0f"}
+
+func authorColour(author string) string {
+	h := fnv.New32a()
+	h.Write([]byte(author))
+	return timelineColours[h.Sum32()%uint32(len(timelineColours))]
+}
+
+// layoutTimeline places each workstream as a lane from its creation to now,
+// split into spans by its state changes, with a tick per log entry. A
+// workstream's state before its first recorded change is that change's
+// "from" state, or its current state if it has none.
+func layoutTimeline(workstreams []workstream.Workstream, changes []workstream.Event, zoom timelineZoom, rules workstream.StaleRules, now time.Time) timelineLayout {
+	byName := map[string][]workstream.Event{}
+	for _, ev := range changes {
+		byName[ev.Workstream] = append(byName[ev.Workstream], ev)
+	}
+
+	// The chart starts at midnight before the earliest thing shown, and
+	// for the week zoom on a Monday, so gridlines fall on week boundaries
+	start := now
+	for _, ws := range workstreams {
+		start = earliest(start, laneStart(ws, byName[ws.Name]))
+	}
+	y, m, d := start.UTC().Date()
+	start = time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	if zoom.GridEvery > 24*time.Hour {
+		start = start.AddDate(0, 0, -((int(start.Weekday()) + 6) % 7))
+	}
+	end := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, 1)
+
+	x := func(t time.Time) int {
+		return timelineMargin + timelineLabelWidth + int(t.Sub(start).Hours()*float64(zoom.DayWidth)/24)
+	}
+
+	layout := timelineLayout{
+		Width:  x(end) + timelineMargin,
+		Height: timelineMargin + timelineAxisHeight + len(workstreams)*timelineLaneHeight + timelineMargin,
+		NowX:   x(now),
+	}
+	for t := start; !t.After(end); t = t.Add(zoom.GridEvery) {
+		layout.Grid = append(layout.Grid, timelineGridline{X: x(t), Label: t.Format("Jan 2")})
+	}
+
+	authors := map[string]*timelineAuthor{}
+	for i, ws := range workstreams {
+		lane := timelineLane{
+			Name:  ws.Name,
+			Label: truncate(ws.Name, timelineLabelChars),
+			State: ws.State,
+			Y:     timelineMargin + timelineAxisHeight + i*timelineLaneHeight,
+		}
+
+		from := laneStart(ws, byName[ws.Name])
+		state := ws.State
+		if evs := byName[ws.Name]; len(evs) > 0 {
+			state = workstream.State(evs[0].Data["from"])
+		}
+		last := from
+		for _, ev := range byName[ws.Name] {
+			lane.Spans = append(lane.Spans, timelineSpan{State: state, From: from, To: ev.CreatedAt})
+			from, state = ev.CreatedAt, workstream.State(ev.Data["to"])
+			last = latest(last, ev.CreatedAt)
+		}
+		lane.Spans = append(lane.Spans, timelineSpan{State: state, From: from, To: now})
+		for j := range lane.Spans {
+			sp := &lane.Spans[j]
+			sp.X, sp.W = x(sp.From), max(x(sp.To)-x(sp.From), 1)
+		}
+
+		for _, e := range ws.Log {
+			author := e.Attribution()
+			if author == "" {
+				author = "unknown"
+			}
+			lane.Ticks = append(lane.Ticks, timelineTick{
+				Time:   e.Timestamp,
+				Type:   e.Type,
+				Author: author,
+				Colour: authorColour(author),
+				Title:  truncate(e.Content, timelineTitleChars),
+				X:      x(e.Timestamp),
+			})
+			if authors[author] == nil {
+				authors[author] = &timelineAuthor{Name: author, Colour: authorColour(author)}
+			}
+			authors[author].Entries++
+			last = latest(last, e.Timestamp)
+		}
+
+		lane.Idle = now.Sub(latest(last, ws.LastUpdate))
+		lane.Stale = rules.Stale(ws.State, ws.LastUpdate, now)
+		layout.Lanes = append(layout.Lanes, lane)
+	}
+
+	for _, a := range authors {
+		layout.Authors = append(layout.Authors, *a)
+	}
+	sort.Slice(layout.Authors, func(i, j int) bool {
+		if layout.Authors[i].Entries != layout.Authors[j].Entries {
+			return layout.Authors[i].Entries > layout.Authors[j].Entries
+		}
+		return layout.Authors[i].Name < layout.Authors[j].Name
+	})
+	return layout
+}
+
+// laneStart is when a workstream's lane begins: its creation, or its first
+// change or log entry if that is earlier or its creation time is unknown
+func laneStart(ws workstream.Workstream, changes []workstream.Event) time.Time {
+	start := ws.CreatedAt
+	if start.IsZero() {
+		start = ws.LastUpdate
+	}
+	if len(changes) > 0 {
+		start = earliest(start, changes[0].CreatedAt)
+	}
+	if len(ws.Log) > 0 {
+		start = earliest(start, ws.Log[0].Timestamp)
+	}
+	return start
+}
+
+func earliest(a, b time.Time) time.Time {
+	if a.IsZero() || (!b.IsZero() && b.Before(a)) {
+		return b
+	}
+	return a
+}
+
+func latest(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
+
+func truncate(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n-1]) + "…"
+	}
+	return s
+}
+
+// handleTimeline plots each workstream's states and log entries over time.
+// ?zoom=day|week sets the scale; ?milestone=NAME shows only its workstreams.
+func (s *Server) handleTimeline(w http.ResponseWriter, r *http.Request) {
+	zoom := timelineZooms[0]
+	for _, z := range timelineZooms {
+		if z.Name == r.URL.Query().Get("zoom") {
+			zoom = z
+		}
+	}
+
+	workstreams, err := s.store.List(store.Filter{Project: s.project})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	milestones, err := s.store.ListMilestones(s.project)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	milestone := r.URL.Query().Get("milestone")
+	if milestone != "" {
+		required := map[string]bool{}
+		found := false
+		for _, m := range milestones {
+			if m.Name != milestone {
+				continue
+			}
+			found = true
+			for _, req := range m.Requirements {
+				if req.WorkstreamProject == s.project {
+					required[req.WorkstreamName] = true
+				}
+			}
+		}
+		if !found {
+			http.NotFound(w, r)
+			return
+		}
+		var filtered []workstream.Workstream
+		for _, ws := range workstreams {
+			if required[ws.Name] {
+				filtered = append(filtered, ws)
+			}
+		}
+		workstreams = filtered
+	}
+
+	changes, err := s.store.StateChanges(s.project)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var stale int
+	layout := layoutTimeline(workstreams, changes, zoom, s.stale, time.Now().UTC())
+	for _, lane := range layout.Lanes {
+		if lane.Stale {
+			stale++
+		}
+	}
+
+	data := struct {
+		Project    string
+		Base       string
+		Timeline   timelineLayout
+		Zoom       string
+		Zooms      []timelineZoom
+		Milestone  string
+		Milestones []workstream.Milestone
+		Stale      int
+		LaneHeight int
+		SpanHeight int
+		Cursor     string
+	}{
+		Project:    s.project,
+		Base:       s.base,
+		Timeline:   layout,
+		Zoom:       zoom.Name,
+		Zooms:      timelineZooms,
+		Milestone:  milestone,
+		Milestones: milestones,
+		Stale:      stale,
+		LaneHeight: timelineLaneHeight,
+		SpanHeight: timelineSpanHeight,
+		Cursor:     s.currentCursor(),
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := templates.ExecuteTemplate(w, "timeline.html", data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,128 @@
+package web
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/faraz/streamctl/internal/store"
+	"github.com/faraz/streamctl/pkg/workstream"
+)
+
+func TestLayoutTimeline(t *testing.T) {
+	day := func(n int) time.Time { return time.Date(2026, 3, 2+n, 12, 0, 0, 0, time.UTC) } // 2026-03-02 is a Monday
+	now := day(14)
+	workstreams := []workstream.Workstream{
+		{Name: "auth", State: workstream.StateDone, CreatedAt: day(0), LastUpdate: day(5), Log: []workstream.LogEntry{
+			{Timestamp: day(1), Content: "started", Author: "alice"},
+			{Timestamp: day(3), Content: "shipped", Author: "bob"},
+		}},
+		{Name: "api", State: workstream.StateInProgress, CreatedAt: day(2), LastUpdate: day(4), Log: []workstream.LogEntry{
+			{Timestamp: day(4), Content: "looking into it", Client: "claude-code"},
+		}},
+	}
+	changes := []workstream.Event{
+		{Type: workstream.EventStateChanged, Workstream: "auth", Data: map[string]string{"from": "pending", "to": "in_progress"}, CreatedAt: day(1)},
+		{Type: workstream.EventStateChanged, Workstream: "auth", Data: map[string]string{"from": "in_progress", "to": "done"}, CreatedAt: day(5)},
+	}
+
+	tl := layoutTimeline(workstreams, changes, timelineZooms[0], workstream.DefaultStaleRules, now)
+	if len(tl.Lanes) != 2 {
+		t.Fatalf("lanes = %d, want 2", len(tl.Lanes))
+	}
+
+	auth := tl.Lanes[0]
+	var states []workstream.State
+	for _, sp := range auth.Spans {
+		states = append(states, sp.State)
+	}
+	if got := len(states); got != 3 || states[0] != workstream.StatePending || states[1] != workstream.StateInProgress || states[2] != workstream.StateDone {
+		t.Errorf("auth spans = %v, want pending, in_progress, done", states)
+	}
+	if auth.Spans[1].W != 4*48 {
+		t.Errorf("in_progress span width = %d, want 4 days at 48 per day", auth.Spans[1].W)
+	}
+	if auth.Stale {
+		t.Errorf("a done workstream should not be stale")
+	}
+
+	// Without state changes a lane is one span in its current state
+	api := tl.Lanes[1]
+	if len(api.Spans) != 1 || api.Spans[0].State != workstream.StateInProgress {
+		t.Errorf("api spans = %+v", api.Spans)
+	}
+	if !api.Stale || api.Idle != 10*24*time.Hour {
+		t.Errorf("api idle = %v, stale = %v; want stale after 10 days", api.Idle, api.Stale)
+	}
+
+	// Lanes follow the configured stale rules, like the dashboard
+	rules, err := workstream.ParseStaleRules(workstream.DefaultStaleRules, "in_progress=30d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lenient := layoutTimeline(workstreams, changes, timelineZooms[0], rules, now); lenient.Lanes[1].Stale {
+		t.Errorf("api should not be stale with in_progress=30d")
+	}
+	if api.Ticks[0].Author != "claude-code" || api.Ticks[0].Colour != authorColour("claude-code") {
+		t.Errorf("tick should be attributed to the client: %+v", api.Ticks[0])
+	}
+	if auth.Ticks[0].X >= auth.Ticks[1].X {
+		t.Errorf("ticks should be placed by time: %d, %d", auth.Ticks[0].X, auth.Ticks[1].X)
+	}
+
+	if len(tl.Authors) != 3 {
+		t.Errorf("authors = %+v, want alice, bob and claude-code", tl.Authors)
+	}
+	if tl.Grid[0].Label != "Mar 2" || tl.Grid[1].X-tl.Grid[0].X != 48 {
+		t.Errorf("grid = %+v, want daily from Mar 2", tl.Grid[:2])
+	}
+
+	// The week zoom starts on a Monday with a gridline a week
+	week := layoutTimeline(workstreams, changes, timelineZooms[1], workstream.DefaultStaleRules, now)
+	if week.Grid[0].Label != "Mar 2" || week.Grid[1].Label != "Mar 9" {
+		t.Errorf("week grid = %+v", week.Grid[:2])
+	}
+	if week.Width >= tl.Width {
+		t.Errorf("week zoom should be narrower: %d vs %d", week.Width, tl.Width)
+	}
+}
+
+func TestServer_Timeline(t *testing.T) {
+	st := setupTestStore(t)
+	st.Create(&workstream.Workstream{Project: "myproject", Name: "auth", State: workstream.StatePending})
+	st.Create(&workstream.Workstream{Project: "myproject", Name: "docs", State: workstream.StatePending})
+	inProgress := workstream.StateInProgress
+	entry := "picked it up"
+	st.Update("myproject", "auth", store.WorkstreamUpdate{State: &inProgress, LogEntry: &entry, LogAuthor: "alice"})
+	st.CreateMilestone(&workstream.Milestone{Project: "myproject", Name: "beta"})
+	st.AddMilestoneRequirement("myproject", "beta", "myproject", "auth")
+	srv := NewServer(st, "myproject")
+
+	w := get(srv, "/timeline")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d", w.Code)
+	}
+	body := w.Body.String()
+	for _, want := range []string{"<svg", `data-name="auth"`, `data-name="docs"`, `class="span span-pending"`, `class="span span-in_progress"`, "alice · note: picked it up", `class="active">day</a>`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("timeline should contain %q", want)
+		}
+	}
+
+	w = get(srv, "/timeline?milestone=beta&zoom=week")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d", w.Code)
+	}
+	body = w.Body.String()
+	if !strings.Contains(body, `data-name="auth"`) || strings.Contains(body, `data-name="docs"`) {
+		t.Errorf("milestone filter should show only its workstreams")
+	}
+	if !strings.Contains(body, `class="active">week</a>`) {
+		t.Errorf("week zoom should be selected")
+	}
+
+	if w := get(srv, "/timeline?milestone=nope"); w.Code != http.StatusNotFound {
+		t.Errorf("unknown milestone status = %d, want 404", w.Code)
+	}
+}
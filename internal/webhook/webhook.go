@@ -0,0 +1,171 @@
+// Package webhook delivers workstream events to configured HTTP endpoints.
+//
+// The store queues a delivery for each subscribed webhook in the same
+// transaction as the change that caused the event, so a Dispatcher in any
+// streamctl process sharing the database can send it.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/faraz/streamctl/internal/store"
+	"github.com/faraz/streamctl/pkg/workstream"
+)
+
+// Request headers sent with every delivery
+const (
+	HeaderEvent     = "X-Streamctl-Event"
+	HeaderDelivery  = "X-Streamctl-Delivery"
+	HeaderSignature = "X-Streamctl-Signature"
+)
+
+// Payload is the JSON body POSTed to a webhook
+type Payload struct {
+	ID         int64             `json:"id"`
+	Type       string            `json:"type"`
+	Project    string            `json:"project"`
+	Workstream string            `json:"workstream,omitempty"`
+	Milestone  string            `json:"milestone,omitempty"`
+	Data       map[string]string `json:"data,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
+}
+
+// NewPayload converts an event to its JSON payload
+func NewPayload(ev workstream.Event) Payload {
+	return Payload{
+		ID:         ev.ID,
+		Type:       string(ev.Type),
+		Project:    ev.Project,
+		Workstream: ev.Workstream,
+		Milestone:  ev.Milestone,
+		Data:       ev.Data,
+		CreatedAt:  ev.CreatedAt,
+	}
+}
+
+// Sign returns the signature header value for body: "sha256=" followed by
+// the hex HMAC-SHA256 of body keyed with secret
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is a valid Sign result for body, for use
+// by receivers
+func Verify(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
+
+// Dispatcher sends queued deliveries, retrying failures with exponential
+// backoff
+type Dispatcher struct {
+	Store  *store.Store
+	Client *http.Client
+
+	Interval    time.Duration // How often to check for due deliveries
+	Backoff     time.Duration // Delay before the first retry; doubles with each attempt
+	MaxAttempts int           // Attempts before a delivery is marked failed
+	BatchSize   int           // Deliveries claimed per check
+
+	// ClaimTTL releases claims on workstreams idle for this long, emitting
+	// claim_expired events. Zero disables expiry.
+	ClaimTTL time.Duration
+}
+
+// NewDispatcher creates a Dispatcher with default settings
+func NewDispatcher(st *store.Store) *Dispatcher {
+	return &Dispatcher{
+		Store:       st,
+		Client:      &http.Client{Timeout: 10 * time.Second},
+		Interval:    2 * time.Second,
+		Backoff:     30 * time.Second,
+		MaxAttempts: 6,
+		BatchSize:   20,
+	}
+}
+
+// Run dispatches until ctx is cancelled
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.Interval)
+	defer ticker.Stop()
+	for {
+		d.Dispatch(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Dispatch expires idle claims and sends every delivery that is due,
+// returning how many were attempted
+func (d *Dispatcher) Dispatch(ctx context.Context) (int, error) {
+	if d.ClaimTTL > 0 {
+		if _, err := d.Store.ExpireClaims(d.ClaimTTL); err != nil {
+			return 0, err
+		}
+	}
+
+	// Hold each claim for longer than a request can take
+	deliveries, err := d.Store.ClaimDeliveries(2*d.Client.Timeout+time.Minute, d.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	for _, del := range deliveries {
+		code, err := d.send(ctx, del)
+		if err == nil {
+			d.Store.FinishDelivery(del.ID, code, "", time.Time{})
+			continue
+		}
+		var retryAt time.Time
+		if del.Attempts < d.MaxAttempts {
+			retryAt = time.Now().Add(d.Backoff << (del.Attempts - 1))
+		}
+		d.Store.FinishDelivery(del.ID, code, err.Error(), retryAt)
+	}
+	return len(deliveries), nil
+}
+
+// send POSTs one delivery, returning the response status code
+func (d *Dispatcher) send(ctx context.Context, del store.Delivery) (int, error) {
+	body, err := json.Marshal(NewPayload(del.Event))
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, del.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "streamctl-webhook")
+	req.Header.Set(HeaderEvent, string(del.Event.Type))
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(del.ID, 10))
+	if del.Secret != "" {
+		req.Header.Set(HeaderSignature, Sign(del.Secret, body))
+	}
+
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
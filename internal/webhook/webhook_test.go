@@ -0,0 +1,199 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/faraz/streamctl/internal/store"
+	"github.com/faraz/streamctl/pkg/workstream"
+)
+
+func setupTestStore(t *testing.T) *store.Store {
+	t.Helper()
+	st, err := store.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("store.New() error = %v", err)
+	}
+	t.Cleanup(func() { st.Close() })
+	return st
+}
+
+// receiver records the requests it gets, failing the first failures of them
+type receiver struct {
+	mu       sync.Mutex
+	failures int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.requests = append(rc.requests, r)
+	rc.bodies = append(rc.bodies, body)
+	if rc.failures > 0 {
+		rc.failures--
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func TestSignAndVerify(t *testing.T) {
+	body := []byte(`{"type":"task_done"}`)
+	sig := Sign("s3cret", body)
+	if len(sig) != len("sha256=")+64 || sig[:7] != "sha256=" {
+		t.Errorf("Sign() = %q", sig)
+	}
+	if !Verify("s3cret", body, sig) {
+		t.Errorf("Verify() rejected a valid signature")
+	}
+	if Verify("other", body, sig) || Verify("s3cret", []byte("{}"), sig) {
+		t.Errorf("Verify() accepted an invalid signature")
+	}
+}
+
+func TestDispatchDeliversSignedEvent(t *testing.T) {
+	st := setupTestStore(t)
+	rc := &receiver{}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	st.AddWebhook(&store.Webhook{URL: srv.URL, Secret: "s3cret"})
+	st.Create(&workstream.Workstream{Project: "proj", Name: "auth", State: workstream.StatePending})
+	state := workstream.StateInProgress
+	st.Update("proj", "auth", store.WorkstreamUpdate{State: &state})
+
+	d := NewDispatcher(st)
+	n, err := d.Dispatch(context.Background())
+	if err != nil || n != 1 {
+		t.Fatalf("Dispatch() = %d, %v; want 1 delivery", n, err)
+	}
+
+	if len(rc.requests) != 1 {
+		t.Fatalf("receiver got %d requests, want 1", len(rc.requests))
+	}
+	req, body := rc.requests[0], rc.bodies[0]
+	if req.Header.Get(HeaderEvent) != "state_changed" {
+		t.Errorf("%s = %q", HeaderEvent, req.Header.Get(HeaderEvent))
+	}
+	if !Verify("s3cret", body, req.Header.Get(HeaderSignature)) {
+		t.Errorf("signature %q does not verify", req.Header.Get(HeaderSignature))
+	}
+
+	var p Payload
+	if err := json.Unmarshal(body, &p); err != nil {
+		t.Fatalf("payload is not JSON: %v", err)
+	}
+	if p.Type != "state_changed" || p.Project != "proj" || p.Workstream != "auth" ||
+		p.Data["from"] != "pending" || p.Data["to"] != "in_progress" {
+		t.Errorf("payload = %+v", p)
+	}
+
+	log, _ := st.Deliveries(10)
+	if len(log) != 1 || log[0].Status != store.DeliveryDelivered || log[0].ResponseCode != http.StatusNoContent {
+		t.Errorf("delivery log = %+v", log)
+	}
+
+	// Nothing left to send
+	if n, _ := d.Dispatch(context.Background()); n != 0 {
+		t.Errorf("second Dispatch() sent %d deliveries, want 0", n)
+	}
+}
+
+func TestDispatchRetriesWithBackoff(t *testing.T) {
+	st := setupTestStore(t)
+	rc := &receiver{failures: 1}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	st.AddWebhook(&store.Webhook{URL: srv.URL})
+	st.Create(&workstream.Workstream{Project: "proj", Name: "auth", State: workstream.StatePending})
+	help := true
+	st.Update("proj", "auth", store.WorkstreamUpdate{NeedsHelp: &help})
+
+	d := NewDispatcher(st)
+	d.Backoff = time.Millisecond
+	d.Dispatch(context.Background())
+
+	log, _ := st.Deliveries(10)
+	if len(log) != 1 || log[0].Status != store.DeliveryPending || log[0].ResponseCode != http.StatusServiceUnavailable || log[0].LastError == "" {
+		t.Fatalf("after failed attempt, delivery log = %+v", log)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	d.Dispatch(context.Background())
+
+	log, _ = st.Deliveries(10)
+	if log[0].Status != store.DeliveryDelivered || log[0].Attempts != 2 {
+		t.Errorf("after retry, delivery = %+v", log[0])
+	}
+	if len(rc.requests) != 2 {
+		t.Errorf("receiver got %d requests, want 2", len(rc.requests))
+	}
+}
+
+func TestDispatchGivesUpAfterMaxAttempts(t *testing.T) {
+	st := setupTestStore(t)
+	rc := &receiver{failures: 100}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	st.AddWebhook(&store.Webhook{URL: srv.URL})
+	st.Create(&workstream.Workstream{Project: "proj", Name: "auth", State: workstream.StatePending})
+	state := workstream.StateBlocked
+	st.Update("proj", "auth", store.WorkstreamUpdate{State: &state})
+
+	d := NewDispatcher(st)
+	d.Backoff = time.Millisecond
+	d.MaxAttempts = 3
+	for i := 0; i < 5; i++ {
+		d.Dispatch(context.Background())
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	log, _ := st.Deliveries(10)
+	if log[0].Status != store.DeliveryFailed || log[0].Attempts != 3 {
+		t.Errorf("delivery = %+v, want failed after 3 attempts", log[0])
+	}
+	if len(rc.requests) != 3 {
+		t.Errorf("receiver got %d requests, want 3", len(rc.requests))
+	}
+}
+
+func TestDispatchExpiresClaims(t *testing.T) {
+	st := setupTestStore(t)
+	rc := &receiver{}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	st.AddWebhook(&store.Webhook{URL: srv.URL, Events: []workstream.EventType{workstream.EventClaimExpired}})
+	st.Create(&workstream.Workstream{Project: "proj", Name: "auth", State: workstream.StateInProgress})
+	owner := "agent-1"
+	st.Update("proj", "auth", store.WorkstreamUpdate{Owner: &owner})
+
+	d := NewDispatcher(st)
+	d.ClaimTTL = time.Nanosecond
+	time.Sleep(time.Millisecond)
+	if n, _ := d.Dispatch(context.Background()); n != 1 {
+		t.Fatalf("Dispatch() sent %d deliveries, want 1", n)
+	}
+
+	var p Payload
+	json.Unmarshal(rc.bodies[0], &p)
+	if p.Type != "claim_expired" || p.Data["owner"] != "agent-1" {
+		t.Errorf("payload = %+v", p)
+	}
+	ws, _ := st.Get("proj", "auth")
+	if ws.Owner != "" {
+		t.Errorf("owner = %q, want claim released", ws.Owner)
+	}
+}
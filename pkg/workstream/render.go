@@ -2,13 +2,181 @@ package workstream
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 )
 
 const TimeFormat = "2006-01-02 15:04"
 
+// RenderOptions limits how much of a workstream Render emits. The zero
+// value renders everything.
+type RenderOptions struct {
+	MaxLogEntries int       // Show at most this many log entries (0 = all)
+	Since         time.Time // Only show log entries at or after this time
+	Before        LogCursor // Paging cursor: only show log entries strictly before this position
+	OmitNotes     bool      // Leave out task notes
+	OmitDoneTasks bool      // Leave out done and skipped tasks
+	MaxTokens     int       // Approximate output budget (0 = unlimited)
+
+	IncludeSuperseded bool // Show log entries covered by the summary
+}
+
+// LogCursor is a position in the newest-first log. Entries are ordered by
+// timestamp and then by ID, so entries sharing a timestamp page cleanly.
+type LogCursor struct {
+	Timestamp time.Time
+	ID        int64
+}
+
+// IsZero reports whether the cursor is unset
+func (c LogCursor) IsZero() bool {
+	return c.Timestamp.IsZero() && c.ID == 0
+}
+
+// After reports whether entry sits at or after the cursor, i.e. is not on
+// the page the cursor starts
+func (c LogCursor) After(entry LogEntry) bool {
+	if !entry.Timestamp.Equal(c.Timestamp) {
+		return entry.Timestamp.After(c.Timestamp)
+	}
+	return entry.ID >= c.ID
+}
+
+// String formats the cursor as "RFC3339Nano/ID"
+func (c LogCursor) String() string {
+	return c.Timestamp.UTC().Format(time.RFC3339Nano) + "/" + strconv.FormatInt(c.ID, 10)
+}
+
+// ParseLogCursor parses a cursor from String. A bare RFC3339 time, as older
+// markers gave, pages from just before that time.
+func ParseLogCursor(s string) (LogCursor, error) {
+	ts, id, hasID := strings.Cut(s, "/")
+	t, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return LogCursor{}, fmt.Errorf("invalid log cursor %q", s)
+	}
+	c := LogCursor{Timestamp: t}
+	if hasID {
+		if c.ID, err = strconv.ParseInt(id, 10, 64); err != nil {
+			return LogCursor{}, fmt.Errorf("invalid log cursor %q", s)
+		}
+	}
+	return c, nil
+}
+
+// cursorAt returns the cursor of the page starting at entry
+func cursorAt(entry LogEntry) LogCursor {
+	return LogCursor{Timestamp: entry.Timestamp, ID: entry.ID + 1}
+}
+
+// charsPerToken is the rough ratio used to turn MaxTokens into a character budget
+const charsPerToken = 4
+
 // Render converts a Workstream struct to markdown format for display
 func Render(ws *Workstream) string {
+	return RenderWith(ws, RenderOptions{})
+}
+
+// RenderWith renders a workstream within the given limits. Log entries that
+// don't fit are summarised by a trailing marker carrying a cursor for
+// RenderOptions.Before to fetch the next page. Entries superseded by the
+// workstream summary are left out, except on history pages.
+//
+// When MaxTokens is set, notes and then done tasks are dropped if the
+// non-log sections alone exceed the budget, and log entries are added newest
+// first until it is used up.
+func RenderWith(ws *Workstream, opts RenderOptions) string {
+	var b strings.Builder
+
+	// A history page only needs the log
+	if opts.Before.IsZero() {
+		head := renderHead(ws, opts)
+		if opts.MaxTokens > 0 && !opts.OmitNotes && len(head) > opts.MaxTokens*charsPerToken {
+			opts.OmitNotes = true
+			head = renderHead(ws, opts)
+		}
+		if opts.MaxTokens > 0 && !opts.OmitDoneTasks && len(head) > opts.MaxTokens*charsPerToken {
+			opts.OmitDoneTasks = true
+			head = renderHead(ws, opts)
+		}
+		b.WriteString(head)
+	} else {
+		b.WriteString("# Workstream: ")
+		b.WriteString(ws.Name)
+		b.WriteString("\n\n")
+	}
+
+	// Log
+	b.WriteString("## Log\n")
+
+	// Skip entries at or after the cursor (log is newest first)
+	log := ws.Log
+	for len(log) > 0 && !opts.Before.IsZero() && opts.Before.After(log[0]) {
+		log = log[1:]
+	}
+
+	// Entries covered by the summary only appear when paging through history
+	var superseded []LogEntry
+	if opts.Before.IsZero() && !opts.IncludeSuperseded {
+		var current []LogEntry
+		for _, entry := range log {
+			if entry.Superseded {
+				superseded = append(superseded, entry)
+			} else {
+				current = append(current, entry)
+			}
+		}
+		log = current
+	}
+
+	// Entries older than Since are left out rather than paged
+	recent := log
+	if !opts.Since.IsZero() {
+		for i, entry := range log {
+			if entry.Timestamp.Before(opts.Since) {
+				recent = log[:i]
+				break
+			}
+		}
+	}
+
+	shown := 0
+	for _, entry := range recent {
+		if opts.MaxLogEntries > 0 && shown >= opts.MaxLogEntries {
+			break
+		}
+		text := renderLogEntry(entry)
+		if opts.MaxTokens > 0 && b.Len()+len(text) > opts.MaxTokens*charsPerToken {
+			break
+		}
+		b.WriteString(text)
+		shown++
+	}
+
+	if omitted := len(recent) - shown; omitted > 0 {
+		b.WriteString(fmt.Sprintf("_%d older %s omitted (before=%q for more)_\n", omitted, entryNoun(omitted), cursorAt(log[shown]).String()))
+	}
+	if earlier := len(log) - len(recent); earlier > 0 {
+		b.WriteString(fmt.Sprintf("_%d %s before %s left out by since_\n", earlier, entryNoun(earlier), opts.Since.UTC().Format(TimeFormat)))
+	}
+	if len(superseded) > 0 {
+		b.WriteString(fmt.Sprintf("_%d earlier entries covered by the summary (before=%q for originals)_\n", len(superseded), cursorAt(superseded[0]).String()))
+	}
+
+	return b.String()
+}
+
+// entryNoun returns "entry" or "entries" to suit n
+func entryNoun(n int) string {
+	if n == 1 {
+		return "entry"
+	}
+	return "entries"
+}
+
+// renderHead renders everything above the log
+func renderHead(ws *Workstream, opts RenderOptions) string {
 	var b strings.Builder
 
 	// Header
@@ -29,8 +197,20 @@ func Render(ws *Workstream) string {
 		b.WriteString(ws.Owner)
 		b.WriteString("\n")
 	}
+	if ws.NeedsHelp {
+		b.WriteString("Needs help: ")
+		if ws.HelpQuestion != "" {
+			b.WriteString(ws.HelpQuestion)
+		} else {
+			b.WriteString("yes")
+		}
+		b.WriteString("\n")
+	}
 	b.WriteString("\n")
 
+	// Decisions (only if any were logged), so the "why" is visible up front
+	renderDecisions(&b, ws.Log)
+
 	// Dependencies (only if there are any)
 	if len(ws.BlockedBy) > 0 || len(ws.Blocks) > 0 {
 		b.WriteString("## Dependencies\n")
@@ -56,7 +236,105 @@ func Render(ws *Workstream) string {
 
 	// Plan
 	b.WriteString("## Plan\n")
-	for i, item := range ws.Plan {
+	if hidden := renderPlanItems(&b, ws.Plan, 0, opts); hidden > 0 {
+		b.WriteString(fmt.Sprintf("_%d done tasks hidden_\n", hidden))
+	}
+	b.WriteString("\n")
+
+	// Summary of compacted log entries, directly above the newer entries
+	if ws.Summary != "" {
+		b.WriteString("## Summary\n")
+		b.WriteString(ws.Summary)
+		b.WriteString("\n")
+		if !ws.SummaryThrough.IsZero() {
+			b.WriteString("_Covers the log through " + ws.SummaryThrough.Format(TimeFormat) + "_\n")
+		}
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// renderLogEntry renders one log entry with its header
+func renderLogEntry(entry LogEntry) string {
+	var b strings.Builder
+	b.WriteString("### ")
+	b.WriteString(entry.Timestamp.Format(TimeFormat))
+	if entry.Type != "" && entry.Type != EntryNote {
+		b.WriteString(" [" + string(entry.Type) + "]")
+	}
+	if who := entry.Attribution(); who != "" {
+		b.WriteString(" @" + who)
+	}
+	b.WriteString("\n")
+	b.WriteString(entry.Content)
+	b.WriteString("\n\n")
+	return b.String()
+}
+
+// renderDecisions writes a summary of decision log entries, newest first
+func renderDecisions(b *strings.Builder, log []LogEntry) {
+	var decisions []LogEntry
+	for _, entry := range log {
+		if entry.Type == EntryDecision {
+			decisions = append(decisions, entry)
+		}
+	}
+	if len(decisions) == 0 {
+		return
+	}
+
+	b.WriteString("## Decisions\n")
+	for _, d := range decisions {
+		b.WriteString(fmt.Sprintf("- %s: %s\n", d.Timestamp.Format(TimeFormat), firstLine(d.Content)))
+		if d.Rationale != "" {
+			b.WriteString("  Rationale: " + d.Rationale + "\n")
+		}
+		if len(d.Alternatives) > 0 {
+			b.WriteString("  Rejected: " + strings.Join(d.Alternatives, "; ") + "\n")
+		}
+	}
+	b.WriteString("\n")
+}
+
+// firstLine returns the first line of s
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
+
+// FilterLog returns the log entries of the given type, or all entries if
+// entryType is empty
+func FilterLog(log []LogEntry, entryType EntryType) []LogEntry {
+	if entryType == "" {
+		return log
+	}
+	var filtered []LogEntry
+	for _, entry := range log {
+		t := entry.Type
+		if t == "" {
+			t = EntryNote
+		}
+		if t == entryType {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered
+}
+
+// renderPlanItems writes a numbered task list, indenting subtasks and notes
+// three spaces per level so they nest as markdown lists. Numbers keep their
+// positions when done tasks are left out. Returns the number of tasks left out.
+func renderPlanItems(b *strings.Builder, items []PlanItem, depth int, opts RenderOptions) int {
+	indent := strings.Repeat("   ", depth)
+	hidden := 0
+	for i, item := range items {
+		if opts.OmitDoneTasks && (item.Status == TaskDone || item.Status == TaskSkipped || (item.Status == "" && item.Complete)) {
+			hidden++
+			continue
+		}
 		marker := "[ ]"
 		switch item.Status {
 		case TaskInProgress:
@@ -71,30 +349,23 @@ func Render(ws *Workstream) string {
 				marker = "[x]"
 			}
 		}
-		b.WriteString(fmt.Sprintf("%d. %s %s\n", i+1, marker, item.Text))
-		if item.Notes != "" {
+		b.WriteString(fmt.Sprintf("%s%d. %s %s", indent, i+1, marker, item.Text))
+		if item.ID != "" {
+			b.WriteString(" (" + item.ID + ")")
+		}
+		b.WriteString("\n")
+		if item.Notes != "" && !opts.OmitNotes {
 			// Indent notes under the task
 			lines := strings.Split(item.Notes, "\n")
 			for _, line := range lines {
-				b.WriteString("   ")
+				b.WriteString(indent + "   ")
 				b.WriteString(line)
 				b.WriteString("\n")
 			}
 		}
+		hidden += renderPlanItems(b, item.Children, depth+1, opts)
 	}
-	b.WriteString("\n")
-
-	// Log
-	b.WriteString("## Log\n")
-	for _, entry := range ws.Log {
-		b.WriteString("### ")
-		b.WriteString(entry.Timestamp.Format(TimeFormat))
-		b.WriteString("\n")
-		b.WriteString(entry.Content)
-		b.WriteString("\n\n")
-	}
-
-	return b.String()
+	return hidden
 }
 
 // Serialize is an alias for Render (for backward compatibility)
@@ -145,3 +416,82 @@ func RenderMilestone(m *Milestone) string {
 
 	return b.String()
 }
+
+// RenderBriefing converts a session briefing to markdown format
+func RenderBriefing(br *Briefing) string {
+	var b strings.Builder
+
+	b.WriteString(fmt.Sprintf("# Session Briefing: %s on %s\n\n", br.Agent, br.Project))
+
+	if br.Previous != nil {
+		b.WriteString("## Last Session\n")
+		b.WriteString(fmt.Sprintf("Ended: %s (%s)\n", br.Previous.EndedAt.Format(TimeFormat), FormatDuration(br.Previous.Duration())))
+		if br.Previous.NextSteps != "" {
+			b.WriteString("Next steps:\n")
+			b.WriteString(br.Previous.NextSteps)
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("## Claimed Work\n")
+	if len(br.Claimed) == 0 {
+		b.WriteString("_Nothing claimed_\n")
+	}
+	for _, ws := range br.Claimed {
+		b.WriteString(fmt.Sprintf("- %s (%s)\n", ws.Name, ws.State))
+	}
+	b.WriteString("\n")
+
+	if len(br.InProgressTasks) > 0 {
+		b.WriteString("## In-Progress Tasks\n")
+		for _, t := range br.InProgressTasks {
+			b.WriteString(fmt.Sprintf("- %s: %s", t.Workstream, t.Task.Text))
+			if t.Task.ID != "" {
+				b.WriteString(" (" + t.Task.ID + ")")
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+
+	if len(br.NeedsHelp) > 0 {
+		b.WriteString("## Needs Help\n")
+		for _, ws := range br.NeedsHelp {
+			b.WriteString(fmt.Sprintf("- %s (%s)\n", ws.Name, ws.State))
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("## Activity by Others")
+	if br.Previous != nil {
+		b.WriteString(" Since Last Session")
+	}
+	b.WriteString("\n")
+	if len(br.RecentActivity) == 0 {
+		b.WriteString("_None_\n")
+	}
+	for _, e := range br.RecentActivity {
+		b.WriteString(fmt.Sprintf("- %s %s", e.Timestamp.Format(TimeFormat), e.WorkstreamName))
+		if who := e.Attribution(); who != "" {
+			b.WriteString(" @" + who)
+		}
+		b.WriteString(": " + firstLine(e.Content) + "\n")
+	}
+
+	return b.String()
+}
+
+// FormatDuration formats a duration coarsely for humans, e.g. "45m" or "2h5m"
+func FormatDuration(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh%dm", int(d.Hours()), int(d.Minutes())%60)
+	default:
+		return fmt.Sprintf("%dd%dh", int(d.Hours()/24), int(d.Hours())%24)
+	}
+}
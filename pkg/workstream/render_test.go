@@ -1,6 +1,7 @@
 package workstream
 
 import (
+	"fmt"
 	"strings"
 	"testing"
 	"time"
@@ -168,6 +169,56 @@ func TestRenderTaskNotes(t *testing.T) {
 	}
 }
 
+func TestRenderTaskIDs(t *testing.T) {
+	ws := &Workstream{
+		Name:       "IDs Test",
+		State:      StateInProgress,
+		LastUpdate: time.Now(),
+		Plan: []PlanItem{
+			{ID: "t7", Text: "First", Status: TaskDone},
+			{ID: "t3", Text: "Second", Status: TaskPending},
+		},
+	}
+
+	output := Render(ws)
+
+	if !strings.Contains(output, "1. [x] First (t7)\n") {
+		t.Errorf("Task ID not rendered after text:\n%s", output)
+	}
+	if !strings.Contains(output, "2. [ ] Second (t3)\n") {
+		t.Errorf("Task ID not rendered after text:\n%s", output)
+	}
+}
+
+func TestRenderSubtasks(t *testing.T) {
+	ws := &Workstream{
+		Name:       "Subtask Test",
+		State:      StateInProgress,
+		LastUpdate: time.Now(),
+		Plan: []PlanItem{
+			{ID: "t1", Text: "Parent", Status: TaskInProgress, Children: []PlanItem{
+				{ID: "t2", Text: "Child done", Status: TaskDone},
+				{ID: "t3", Text: "Child with kids", Status: TaskPending, Notes: "note", Children: []PlanItem{
+					{ID: "t4", Text: "Grandchild", Status: TaskPending},
+				}},
+			}},
+			{ID: "t5", Text: "Sibling", Status: TaskPending},
+		},
+	}
+
+	output := Render(ws)
+
+	want := "1. [>] Parent (t1)\n" +
+		"   1. [x] Child done (t2)\n" +
+		"   2. [ ] Child with kids (t3)\n" +
+		"      note\n" +
+		"      1. [ ] Grandchild (t4)\n" +
+		"2. [ ] Sibling (t5)\n"
+	if !strings.Contains(output, want) {
+		t.Errorf("Subtasks not indented correctly, got:\n%s", output)
+	}
+}
+
 func TestRenderDependencies(t *testing.T) {
 	ws := &Workstream{
 		Name:       "Dependency Test",
@@ -220,3 +271,354 @@ func TestRenderNoDependencies(t *testing.T) {
 		t.Errorf("Dependencies section should be omitted when empty")
 	}
 }
+
+func TestRenderDecisions(t *testing.T) {
+	ts := time.Date(2026, 2, 10, 10, 0, 0, 0, time.UTC)
+	ws := &Workstream{
+		Name:       "Auth",
+		State:      StateInProgress,
+		LastUpdate: ts,
+		Objective:  "Add auth.",
+		Log: []LogEntry{
+			{Timestamp: ts, Content: "Wired up login form", Type: EntryProgress},
+			{
+				Timestamp:    ts,
+				Content:      "Use JWT instead of sessions\nMore detail here",
+				Type:         EntryDecision,
+				Alternatives: []string{"server sessions", "opaque tokens"},
+				Rationale:    "stateless API servers",
+			},
+		},
+	}
+
+	output := Render(ws)
+
+	decisions := strings.Index(output, "## Decisions")
+	if decisions < 0 {
+		t.Fatalf("Missing Decisions section:\n%s", output)
+	}
+	if objective := strings.Index(output, "## Objective"); decisions > objective {
+		t.Errorf("Decisions should be rendered before Objective")
+	}
+	if !strings.Contains(output, "- 2026-02-10 10:00: Use JWT instead of sessions\n") {
+		t.Errorf("Decision summary should use the first line of content:\n%s", output)
+	}
+	if !strings.Contains(output, "Rationale: stateless API servers") {
+		t.Errorf("Missing rationale")
+	}
+	if !strings.Contains(output, "Rejected: server sessions; opaque tokens") {
+		t.Errorf("Missing alternatives")
+	}
+	if !strings.Contains(output, "### 2026-02-10 10:00 [progress]") {
+		t.Errorf("Log header should show entry type")
+	}
+}
+
+func TestRenderNoDecisions(t *testing.T) {
+	ws := &Workstream{
+		Name:       "Plain",
+		State:      StatePending,
+		LastUpdate: time.Now(),
+		Log:        []LogEntry{{Timestamp: time.Now(), Content: "note", Type: EntryNote}},
+	}
+
+	output := Render(ws)
+
+	if strings.Contains(output, "## Decisions") {
+		t.Errorf("Decisions section should be omitted when there are none")
+	}
+	if strings.Contains(output, "[note]") {
+		t.Errorf("Note entries should not be labelled")
+	}
+}
+
+func TestRenderLogAttribution(t *testing.T) {
+	ts := time.Date(2026, 2, 10, 10, 0, 0, 0, time.UTC)
+	ws := &Workstream{
+		Name:       "Auth",
+		State:      StateInProgress,
+		LastUpdate: ts,
+		Log: []LogEntry{
+			{Timestamp: ts, Content: "By author", Type: EntryProgress, Author: "alice", Client: "claude-code/1.0"},
+			{Timestamp: ts, Content: "By client", Client: "claude-code/1.0"},
+			{Timestamp: ts, Content: "Anonymous"},
+		},
+	}
+
+	output := Render(ws)
+
+	if !strings.Contains(output, "### 2026-02-10 10:00 [progress] @alice\nBy author") {
+		t.Errorf("Explicit author should win over client:\n%s", output)
+	}
+	if !strings.Contains(output, "### 2026-02-10 10:00 @claude-code/1.0\nBy client") {
+		t.Errorf("Client should be shown when no author given:\n%s", output)
+	}
+	if !strings.Contains(output, "### 2026-02-10 10:00\nAnonymous") {
+		t.Errorf("Unattributed entries should have a bare header:\n%s", output)
+	}
+}
+
+func budgetTestWorkstream() *Workstream {
+	base := time.Date(2026, 2, 10, 10, 0, 0, 0, time.UTC)
+	ws := &Workstream{
+		Name:       "Long Runner",
+		State:      StateInProgress,
+		LastUpdate: base,
+		Objective:  "Keep going.",
+		Plan: []PlanItem{
+			{ID: "t1", Text: "Finished step", Status: TaskDone, Notes: "Long finished notes"},
+			{ID: "t2", Text: "Current step", Status: TaskInProgress, Notes: "Current notes"},
+		},
+	}
+	// Newest first, one entry per hour
+	for i := 0; i < 10; i++ {
+		ws.Log = append(ws.Log, LogEntry{
+			ID:        int64(10 - i),
+			Timestamp: base.Add(-time.Duration(i) * time.Hour),
+			Content:   fmt.Sprintf("entry %d", i),
+		})
+	}
+	return ws
+}
+
+func TestRenderWithMaxLogEntries(t *testing.T) {
+	ws := budgetTestWorkstream()
+
+	output := RenderWith(ws, RenderOptions{MaxLogEntries: 3})
+
+	if !strings.Contains(output, "entry 2") || strings.Contains(output, "entry 3") {
+		t.Errorf("Expected only the 3 newest entries:\n%s", output)
+	}
+	if !strings.Contains(output, "_7 older entries omitted") {
+		t.Errorf("Missing omitted marker:\n%s", output)
+	}
+
+	// Following the cursor returns the next page
+	cursor := ws.Log[3].Timestamp.Format(time.RFC3339Nano) + "/8"
+	if !strings.Contains(output, `before="`+cursor+`"`) {
+		t.Fatalf("Missing cursor %s:\n%s", cursor, output)
+	}
+	before, err := ParseLogCursor(cursor)
+	if err != nil {
+		t.Fatalf("ParseLogCursor() error = %v", err)
+	}
+	page := RenderWith(ws, RenderOptions{MaxLogEntries: 3, Before: before})
+	if !strings.Contains(page, "entry 3") || !strings.Contains(page, "entry 5") || strings.Contains(page, "entry 2") || strings.Contains(page, "entry 6") {
+		t.Errorf("Second page should hold entries 3-5:\n%s", page)
+	}
+	if strings.Contains(page, "## Plan") {
+		t.Errorf("History pages should only contain the log:\n%s", page)
+	}
+	if !strings.Contains(page, "_4 older entries omitted") {
+		t.Errorf("Second page marker wrong:\n%s", page)
+	}
+}
+
+func TestRenderWithSince(t *testing.T) {
+	ws := budgetTestWorkstream()
+
+	output := RenderWith(ws, RenderOptions{Since: ws.Log[1].Timestamp})
+
+	if !strings.Contains(output, "entry 1") || strings.Contains(output, "entry 2") {
+		t.Errorf("Expected entries since the cutoff only:\n%s", output)
+	}
+	if strings.Contains(output, "older entries omitted") {
+		t.Errorf("Entries before since are not paged:\n%s", output)
+	}
+	if !strings.Contains(output, "_8 entries before 2026-02-10 09:00 left out by since_") {
+		t.Errorf("Missing since marker:\n%s", output)
+	}
+
+	// Limits and since are counted separately
+	output = RenderWith(ws, RenderOptions{Since: ws.Log[4].Timestamp, MaxLogEntries: 2})
+	if !strings.Contains(output, "_3 older entries omitted") || !strings.Contains(output, "_5 entries before") {
+		t.Errorf("Expected 3 omitted by the limit and 5 by since:\n%s", output)
+	}
+}
+
+func TestRenderWithPagesEntriesSharingTimestamp(t *testing.T) {
+	ws := budgetTestWorkstream()
+	for i := range ws.Log {
+		ws.Log[i].Timestamp = ws.Log[0].Timestamp
+	}
+
+	seen := map[string]bool{}
+	opts := RenderOptions{MaxLogEntries: 3}
+	for page := 0; page < 5; page++ {
+		output := RenderWith(ws, opts)
+		for i := range ws.Log {
+			entry := fmt.Sprintf("entry %d\n", i)
+			if strings.Contains(output, entry) {
+				if seen[entry] {
+					t.Errorf("%q repeated on page %d", entry, page)
+				}
+				seen[entry] = true
+			}
+		}
+		marker := strings.Index(output, `before="`)
+		if marker < 0 {
+			break
+		}
+		cursor := output[marker+len(`before="`):]
+		opts.Before, _ = ParseLogCursor(cursor[:strings.Index(cursor, `"`)])
+	}
+	if len(seen) != len(ws.Log) {
+		t.Errorf("Paging showed %d of %d entries", len(seen), len(ws.Log))
+	}
+}
+
+func TestParseLogCursor(t *testing.T) {
+	at := time.Date(2026, 10, 12, 9, 14, 3, 0, time.UTC)
+	c, err := ParseLogCursor(LogCursor{Timestamp: at, ID: 42}.String())
+	if err != nil || !c.Timestamp.Equal(at) || c.ID != 42 {
+		t.Errorf("ParseLogCursor(String()) = %+v, %v", c, err)
+	}
+	// Bare times from older markers still parse
+	if c, err := ParseLogCursor("2026-10-12T09:14:03.000000001Z"); err != nil || c.ID != 0 {
+		t.Errorf("ParseLogCursor(time) = %+v, %v", c, err)
+	}
+	if _, err := ParseLogCursor("yesterday"); err == nil {
+		t.Error("ParseLogCursor() should reject non-times")
+	}
+}
+
+func TestRenderWithOmitNotesAndDoneTasks(t *testing.T) {
+	ws := budgetTestWorkstream()
+
+	output := RenderWith(ws, RenderOptions{OmitNotes: true, OmitDoneTasks: true})
+
+	if strings.Contains(output, "Finished step") {
+		t.Errorf("Done task should be hidden:\n%s", output)
+	}
+	if !strings.Contains(output, "2. [>] Current step (t2)") {
+		t.Errorf("Remaining task should keep its position number:\n%s", output)
+	}
+	if strings.Contains(output, "Current notes") {
+		t.Errorf("Notes should be omitted:\n%s", output)
+	}
+	if !strings.Contains(output, "_1 done tasks hidden_") {
+		t.Errorf("Missing hidden tasks marker:\n%s", output)
+	}
+}
+
+func TestRenderWithMaxTokens(t *testing.T) {
+	ws := budgetTestWorkstream()
+	full := Render(ws)
+
+	output := RenderWith(ws, RenderOptions{MaxTokens: 100})
+
+	if len(output) >= len(full) {
+		t.Errorf("Budgeted output should be shorter than full render")
+	}
+	if !strings.Contains(output, "## Plan") || !strings.Contains(output, "entry 0") {
+		t.Errorf("Budgeted output should keep the head and newest entries:\n%s", output)
+	}
+	if !strings.Contains(output, "older entries omitted") {
+		t.Errorf("Missing omitted marker:\n%s", output)
+	}
+}
+
+func TestRenderWithZeroOptionsMatchesRender(t *testing.T) {
+	ws := budgetTestWorkstream()
+	if RenderWith(ws, RenderOptions{}) != Render(ws) {
+		t.Errorf("Zero options should render everything")
+	}
+	if strings.Contains(Render(ws), "omitted") {
+		t.Errorf("Full render should not have an omitted marker")
+	}
+}
+
+func TestRenderSummaryHidesSupersededEntries(t *testing.T) {
+	ws := budgetTestWorkstream()
+	ws.Summary = "Entries 5-9 were setup work."
+	ws.SummaryThrough = ws.Log[5].Timestamp
+	for i := 5; i < len(ws.Log); i++ {
+		ws.Log[i].Superseded = true
+	}
+
+	output := Render(ws)
+
+	summary := strings.Index(output, "## Summary\nEntries 5-9 were setup work.")
+	if summary < 0 {
+		t.Fatalf("Missing summary:\n%s", output)
+	}
+	if summary > strings.Index(output, "## Log") {
+		t.Errorf("Summary should precede the log")
+	}
+	if !strings.Contains(output, "entry 4") || strings.Contains(output, "entry 5") {
+		t.Errorf("Only entries newer than the summary should be shown:\n%s", output)
+	}
+	cursor := ws.Log[5].Timestamp.Format(time.RFC3339Nano) + "/6"
+	if !strings.Contains(output, `_5 earlier entries covered by the summary (before="`+cursor+`" for originals)_`) {
+		t.Errorf("Missing superseded marker:\n%s", output)
+	}
+
+	// History pages and IncludeSuperseded show the originals
+	before, _ := ParseLogCursor(cursor)
+	if page := RenderWith(ws, RenderOptions{Before: before}); !strings.Contains(page, "entry 9") {
+		t.Errorf("History page should include superseded entries:\n%s", page)
+	}
+	if full := RenderWith(ws, RenderOptions{IncludeSuperseded: true}); !strings.Contains(full, "entry 9") {
+		t.Errorf("IncludeSuperseded should show superseded entries")
+	}
+}
+
+func TestRenderBriefing(t *testing.T) {
+	ended := time.Date(2026, 2, 10, 18, 0, 0, 0, time.UTC)
+	br := &Briefing{
+		Project: "proj",
+		Agent:   "agent-1",
+		Previous: &Session{
+			StartedAt: ended.Add(-90 * time.Minute),
+			EndedAt:   ended,
+			NextSteps: "Finish parser",
+		},
+		Claimed:         []Workstream{{Name: "parser", State: StateInProgress}},
+		InProgressTasks: []BriefingTask{{Workstream: "parser", Task: PlanItem{ID: "t3", Text: "Tokenizer"}}},
+		NeedsHelp:       []Workstream{{Name: "infra", State: StateBlocked}},
+		RecentActivity: []ActivityEntry{
+			{WorkstreamName: "infra", Timestamp: ended.Add(time.Hour), Content: "Waiting on DNS\nmore", Author: "agent-2"},
+		},
+	}
+
+	output := RenderBriefing(br)
+
+	for _, want := range []string{
+		"# Session Briefing: agent-1 on proj",
+		"Ended: 2026-02-10 18:00 (1h30m)",
+		"Finish parser",
+		"- parser (in_progress)",
+		"- parser: Tokenizer (t3)",
+		"## Needs Help\n- infra (blocked)",
+		"## Activity by Others Since Last Session\n- 2026-02-10 19:00 infra @agent-2: Waiting on DNS\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("briefing missing %q:\n%s", want, output)
+		}
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	cases := map[time.Duration]string{
+		30 * time.Second:            "30s",
+		45 * time.Minute:            "45m",
+		2*time.Hour + 5*time.Minute: "2h5m",
+		50 * time.Hour:              "2d2h",
+	}
+	for d, want := range cases {
+		if got := FormatDuration(d); got != want {
+			t.Errorf("FormatDuration(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
+
+func TestRenderNeedsHelp(t *testing.T) {
+	ws := &Workstream{Name: "x", State: StateBlocked, LastUpdate: time.Now(), NeedsHelp: true, HelpQuestion: "Which region?"}
+	if output := Render(ws); !strings.Contains(output, "Needs help: Which region?\n") {
+		t.Errorf("Missing help question:\n%s", output)
+	}
+	ws.HelpQuestion = ""
+	if output := Render(ws); !strings.Contains(output, "Needs help: yes\n") {
+		t.Errorf("Missing bare needs help flag:\n%s", output)
+	}
+}
@@ -0,0 +1,108 @@
+package workstream
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StaleRulesEnv names the environment variable that overrides
+// DefaultStaleRules, e.g. "in_progress=3d,pending=30d,blocked=off"
+const StaleRulesEnv = "STREAMCTL_STALE"
+
+// StaleRules are how long a workstream may go without an update in each
+// state before it counts as stale. States without a rule never go stale.
+type StaleRules map[State]time.Duration
+
+// DefaultStaleRules flag in-progress work untouched for a week and blocked
+// work untouched for two
+var DefaultStaleRules = StaleRules{
+	StateInProgress: 7 * 24 * time.Hour,
+	StateBlocked:    14 * 24 * time.Hour,
+}
+
+// Stale reports whether a workstream in state, last updated at lastUpdate,
+// is stale at now
+func (r StaleRules) Stale(state State, lastUpdate, now time.Time) bool {
+	after, ok := r[state]
+	return ok && now.Sub(lastUpdate) >= after
+}
+
+// States returns the states that have a rule, in a stable order
+func (r StaleRules) States() []State {
+	states := make([]State, 0, len(r))
+	for state := range r {
+		states = append(states, state)
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i] < states[j] })
+	return states
+}
+
+func (r StaleRules) String() string {
+	var parts []string
+	for _, state := range r.States() {
+		parts = append(parts, fmt.Sprintf("%s=%s", state, FormatDuration(r[state])))
+	}
+	return strings.Join(parts, ",")
+}
+
+// ParseStaleRules overrides rules with comma-separated STATE=DURATION pairs.
+// Durations are Go durations or whole days or weeks ("10d", "2w"); "off"
+// removes a state's rule.
+func ParseStaleRules(rules StaleRules, s string) (StaleRules, error) {
+	parsed := StaleRules{}
+	for state, after := range rules {
+		parsed[state] = after
+	}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid stale rule %q: want STATE=DURATION", part)
+		}
+		state := State(strings.TrimSpace(name))
+		switch state {
+		case StatePending, StateInProgress, StateBlocked:
+		default:
+			return nil, fmt.Errorf("invalid stale rule %q: state must be pending, in_progress or blocked", part)
+		}
+		value = strings.TrimSpace(value)
+		if value == "off" {
+			delete(parsed, state)
+			continue
+		}
+		after, err := parseDays(value)
+		if err != nil || after <= 0 {
+			return nil, fmt.Errorf("invalid stale rule %q: duration must be positive, like 36h, 7d or 2w", part)
+		}
+		parsed[state] = after
+	}
+	return parsed, nil
+}
+
+// StaleRulesFromEnv returns DefaultStaleRules overridden by STREAMCTL_STALE.
+// If that is invalid, it returns the defaults with the error.
+func StaleRulesFromEnv() (StaleRules, error) {
+	rules, err := ParseStaleRules(DefaultStaleRules, os.Getenv(StaleRulesEnv))
+	if err != nil {
+		return DefaultStaleRules, fmt.Errorf("%s: %w", StaleRulesEnv, err)
+	}
+	return rules, nil
+}
+
+// parseDays parses a Go duration, or a whole number of days or weeks
+func parseDays(s string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			days, err := strconv.Atoi(n)
+			return time.Duration(days) * unit, err
+		}
+	}
+	return time.ParseDuration(s)
+}
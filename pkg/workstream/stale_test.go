@@ -0,0 +1,67 @@
+package workstream
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStaleRules(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	rules := DefaultStaleRules
+
+	tests := []struct {
+		state State
+		idle  time.Duration
+		want  bool
+	}{
+		{StateInProgress, 6 * 24 * time.Hour, false},
+		{StateInProgress, 7 * 24 * time.Hour, true},
+		{StateBlocked, 10 * 24 * time.Hour, false},
+		{StateBlocked, 15 * 24 * time.Hour, true},
+		{StatePending, 365 * 24 * time.Hour, false}, // No rule
+		{StateDone, 365 * 24 * time.Hour, false},
+	}
+	for _, tt := range tests {
+		if got := rules.Stale(tt.state, now.Add(-tt.idle), now); got != tt.want {
+			t.Errorf("Stale(%s, %v idle) = %v, want %v", tt.state, tt.idle, got, tt.want)
+		}
+	}
+}
+
+func TestParseStaleRules(t *testing.T) {
+	rules, err := ParseStaleRules(DefaultStaleRules, "in_progress=3d, pending=2w,blocked=off")
+	if err != nil {
+		t.Fatalf("ParseStaleRules() error = %v", err)
+	}
+	if got := rules.String(); got != "in_progress=3d0h,pending=14d0h" {
+		t.Errorf("rules = %s", got)
+	}
+	if _, ok := DefaultStaleRules[StatePending]; ok {
+		t.Errorf("parsing should not change the rules it starts from")
+	}
+
+	rules, err = ParseStaleRules(DefaultStaleRules, "in_progress=36h")
+	if err != nil || rules[StateInProgress] != 36*time.Hour || rules[StateBlocked] != DefaultStaleRules[StateBlocked] {
+		t.Errorf("rules = %v, %v", rules, err)
+	}
+
+	for _, bad := range []string{"in_progress", "done=3d", "in_progress=soon", "blocked=-1h", "pending=0d"} {
+		if _, err := ParseStaleRules(DefaultStaleRules, bad); err == nil {
+			t.Errorf("ParseStaleRules(%q) should fail", bad)
+		}
+	}
+}
+
+func TestStaleRulesFromEnv(t *testing.T) {
+	t.Setenv(StaleRulesEnv, "pending=30d")
+	rules, err := StaleRulesFromEnv()
+	if err != nil || rules[StatePending] != 30*24*time.Hour || rules[StateInProgress] != DefaultStaleRules[StateInProgress] {
+		t.Errorf("rules = %v, %v", rules, err)
+	}
+
+	t.Setenv(StaleRulesEnv, "pending=later")
+	rules, err = StaleRulesFromEnv()
+	if err == nil || len(rules) != len(DefaultStaleRules) {
+		t.Errorf("an invalid variable should fall back to the defaults with an error: %v, %v", rules, err)
+	}
+}
@@ -1,6 +1,9 @@
 package workstream
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 // State represents the current state of a workstream
 type State string
@@ -24,16 +27,112 @@ const (
 
 // PlanItem represents a single item in the workstream plan
 type PlanItem struct {
+	ID       string // Stable short ID (e.g. "t12"), unaffected by reordering or removal
 	Text     string
 	Status   TaskStatus
-	Notes    string // Markdown-formatted notes (code snippets, details, links)
-	Complete bool   // Deprecated: use Status instead
+	Notes    string     // Markdown-formatted notes (code snippets, details, links)
+	Complete bool       // Deprecated: use Status instead
+	Children []PlanItem // Subtasks, in order; a parent's status rolls up from these
+}
+
+// DoneChildren returns how many direct subtasks are done or skipped
+func (p PlanItem) DoneChildren() int {
+	n := 0
+	for _, c := range p.Children {
+		if c.Status == TaskDone || c.Status == TaskSkipped {
+			n++
+		}
+	}
+	return n
+}
+
+// RollUpStatus derives a parent task's status from its subtasks' statuses:
+// all skipped is skipped, all finished (done or skipped) is done, all pending
+// is pending, and anything else is in_progress. Returns "" for no subtasks.
+func RollUpStatus(children []TaskStatus) TaskStatus {
+	if len(children) == 0 {
+		return ""
+	}
+	var pending, skipped, done int
+	for _, st := range children {
+		switch st {
+		case TaskDone:
+			done++
+		case TaskSkipped:
+			skipped++
+		case TaskPending, "":
+			pending++
+		}
+	}
+	switch {
+	case skipped == len(children):
+		return TaskSkipped
+	case done+skipped == len(children):
+		return TaskDone
+	case pending == len(children):
+		return TaskPending
+	default:
+		return TaskInProgress
+	}
+}
+
+// EntryType classifies a log entry
+type EntryType string
+
+const (
+	EntryNote     EntryType = "note"
+	EntryDecision EntryType = "decision"
+	EntryProgress EntryType = "progress"
+	EntryQuestion EntryType = "question"
+	EntryBlocker  EntryType = "blocker"
+)
+
+// EntryTypes lists the valid log entry types
+var EntryTypes = []EntryType{EntryDecision, EntryProgress, EntryQuestion, EntryBlocker, EntryNote}
+
+// ParseEntryType validates a log entry type, defaulting empty to note
+func ParseEntryType(s string) (EntryType, error) {
+	if s == "" {
+		return EntryNote, nil
+	}
+	for _, t := range EntryTypes {
+		if string(t) == s {
+			return t, nil
+		}
+	}
+	return "", fmt.Errorf("invalid log entry type %q (want decision, progress, question, blocker or note)", s)
 }
 
 // LogEntry represents a timestamped log entry
 type LogEntry struct {
+	ID        int64 // Store row ID, ordering entries that share a timestamp
 	Timestamp time.Time
 	Content   string
+	Type      EntryType
+
+	// Decision details (only meaningful for decision entries)
+	Alternatives []string // Options considered and rejected
+	Rationale    string   // Why this option was chosen
+
+	// Attribution
+	Author    string // Who wrote the entry, if given explicitly
+	Client    string // MCP client identity from the initialize handshake
+	SessionID string // streamctl serve process that recorded the entry
+
+	Superseded bool // Covered by the workstream summary
+}
+
+// Attribution returns who wrote the entry: the explicit author if set,
+// otherwise the MCP client identity
+func (e LogEntry) Attribution() string {
+	return attribution(e.Author, e.Client)
+}
+
+func attribution(author, client string) string {
+	if author != "" {
+		return author
+	}
+	return client
 }
 
 // Dependency represents a blocking relationship between workstreams
@@ -53,30 +152,60 @@ type Workstream struct {
 	// Status section
 	State      State
 	LastUpdate time.Time
-	Owner      string // Optional
-	NeedsHelp  bool   // Flag indicating workstream is stuck/at-risk
+	CreatedAt  time.Time // When it was added to the store; zero if unknown
+	Owner      string    // Optional
+	NeedsHelp  bool      // Flag indicating workstream is stuck/at-risk
+
+	HelpQuestion string // Open help request question, if any
 
 	// Content sections
 	Objective string
 	Plan      []PlanItem
 	Log       []LogEntry
 
+	// Rolling summary that supersedes log entries up to SummaryThrough
+	Summary        string
+	SummaryThrough time.Time
+
 	// Dependencies
 	BlockedBy []Dependency // Workstreams that block this one
 	Blocks    []Dependency // Workstreams this one blocks
 }
 
+// TaskProgress returns how many top-level tasks are done or skipped, out of
+// all top-level tasks
+func (ws Workstream) TaskProgress() (done, total int) {
+	for _, p := range ws.Plan {
+		if p.Status == TaskDone || p.Status == TaskSkipped {
+			done++
+		}
+	}
+	return done, len(ws.Plan)
+}
+
 // ActivityEntry represents a log entry with workstream context
 type ActivityEntry struct {
+	ID                int64
 	WorkstreamName    string
 	WorkstreamProject string
 	Timestamp         time.Time
 	Content           string
+	Type              EntryType
+	Alternatives      []string // Decision entries only
+	Rationale         string   // Decision entries only
+	Author            string
+	Client            string
+	SessionID         string
 	NeedsHelp         bool   // Workstream needs help flag
 	BlockedBy         string // First blocker name if blocked
 	RelativeTime      string // Human-readable relative time
 }
 
+// Attribution returns who wrote the entry (see LogEntry.Attribution)
+func (e ActivityEntry) Attribution() string {
+	return attribution(e.Author, e.Client)
+}
+
 // Milestone represents a cross-workstream gate/checkpoint
 type Milestone struct {
 	Name         string
@@ -87,9 +216,131 @@ type Milestone struct {
 	Requirements []MilestoneRequirement
 }
 
+// DoneRequirements returns how many required workstreams are done
+func (m Milestone) DoneRequirements() int {
+	n := 0
+	for _, req := range m.Requirements {
+		if req.WorkstreamState == StateDone {
+			n++
+		}
+	}
+	return n
+}
+
 // MilestoneRequirement represents a workstream required for a milestone
 type MilestoneRequirement struct {
 	WorkstreamProject string
 	WorkstreamName    string
 	WorkstreamState   State // Current state of the workstream
 }
+
+// Session is an agent's working session on a project, from session_start to
+// session_end
+type Session struct {
+	ID        string
+	Project   string
+	Agent     string
+	Client    string // MCP client identity, if known
+	StartedAt time.Time
+	EndedAt   time.Time // Zero while the session is active
+	NextSteps string    // Handoff note recorded at session_end
+}
+
+// Active reports whether the session has not ended
+func (s Session) Active() bool {
+	return s.EndedAt.IsZero()
+}
+
+// Duration returns how long the session lasted, or has lasted so far
+func (s Session) Duration() time.Duration {
+	if s.Active() {
+		return time.Since(s.StartedAt)
+	}
+	return s.EndedAt.Sub(s.StartedAt)
+}
+
+// Briefing summarises what an agent needs to know at the start of a session
+type Briefing struct {
+	Project         string
+	Agent           string
+	Previous        *Session        // The agent's last ended session, if any
+	Claimed         []Workstream    // Workstreams owned by the agent
+	InProgressTasks []BriefingTask  // In-progress tasks in claimed workstreams
+	NeedsHelp       []Workstream    // Workstreams flagged needs_help
+	RecentActivity  []ActivityEntry // Activity by others since the previous session
+}
+
+// BriefingTask is a task together with the workstream it belongs to
+type BriefingTask struct {
+	Workstream string
+	Task       PlanItem
+}
+
+// HelpRequest is a question an agent raised with needs_help, and the human's
+// answer once given
+type HelpRequest struct {
+	ID         int64
+	Question   string
+	AskedBy    string
+	AskedAt    time.Time
+	Answer     string
+	AnsweredBy string
+	AnsweredAt time.Time // Zero until answered
+}
+
+// Answered reports whether the request has been answered
+func (h HelpRequest) Answered() bool {
+	return !h.AnsweredAt.IsZero()
+}
+
+// EventType identifies a kind of change worth notifying people about
+type EventType string
+
+const (
+	EventStateChanged       EventType = "state_changed"
+	EventNeedsHelpRaised    EventType = "needs_help_raised"
+	EventNeedsHelpCleared   EventType = "needs_help_cleared"
+	EventTaskDone           EventType = "task_done"
+	EventMilestoneCompleted EventType = "milestone_completed"
+	EventClaimExpired       EventType = "claim_expired"
+)
+
+// EventTypes lists all event types
+var EventTypes = []EventType{
+	EventStateChanged, EventNeedsHelpRaised, EventNeedsHelpCleared,
+	EventTaskDone, EventMilestoneCompleted, EventClaimExpired,
+}
+
+// ParseEventType validates an event type name
+func ParseEventType(s string) (EventType, error) {
+	for _, t := range EventTypes {
+		if string(t) == s {
+			return t, nil
+		}
+	}
+	return "", fmt.Errorf("invalid event type: %s (valid: state_changed, needs_help_raised, needs_help_cleared, task_done, milestone_completed, claim_expired)", s)
+}
+
+// Event records a change to a workstream or milestone. Data holds
+// type-specific details, e.g. "from" and "to" for state changes.
+type Event struct {
+	ID         int64
+	Type       EventType
+	Project    string
+	Workstream string // Empty for milestone events
+	Milestone  string // Set for milestone events
+	Data       map[string]string
+	CreatedAt  time.Time
+}
+
+// Snapshot is a workstream's headline status without its plan or log, cheap
+// enough to poll
+type Snapshot struct {
+	Project    string
+	Name       string
+	State      State
+	Owner      string
+	NeedsHelp  bool
+	Blocked    bool // Blocked state or has blockers
+	LastUpdate time.Time
+}
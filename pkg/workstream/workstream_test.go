@@ -49,3 +49,89 @@ func TestWorkstreamHasDependencies(t *testing.T) {
 		t.Errorf("Blocks len = %d, want 1", len(ws.Blocks))
 	}
 }
+
+func TestRollUpStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		children []TaskStatus
+		want     TaskStatus
+	}{
+		{"no subtasks", nil, ""},
+		{"all pending", []TaskStatus{TaskPending, TaskPending}, TaskPending},
+		{"one started", []TaskStatus{TaskPending, TaskInProgress}, TaskInProgress},
+		{"partly done", []TaskStatus{TaskDone, TaskPending}, TaskInProgress},
+		{"all done", []TaskStatus{TaskDone, TaskDone}, TaskDone},
+		{"done and skipped", []TaskStatus{TaskDone, TaskSkipped}, TaskDone},
+		{"all skipped", []TaskStatus{TaskSkipped, TaskSkipped}, TaskSkipped},
+	}
+
+	for _, tt := range tests {
+		if got := RollUpStatus(tt.children); got != tt.want {
+			t.Errorf("%s: RollUpStatus() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPlanItemDoneChildren(t *testing.T) {
+	item := PlanItem{
+		Text: "Parent",
+		Children: []PlanItem{
+			{Text: "a", Status: TaskDone},
+			{Text: "b", Status: TaskSkipped},
+			{Text: "c", Status: TaskPending},
+		},
+	}
+	if got := item.DoneChildren(); got != 2 {
+		t.Errorf("DoneChildren() = %d, want 2", got)
+	}
+}
+
+func TestMilestoneDoneRequirements(t *testing.T) {
+	m := Milestone{Requirements: []MilestoneRequirement{
+		{WorkstreamName: "a", WorkstreamState: StateDone},
+		{WorkstreamName: "b", WorkstreamState: StateInProgress},
+	}}
+	if got := m.DoneRequirements(); got != 1 {
+		t.Errorf("DoneRequirements() = %d, want 1", got)
+	}
+}
+
+func TestWorkstreamTaskProgress(t *testing.T) {
+	ws := Workstream{Plan: []PlanItem{
+		{Text: "a", Status: TaskDone},
+		{Text: "b", Status: TaskInProgress, Children: []PlanItem{{Text: "b1", Status: TaskDone}}},
+		{Text: "c", Status: TaskSkipped},
+	}}
+	if done, total := ws.TaskProgress(); done != 2 || total != 3 {
+		t.Errorf("TaskProgress() = %d/%d, want 2/3", done, total)
+	}
+}
+
+func TestParseEntryType(t *testing.T) {
+	if got, err := ParseEntryType(""); err != nil || got != EntryNote {
+		t.Errorf("ParseEntryType(\"\") = %q, %v; want note", got, err)
+	}
+	if got, err := ParseEntryType("decision"); err != nil || got != EntryDecision {
+		t.Errorf("ParseEntryType(decision) = %q, %v", got, err)
+	}
+	if _, err := ParseEntryType("rant"); err == nil {
+		t.Errorf("Expected error for unknown type")
+	}
+}
+
+func TestFilterLog(t *testing.T) {
+	log := []LogEntry{
+		{Content: "a", Type: EntryDecision},
+		{Content: "b"},
+		{Content: "c", Type: EntryNote},
+	}
+	if got := FilterLog(log, ""); len(got) != 3 {
+		t.Errorf("Empty filter should return all entries, got %d", len(got))
+	}
+	if got := FilterLog(log, EntryDecision); len(got) != 1 || got[0].Content != "a" {
+		t.Errorf("Decision filter = %+v", got)
+	}
+	if got := FilterLog(log, EntryNote); len(got) != 2 {
+		t.Errorf("Untyped entries should count as notes, got %d", len(got))
+	}
+}